@@ -71,6 +71,9 @@ func main() {
 	// Configure database connection for logging subsystem
 	models.SetDBConnection(db)
 
+	// Purge records and stations that stayed in the trash past the retention period
+	models.StartTrashPurge(db, time.Hour)
+
 	// Initialize rate limiter with configuration from environment variables
 	// This protects against DoS attacks by limiting request frequency
 	rateLimiter := utils.InitRateLimiter()
@@ -91,7 +94,7 @@ func main() {
 	// This controls which domains can access our API
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins: []string{"http://*"},
-		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders: []string{
 			"Accept",
 			"Authorization",
@@ -99,9 +102,10 @@ func main() {
 			"X-Refresh-Token",
 			"X-Csrf-Token",
 			"X-Request-ID",
+			"If-Match",
 		},
 		AllowCredentials: true,
-		ExposedHeaders:   []string{"Link", "X-Csrf-Token", "X-Request-ID"},
+		ExposedHeaders:   []string{"Link", "X-Csrf-Token", "X-Request-ID", "ETag"},
 	}))
 
 	// Add request ID middleware for tracing requests through the system
@@ -109,8 +113,8 @@ func main() {
 	r.Use(authMiddleware.ContextWithRequestID)
 
 	// Add standard middleware for logging, panic recovery
-	r.Use(middleware.Logger)    // Logs HTTP requests
-	r.Use(middleware.Recoverer) // Recovers from panics and returns 500 error
+	r.Use(authMiddleware.RequestLogger("/api/v1/calendar/")) // Logs HTTP requests, except calendar feeds whose URL holds a token
+	r.Use(middleware.Recoverer)                              // Recovers from panics and returns 500 error
 
 	// Add rate limiting middleware to prevent abuse
 	r.Use(authMiddleware.RateLimitMiddleware(rateLimiter))
@@ -165,6 +169,11 @@ func main() {
 		})
 	})
 
+	// Calendar duty feeds are authorized by the token in the URL alone.
+	// They are kept out of both request logs (see RequestLogger), where the
+	// token would leak.
+	r.Get("/api/v1/calendar/{token}.ics", handlers.ServeCalendarFeed(db))
+
 	r.Group(func(r chi.Router) {
 		r.Use(authMiddleware.RegistrationEnabledMiddleware(db))
 
@@ -183,6 +192,7 @@ func main() {
 
 		r.Post("/api/v1/auth-ping", handlers.AuthPing())
 		r.Get("/api/v1/profile", handlers.GetProfile(db))
+		r.Get("/api/v1/profile/depots", handlers.GetProfileDepots(db))
 		r.Put("/api/v1/profile-theme", handlers.UpdateProfileTheme(db))
 		r.Put("/api/v1/profile-avatar", handlers.UpdateProfileAvatar(db))
 		r.Post("/api/v1/change-password", handlers.ChangePassword(db))
@@ -191,10 +201,66 @@ func main() {
 		r.Post("/api/v1/client-logs", handlers.SaveClientLogs(db))
 		r.Get("/api/v1/stations", handlers.GetAllStations(db))
 		r.Get("/api/v1/stations/{id}", handlers.GetStationByID(db))
+		r.Get("/api/v1/stations/{stationId}/shifts", handlers.GetStationShifts(db))
+		r.Post("/api/v1/stations/{id}/track-conflicts", handlers.CheckTrackConflicts(db))
+		r.Get("/api/v1/stations/{id}/track-assignments", handlers.GetStationTrackAssignments(db))
+		r.Get("/api/v1/stations/{id}/parking-plan", handlers.GetParkingPlanProposal(db))
 
 		r.Get("/api/v1/field-mappings/map", handlers.GetFieldMappingsMap(db))
 		r.Get("/api/v1/antras-field-mappings/map", handlers.GetAntrasFieldMappingsMap(db))
 
+		// Train schedule records (owners, depot editors and admins may modify them; viewers may not)
+		r.Route("/api/v1/train-schedules", func(r chi.Router) {
+			r.Get("/", handlers.GetTrainSchedules(db))
+			r.Post("/", handlers.SaveTrainSchedules(db))
+			r.Post("/import", handlers.ImportTrainSchedules(db))
+			r.Get("/export", handlers.ExportTrainSchedules(db))
+			r.Get("/depots", handlers.GetTrainScheduleDepots(db))
+			r.Get("/rotations", handlers.GetVehicleRotations(db))
+			r.Get("/punctuality", handlers.GetPunctuality(db))
+			r.Get("/editable-fields", handlers.GetActiveEditableFields(db))
+			r.Get("/trash", handlers.GetTrainScheduleTrash(db))
+			r.Post("/trash/{id}/restore", handlers.RestoreTrainSchedule(db))
+
+			// Duplicate cleanup and purging the trash are limited to admins
+			r.Group(func(r chi.Router) {
+				r.Use(authMiddleware.RoleMiddleware("admin"))
+				r.Get("/duplicates", handlers.FindTrainScheduleDuplicates(db))
+				r.Post("/duplicates/merge", handlers.MergeTrainScheduleDuplicates(db))
+				r.Delete("/trash/{id}", handlers.PurgeTrainSchedule(db))
+			})
+
+			r.Get("/{id}", handlers.GetTrainSchedule(db))
+			r.Patch("/{id}", handlers.UpdateTrainScheduleField(db))
+			r.Delete("/{id}", handlers.DeleteTrainSchedule(db))
+			r.Get("/{id}/revisions", handlers.GetTrainScheduleRevisions(db))
+			r.Post("/{id}/revisions/{revisionId}/revert", handlers.RevertTrainSchedule(db))
+		})
+
+		// Daily depot plans (depot editors publish them, admins reopen them)
+		r.Route("/api/v1/depot-plans", func(r chi.Router) {
+			r.Get("/", handlers.GetDepotPlans(db))
+			r.Post("/", handlers.CreateDepotPlan(db))
+			r.Get("/{id}", handlers.GetDepotPlan(db))
+			r.Put("/{id}/status", handlers.UpdateDepotPlanStatus(db))
+		})
+
+		// Track assignments of the parking plan (depot editors and admins may change them; viewers may not)
+		r.Route("/api/v1/track-assignments", func(r chi.Router) {
+			r.Post("/", handlers.CreateTrackAssignment(db))
+			r.Get("/{id}", handlers.GetTrackAssignment(db))
+			r.Put("/{id}", handlers.UpdateTrackAssignment(db))
+			r.Delete("/{id}", handlers.DeleteTrackAssignment(db))
+		})
+
+		// Antras workbook imports
+		r.Route("/api/v1/antras/imports", func(r chi.Router) {
+			r.Get("/", handlers.GetAntrasImports(db))
+			r.Post("/", handlers.ImportAntrasWorkbook(db))
+			r.Get("/{id}", handlers.GetAntrasImport(db))
+		})
+		r.Get("/api/v1/antras/crew-check", handlers.CheckCrewDuties(db))
+
 		// Admin-only routes group with additional role-based middleware
 		r.Group(func(r chi.Router) {
 			r.Use(authMiddleware.RoleMiddleware("admin"))
@@ -225,11 +291,23 @@ func main() {
 			r.Post("/api/v1/cache/clear", handlers.ClearCache(appCache))
 
 			r.Post("/api/v1/stations", handlers.CreateStation(db))
+			r.Get("/api/v1/stations/trash", handlers.GetStationTrash(db))
+			r.Post("/api/v1/stations/trash/{id}/restore", handlers.RestoreStation(db))
+			r.Delete("/api/v1/stations/trash/{id}", handlers.PurgeStation(db))
+			r.Post("/api/v1/trash/purge", handlers.PurgeExpiredTrash(db))
 			r.Put("/api/v1/stations/{id}", handlers.UpdateStation(db))
 			r.Delete("/api/v1/stations/{id}", handlers.DeleteStation(db))
 			r.Post("/api/v1/stations/{stationId}/tracks", handlers.AddTrack(db))
 			r.Put("/api/v1/tracks/{trackId}", handlers.UpdateTrack(db))
 			r.Delete("/api/v1/tracks/{trackId}", handlers.DeleteTrack(db))
+			r.Post("/api/v1/stations/{stationId}/shifts", handlers.CreateStationShift(db))
+			r.Put("/api/v1/shifts/{shiftId}", handlers.UpdateStationShift(db))
+			r.Delete("/api/v1/shifts/{shiftId}", handlers.DeleteStationShift(db))
+
+			// Depot teams deciding who may edit and view schedules touching a depot
+			r.Get("/api/v1/stations/{stationId}/members", handlers.GetDepotMembers(db))
+			r.Put("/api/v1/stations/{stationId}/members", handlers.SetDepotMember(db))
+			r.Delete("/api/v1/stations/{stationId}/members/{userId}", handlers.RemoveDepotMember(db))
 
 			// Field mappings management endpoints
 			r.Get("/api/v1/field-mappings", handlers.GetFieldMappings(db))
@@ -250,6 +328,42 @@ func main() {
 				r.Delete("/{id}", handlers.DeleteAntrasFieldMapping(db))
 				r.Post("/reorder", handlers.ReorderAntrasFieldMappings(db))
 			})
+
+			// Vehicle display name rules used by imports
+			r.Route("/api/v1/vehicle-name-rules", func(r chi.Router) {
+				r.Get("/", handlers.GetVehicleNameRules(db))
+				r.Get("/{id}", handlers.GetVehicleNameRule(db))
+				r.Post("/", handlers.CreateVehicleNameRule(db))
+				r.Put("/{id}", handlers.UpdateVehicleNameRule(db))
+				r.Delete("/{id}", handlers.DeleteVehicleNameRule(db))
+				r.Post("/test", handlers.TestVehicleNameRules(db))
+			})
+
+			// Train schedule fields users may edit and the values they accept
+			r.Route("/api/v1/editable-fields", func(r chi.Router) {
+				r.Get("/", handlers.GetEditableFields(db))
+				r.Get("/{id}", handlers.GetEditableField(db))
+				r.Post("/", handlers.CreateEditableField(db))
+				r.Put("/{id}", handlers.UpdateEditableField(db))
+				r.Delete("/{id}", handlers.DeleteEditableField(db))
+			})
+
+			// Recurring timetable templates and the schedule generator
+			r.Route("/api/v1/timetable-templates", func(r chi.Router) {
+				r.Get("/", handlers.GetTimetableTemplates(db))
+				r.Get("/{id}", handlers.GetTimetableTemplate(db))
+				r.Post("/", handlers.CreateTimetableTemplate(db))
+				r.Put("/{id}", handlers.UpdateTimetableTemplate(db))
+				r.Delete("/{id}", handlers.DeleteTimetableTemplate(db))
+				r.Post("/generate", handlers.GenerateTrainSchedules(db))
+			})
+
+			// Per-employee iCalendar duty feeds
+			r.Route("/api/v1/calendar-feeds", func(r chi.Router) {
+				r.Get("/", handlers.GetCalendarFeeds(db))
+				r.Post("/", handlers.CreateCalendarFeed(db))
+				r.Delete("/{id}", handlers.RevokeCalendarFeed(db))
+			})
 		})
 	})
 
@@ -0,0 +1,271 @@
+// backend/internal/crewduty/crewduty.go
+package crewduty
+
+// This package checks crew duties listed in Antras imports: how long each
+// duty lasts, how much rest an employee gets between consecutive duties and
+// whether one personnel number is assigned to duties that overlap.
+//
+// Antras lists a duty on every row the employee works, so duties are first
+// collected from the rows: rows with the same personnel number, duty code
+// and duty times describe one duty.
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"yopta-template/internal/models"
+	"yopta-template/internal/timeparse"
+)
+
+// Violation kinds
+const (
+	DutyTooLong  = "duty_too_long"  // A duty lasts longer than MaxDutyMinutes
+	RestTooShort = "rest_too_short" // Rest between two duties is shorter than MinRestMinutes
+	DutyOverlap  = "overlap"        // Two duties of the same employee overlap
+)
+
+// Limits are the working time rules duties are checked against.
+type Limits struct {
+	MaxDutyMinutes int `json:"max_duty_minutes"` // Longest allowed duty
+	MinRestMinutes int `json:"min_rest_minutes"` // Shortest allowed rest between duties
+}
+
+// DefaultLimits are used when no limits are configured:
+// a duty of at most 12 hours followed by at least 12 hours of rest.
+var DefaultLimits = Limits{MaxDutyMinutes: 12 * 60, MinRestMinutes: 12 * 60}
+
+// Validate checks that the limits are usable.
+func (l Limits) Validate() error {
+	if l.MaxDutyMinutes <= 0 {
+		return fmt.Errorf("max duty minutes must be positive")
+	}
+	if l.MinRestMinutes < 0 {
+		return fmt.Errorf("min rest minutes must not be negative")
+	}
+	return nil
+}
+
+// Duty is one duty of an employee, collected from the rows listing it.
+type Duty struct {
+	Duty     string    `json:"duty"`     // Duty code
+	Start    time.Time `json:"start"`    // Duty start
+	End      time.Time `json:"end"`      // Duty end
+	Minutes  int       `json:"minutes"`  // Duty length
+	Stations []string  `json:"stations"` // Stations of the rows listing the duty
+	Trains   []string  `json:"trains"`   // Trains of the rows listing the duty
+}
+
+// Violation is a broken limit.
+type Violation struct {
+	Kind         string    `json:"kind"`                    // Violation kind
+	Duty         string    `json:"duty"`                    // Duty code
+	OtherDuty    string    `json:"other_duty,omitempty"`    // Previous duty for rest and overlap violations
+	Start        time.Time `json:"start"`                   // Start of the violating period
+	End          time.Time `json:"end"`                     // End of the violating period
+	Minutes      int       `json:"minutes"`                 // Duty length, rest or overlap length
+	LimitMinutes int       `json:"limit_minutes,omitempty"` // Broken limit
+}
+
+// Day lists the duties and violations of an employee on one day.
+// Duties and violations belong to the Vilnius day their duty starts on.
+type Day struct {
+	Date        string      `json:"date"`         // YYYY-MM-DD
+	DutyMinutes int         `json:"duty_minutes"` // Total length of the day's duties
+	Duties      []Duty      `json:"duties"`       // Duties in time order
+	Violations  []Violation `json:"violations"`   // Violations in time order
+}
+
+// Employee is the check result of one personnel number.
+type Employee struct {
+	PersonnelNumber string `json:"personnel_number"`
+	Name            string `json:"name"`
+	Occupation      string `json:"occupation"` // "M" (driver), "K" (conductor) or empty
+	Violations      int    `json:"violations"` // Number of violations on all days
+	Days            []Day  `json:"days"`       // Days in date order
+}
+
+// Report is the result of a check.
+type Report struct {
+	Limits     Limits     `json:"limits"`     // Limits used
+	Employees  []Employee `json:"employees"`  // Employees ordered by personnel number
+	Violations int        `json:"violations"` // Number of violations of all employees
+	Skipped    int        `json:"skipped"`    // Rows without both duty times, left out
+}
+
+// Check collects the duties from the crew assignments and checks them.
+// Only duties starting in [from, to) are reported; earlier duties are still
+// needed to check the rest before the first reported one. A nil bound is open.
+func Check(assignments []models.AntrasCrewAssignment, limits Limits, from, to *time.Time) Report {
+	report := Report{Limits: limits, Employees: []Employee{}}
+
+	byPerson := make(map[string]*Employee)
+	duties := make(map[string][]*Duty)
+	seen := make(map[string]*Duty)
+	var numbers []string
+
+	for _, a := range assignments {
+		if a.PersonnelNumber == "" {
+			continue
+		}
+		if a.DutyStart == nil || a.DutyEnd == nil {
+			report.Skipped++
+			continue
+		}
+
+		start, end := *a.DutyStart, *a.DutyEnd
+		// A duty ending after midnight may be written without the (+1) marker
+		if end.Before(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+
+		employee := byPerson[a.PersonnelNumber]
+		if employee == nil {
+			employee = &Employee{PersonnelNumber: a.PersonnelNumber, Days: []Day{}}
+			byPerson[a.PersonnelNumber] = employee
+			numbers = append(numbers, a.PersonnelNumber)
+		}
+		if employee.Name == "" {
+			employee.Name = a.Name
+		}
+		if employee.Occupation == "" {
+			employee.Occupation = a.Occupation
+		}
+
+		key := a.PersonnelNumber + "|" + a.Duty + "|" + start.UTC().Format(time.RFC3339) + "|" + end.UTC().Format(time.RFC3339)
+		duty := seen[key]
+		if duty == nil {
+			duty = &Duty{
+				Duty:     a.Duty,
+				Start:    start,
+				End:      end,
+				Minutes:  int(end.Sub(start).Minutes()),
+				Stations: []string{},
+				Trains:   []string{},
+			}
+			seen[key] = duty
+			duties[a.PersonnelNumber] = append(duties[a.PersonnelNumber], duty)
+		}
+		duty.Stations = appendUnique(duty.Stations, a.StationCode)
+		duty.Trains = appendUnique(duty.Trains, a.TrainNo)
+	}
+
+	sort.Strings(numbers)
+	for _, number := range numbers {
+		employee := byPerson[number]
+		checkEmployee(employee, duties[number], limits, from, to)
+		if len(employee.Days) == 0 {
+			continue
+		}
+		report.Violations += employee.Violations
+		report.Employees = append(report.Employees, *employee)
+	}
+
+	return report
+}
+
+// checkEmployee checks the duties of one employee and fills in the days.
+func checkEmployee(employee *Employee, duties []*Duty, limits Limits, from, to *time.Time) {
+	sort.SliceStable(duties, func(a, b int) bool {
+		if !duties[a].Start.Equal(duties[b].Start) {
+			return duties[a].Start.Before(duties[b].Start)
+		}
+		return duties[a].End.Before(duties[b].End)
+	})
+
+	days := make(map[string]*Day)
+	var dates []string
+	day := func(t time.Time) *Day {
+		date := t.In(timeparse.Vilnius).Format("2006-01-02")
+		d := days[date]
+		if d == nil {
+			d = &Day{Date: date, Duties: []Duty{}, Violations: []Violation{}}
+			days[date] = d
+			dates = append(dates, date)
+		}
+		return d
+	}
+	inRange := func(t time.Time) bool {
+		return (from == nil || !t.Before(*from)) && (to == nil || t.Before(*to))
+	}
+
+	// latest is the duty that ends last among those checked so far
+	var latest *Duty
+	for _, duty := range duties {
+		reported := inRange(duty.Start)
+		var violations []Violation
+
+		if duty.Minutes > limits.MaxDutyMinutes {
+			violations = append(violations, Violation{
+				Kind:         DutyTooLong,
+				Duty:         duty.Duty,
+				Start:        duty.Start,
+				End:          duty.End,
+				Minutes:      duty.Minutes,
+				LimitMinutes: limits.MaxDutyMinutes,
+			})
+		}
+
+		if latest != nil {
+			if duty.Start.Before(latest.End) {
+				end := minTime(duty.End, latest.End)
+				violations = append(violations, Violation{
+					Kind:      DutyOverlap,
+					Duty:      duty.Duty,
+					OtherDuty: latest.Duty,
+					Start:     duty.Start,
+					End:       end,
+					Minutes:   int(end.Sub(duty.Start).Minutes()),
+				})
+			} else if rest := int(duty.Start.Sub(latest.End).Minutes()); rest < limits.MinRestMinutes {
+				violations = append(violations, Violation{
+					Kind:         RestTooShort,
+					Duty:         duty.Duty,
+					OtherDuty:    latest.Duty,
+					Start:        latest.End,
+					End:          duty.Start,
+					Minutes:      rest,
+					LimitMinutes: limits.MinRestMinutes,
+				})
+			}
+		}
+		if latest == nil || duty.End.After(latest.End) {
+			latest = duty
+		}
+
+		if !reported {
+			continue
+		}
+		d := day(duty.Start)
+		d.Duties = append(d.Duties, *duty)
+		d.DutyMinutes += duty.Minutes
+		d.Violations = append(d.Violations, violations...)
+		employee.Violations += len(violations)
+	}
+
+	sort.Strings(dates)
+	for _, date := range dates {
+		employee.Days = append(employee.Days, *days[date])
+	}
+}
+
+// minTime returns the earlier of two times.
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// appendUnique appends a non-empty value that is not in the list yet.
+func appendUnique(list []string, value string) []string {
+	if value == "" {
+		return list
+	}
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
@@ -0,0 +1,220 @@
+// backend/internal/exporter/schedule.go
+package exporter
+
+// This package writes train schedule records back out in the layout of the
+// planning system export, so an edited plan can be returned to the planning
+// department. Columns are named and ordered by the field mappings, the same
+// ones the importer reads them with.
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"yopta-template/internal/models"
+	"yopta-template/internal/timeparse"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// NotesColumn is the header of the column holding dispatcher notes,
+// which the planning export does not have.
+const NotesColumn = "Notes"
+
+// Table is an export laid out as rows of cells, header first.
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// ScheduleTable lays out records in the column order of the field mappings.
+//
+// Each row starts from the imported row kept in the record's raw data, so
+// columns the record does not model are exported as they were received.
+// Values a dispatcher can change (train numbers, vehicle, locations, times,
+// tracks, crew and duties) are taken from the stored record. Notes are added
+// as the last column.
+//
+// Parameters:
+//   - records: Records to export, in export order
+//   - mappings: Field mappings ordered by sort_order
+//
+// Returns:
+//   - Header and rows ready to be written as CSV or XLSX
+func ScheduleTable(records []models.TrainSchedule, mappings []models.FieldMapping) Table {
+	table := Table{Header: make([]string, 0, len(mappings)+1)}
+	for _, m := range mappings {
+		table.Header = append(table.Header, m.ExternalName)
+	}
+	table.Header = append(table.Header, NotesColumn)
+
+	for i := range records {
+		values := ScheduleRow(&records[i])
+		row := make([]string, 0, len(table.Header))
+		for _, m := range mappings {
+			row = append(row, values[m.InternalName])
+		}
+		row = append(row, records[i].Notes)
+		table.Rows = append(table.Rows, row)
+	}
+
+	return table
+}
+
+// ScheduleRow returns the values of a record by internal field name.
+func ScheduleRow(s *models.TrainSchedule) map[string]string {
+	row := make(map[string]string)
+	if s.RawData != "" {
+		var raw map[string]any
+		if err := json.Unmarshal([]byte(s.RawData), &raw); err == nil {
+			for name, value := range raw {
+				if text, ok := value.(string); ok {
+					row[name] = text
+				}
+			}
+		}
+	}
+
+	// The importer reads these from the first non-empty of several columns,
+	// so a changed value is written back to the column it came from
+	setFirst(row, s.TrainNumberDeparture, "departureTrainNumber", "departureNetworkTrainNumber")
+	setFirst(row, s.TrainNumberArrival, "arrivalTrainNumber", "arrivalNetworkTrainNumber")
+	setFirst(row, s.VehicleName, "vehicle", "vehicleName")
+	setFirst(row, s.StartingLocation, "startingLocation", "departureDepot")
+	setFirst(row, s.EndLocation, "endLocation", "arrivalDepot")
+
+	row["startingTrack"] = s.StartingTrack
+	row["targetTrack"] = s.TargetTrack
+	row["departureEmployee1"] = s.Employee1Departure
+	row["arrivalEmployee1"] = s.Employee1Arrival
+	row["departureDuty"] = s.DutyDeparture
+	row["arrivalDuty"] = s.DutyArrival
+
+	setDateTime(row, s.DepartureDateTime, "departureDate", "departurePlanned")
+	setDateTime(row, s.ArrivalDateTime, "arrivalDate", "arrivalPlanned")
+	if row["date"] == "" {
+		if t := firstTime(s.DepartureDateTime, s.ArrivalDateTime); t != nil {
+			row["date"] = t.In(timeparse.Vilnius).Format("2006-01-02")
+		}
+	}
+
+	return row
+}
+
+// setFirst stores value in the first non-empty of the columns, or in the
+// first column if all are empty, unless the columns already yield it.
+func setFirst(row map[string]string, value string, columns ...string) {
+	for _, column := range columns {
+		if row[column] == "" {
+			continue
+		}
+		if row[column] != value {
+			row[column] = value
+		}
+		return
+	}
+	if value != "" {
+		row[columns[0]] = value
+	}
+}
+
+// setDateTime stores a time as a Vilnius date and HH:MM clock, unless the
+// imported cells already describe the same time. Keeping them preserves
+// forms such as "00:24 (+1)" that the planning system uses.
+func setDateTime(row map[string]string, t *time.Time, dateColumn, clockColumn string) {
+	imported, err := timeparse.ParseDateTime(firstNonEmpty(row[dateColumn], row["date"]), row[clockColumn])
+	if err == nil && sameTime(imported, t) {
+		return
+	}
+
+	if t == nil {
+		row[clockColumn] = ""
+		return
+	}
+	local := t.In(timeparse.Vilnius)
+	row[dateColumn] = local.Format("2006-01-02")
+	row[clockColumn] = local.Format("15:04")
+}
+
+// sameTime reports whether two optional times are equal.
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+// firstTime returns the first non-nil time.
+func firstTime(times ...*time.Time) *time.Time {
+	for _, t := range times {
+		if t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+// firstNonEmpty returns the first non-empty string from the arguments.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// WriteCSV writes a table as comma-separated UTF-8 text. The byte order mark
+// lets Excel recognise the encoding of Lithuanian names.
+func WriteCSV(w io.Writer, table Table) error {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(table.Header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(table.Rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// WriteXLSX writes a table as a single-sheet workbook. All cells are text,
+// so train numbers and times keep their leading zeros.
+func WriteXLSX(w io.Writer, table Table, sheet string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName(f.GetSheetName(0), sheet); err != nil {
+		return fmt.Errorf("failed to name sheet: %w", err)
+	}
+
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return fmt.Errorf("failed to create sheet writer: %w", err)
+	}
+
+	rows := append([][]string{table.Header}, table.Rows...)
+	for i, row := range rows {
+		cells := make([]any, len(row))
+		for j, value := range row {
+			cells[j] = value
+		}
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		if err := sw.SetRow(cell, cells); err != nil {
+			return fmt.Errorf("failed to write row %d: %w", i+1, err)
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return fmt.Errorf("failed to write sheet: %w", err)
+	}
+
+	return f.Write(w)
+}
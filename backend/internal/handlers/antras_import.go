@@ -0,0 +1,213 @@
+// backend/internal/handlers/antras_import.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"yopta-template/internal/importer"
+	"yopta-template/internal/models"
+	"yopta-template/internal/vehiclename"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ImportAntrasWorkbook parses an uploaded Antras .xlsx workbook and stores
+// every valid row together with its crew members. Each upload is saved as an
+// import with its counters and errors, so it can be reviewed later.
+func ImportAntrasWorkbook(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+		if err := r.ParseMultipartForm(maxImportSize); err != nil {
+			http.Error(w, "Failas per didelis arba neteisingas formatas", http.StatusBadRequest)
+			return
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "Nepavyko gauti failo", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		mappings, err := models.GetAllAntrasFieldMappings(db)
+		if err != nil {
+			http.Error(
+				w,
+				"Nepavyko gauti Antras laukų atvaizdavimų: "+err.Error(),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		rules, err := models.GetActiveVehicleNameRules(db)
+		if err != nil {
+			http.Error(
+				w,
+				"Nepavyko gauti riedmenų pavadinimų taisyklių: "+err.Error(),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		names, err := vehiclename.New(rules)
+		if err != nil {
+			http.Error(
+				w,
+				"Netinkamos riedmenų pavadinimų taisyklės: "+err.Error(),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		result, err := importer.ParseAntrasWorkbook(file, header.Filename, mappings, names)
+		if err != nil {
+			http.Error(w, "Netinkamas failo formatas: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		imp := &result.Import
+		imp.UserID = &userID
+		imp.Errors, err = json.Marshal(result.Errors)
+		if err != nil {
+			http.Error(w, "Nepavyko apdoroti klaidų sąrašo", http.StatusInternalServerError)
+			return
+		}
+
+		if err := models.SaveAntrasImport(db, imp); err != nil {
+			http.Error(
+				w,
+				"Nepavyko išsaugoti Antras importo: "+err.Error(),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":                 imp.ID,
+			"file_name":          imp.FileName,
+			"sheets":             result.Sheets,
+			"sheets_count":       imp.SheetsCount,
+			"records_count":      imp.RecordsCount,
+			"skipped_sheets":     imp.SkippedSheets,
+			"duplicates_skipped": imp.DuplicatesSkipped,
+			"errors":             result.Errors,
+		})
+	}
+}
+
+// GetAntrasImports returns the list of past Antras imports without their rows.
+// Admins see every import, other users the imports holding rows of their depots.
+func GetAntrasImports(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		editor, err := models.NewEditor(db, userID, isAdmin, getRequestIDFromContext(r))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų teisių", http.StatusInternalServerError)
+			return
+		}
+
+		imports, err := models.GetAntrasImports(db, editor.AntrasStations())
+		if err != nil {
+			http.Error(
+				w,
+				"Nepavyko gauti Antras importų: "+err.Error(),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		if imports == nil {
+			imports = []models.AntrasImport{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(imports)
+	}
+}
+
+// GetAntrasImport returns a single Antras import with its rows and crew members.
+// Crew names and phone numbers are personal data: users other than admins get
+// only the rows of their depots, and no import without such rows.
+func GetAntrasImport(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Netinkamas ID", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		editor, err := models.NewEditor(db, userID, isAdmin, getRequestIDFromContext(r))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų teisių", http.StatusInternalServerError)
+			return
+		}
+
+		imp, err := models.GetAntrasImportByID(db, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Importas nerastas", http.StatusNotFound)
+			} else {
+				http.Error(
+					w,
+					"Nepavyko gauti Antras importo: "+err.Error(),
+					http.StatusInternalServerError,
+				)
+			}
+			return
+		}
+
+		imp.Records, err = models.GetAntrasRecords(db, id)
+		if err != nil {
+			http.Error(
+				w,
+				"Nepavyko gauti importo įrašų: "+err.Error(),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+		if stations := editor.AntrasStations(); stations != nil {
+			imp.Records = models.FilterAntrasRecords(imp.Records, stations)
+			if len(imp.Records) == 0 {
+				http.Error(w, "Importas nerastas", http.StatusNotFound)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(imp)
+	}
+}
@@ -0,0 +1,110 @@
+// backend/internal/handlers/bulk.go
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"yopta-template/internal/models"
+)
+
+// ndjsonContentType is the media type of streamed progress responses
+const ndjsonContentType = "application/x-ndjson"
+
+// bulkProgress streams the progress of a bulk save as newline-delimited JSON.
+// Clients opt in with "Accept: application/x-ndjson" and receive lines like
+// {"type":"progress","processed":500,"total":3200} followed by one
+// {"type":"result",...} line with the same body a plain request would get.
+type bulkProgress struct {
+	w       http.ResponseWriter
+	encoder *json.Encoder
+	flusher http.Flusher
+}
+
+// newBulkProgress starts a progress stream if the client asked for one.
+// Returns nil otherwise, in which case the handler answers with plain JSON.
+func newBulkProgress(w http.ResponseWriter, r *http.Request) *bulkProgress {
+	if !strings.Contains(r.Header.Get("Accept"), ndjsonContentType) {
+		return nil
+	}
+
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	return &bulkProgress{w: w, encoder: json.NewEncoder(w), flusher: flusher}
+}
+
+// report sends one progress line. Safe to call on a nil stream.
+func (p *bulkProgress) report(processed, total int) {
+	if p == nil {
+		return
+	}
+	p.encoder.Encode(map[string]any{
+		"type":      "progress",
+		"processed": processed,
+		"total":     total,
+	})
+	if p.flusher != nil {
+		p.flusher.Flush()
+	}
+}
+
+// callback returns report as a progress function, or nil if nothing is streamed.
+func (p *bulkProgress) callback() func(processed, total int) {
+	if p == nil {
+		return nil
+	}
+	return p.report
+}
+
+// writeBulkResult sends the final response of a bulk save.
+// When streaming, the status is already sent, so it is repeated in the body.
+func writeBulkResult(w http.ResponseWriter, p *bulkProgress, status int, body map[string]any) {
+	if p != nil {
+		body["type"] = "result"
+		body["status"] = status
+		p.encoder.Encode(body)
+		if p.flusher != nil {
+			p.flusher.Flush()
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeBulkError reports a bulk save that could not run at all.
+func writeBulkError(w http.ResponseWriter, p *bulkProgress, message string, status int) {
+	if p != nil {
+		writeBulkResult(w, p, status, map[string]any{"message": message})
+		return
+	}
+	http.Error(w, message, status)
+}
+
+// saveResultStatus picks the HTTP status for a bulk save. The request
+// succeeds if anything was saved; otherwise the most telling failure wins.
+func saveResultStatus(result *models.TrainScheduleSaveResult) int {
+	if result.Processed > 0 || len(result.Failed) == 0 {
+		return http.StatusOK
+	}
+
+	forbidden := 0
+	for _, failure := range result.Failed {
+		switch failure.Code {
+		case models.SaveErrorConflict:
+			return http.StatusConflict
+		case models.SaveErrorForbidden:
+			forbidden++
+		}
+	}
+	if forbidden == len(result.Failed) {
+		return http.StatusForbidden
+	}
+	return http.StatusUnprocessableEntity
+}
@@ -0,0 +1,188 @@
+// backend/internal/handlers/calendar_feed.go
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"yopta-template/internal/ical"
+	"yopta-template/internal/models"
+	"yopta-template/internal/timeparse"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Time range published by a duty feed, relative to the request
+const (
+	calendarFeedPast   = 14 * 24 * time.Hour
+	calendarFeedFuture = 60 * 24 * time.Hour
+)
+
+// calendarFeedRefresh tells calendar apps how often to reload a feed
+const calendarFeedRefresh = 30 * time.Minute
+
+// CalendarFeedRequest identifies the employee of a new feed.
+type CalendarFeedRequest struct {
+	PersonnelNumber string `json:"personnel_number"` // Antras personnel number
+	EmployeeName    string `json:"employee_name"`    // Name as written in the schedule employee fields
+}
+
+// GetCalendarFeeds returns all duty feeds, including revoked ones.
+func GetCalendarFeeds(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		feeds, err := models.GetCalendarFeeds(db)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti kalendorių: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(feeds)
+	}
+}
+
+// CreateCalendarFeed creates a duty feed for an employee. The response holds
+// the feed URL with its token; the token is not stored and cannot be shown again.
+func CreateCalendarFeed(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		var request CalendarFeedRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Neteisingas užklausos formatas", http.StatusBadRequest)
+			return
+		}
+		request.PersonnelNumber = strings.TrimSpace(request.PersonnelNumber)
+		request.EmployeeName = strings.TrimSpace(request.EmployeeName)
+		if request.PersonnelNumber == "" && request.EmployeeName == "" {
+			http.Error(w, "Nurodykite tabelio numerį arba darbuotojo vardą", http.StatusBadRequest)
+			return
+		}
+
+		feed, token, err := models.CreateCalendarFeed(db, request.PersonnelNumber, request.EmployeeName, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko sukurti kalendoriaus: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{
+			"feed":  feed,
+			"token": token,
+			"url":   "/api/v1/calendar/" + token + ".ics",
+		})
+	}
+}
+
+// RevokeCalendarFeed stops a duty feed from serving.
+func RevokeCalendarFeed(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Netinkamas ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := models.RevokeCalendarFeed(db, id); err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Kalendorius nerastas arba jau atšauktas", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko atšaukti kalendoriaus: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ServeCalendarFeed serves the duties of a feed's employee as an iCalendar
+// file. The token in the URL is the only credential, so calendar apps can
+// subscribe without logging in. Duties are built on every request, so the
+// feed follows schedule changes at the next refresh.
+func ServeCalendarFeed(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		feed, err := models.GetCalendarFeedByToken(db, chi.URLParam(r, "token"))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Kalendorius nerastas", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko gauti kalendoriaus", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		now := time.Now()
+		duties, err := models.GetEmployeeDuties(
+			db, feed.PersonnelNumber, feed.EmployeeName,
+			now.Add(-calendarFeedPast), now.Add(calendarFeedFuture),
+		)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti pareigų", http.StatusInternalServerError)
+			return
+		}
+
+		employee := feed.EmployeeName
+		if employee == "" {
+			employee = feed.PersonnelNumber
+		}
+		calendar := ical.Calendar{Name: "Pareigos: " + employee, Refresh: calendarFeedRefresh}
+		for _, duty := range duties {
+			calendar.Events = append(calendar.Events, dutyEvent(feed.ID, duty))
+		}
+
+		var body bytes.Buffer
+		if err := calendar.Write(&body); err != nil {
+			http.Error(w, "Nepavyko sudaryti kalendoriaus", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write(body.Bytes())
+	}
+}
+
+// dutyEvent turns a duty into a calendar event. The UID is derived from the
+// feed, duty code and day, so a changed duty replaces its earlier copy.
+func dutyEvent(feedID int, duty models.EmployeeDuty) ical.Event {
+	day := duty.Start.In(timeparse.Vilnius).Format("20060102")
+
+	key, summary := duty.Duty, "Pareiga "+duty.Duty
+	if duty.Duty == "" {
+		key = strings.Join(duty.Trains, "-")
+		summary = "Traukinys " + strings.Join(duty.Trains, ", ")
+	}
+
+	var details []string
+	if len(duty.Trains) > 0 {
+		details = append(details, "Traukiniai: "+strings.Join(duty.Trains, ", "))
+	}
+	if len(duty.Locations) > 0 {
+		details = append(details, "Vietos: "+strings.Join(duty.Locations, ", "))
+	}
+	if len(duty.Tracks) > 0 {
+		details = append(details, "Keliai: "+strings.Join(duty.Tracks, ", "))
+	}
+
+	return ical.Event{
+		UID:          fmt.Sprintf("%d-%s-%s@yopta", feedID, key, day),
+		Start:        duty.Start,
+		End:          duty.End,
+		Summary:      summary,
+		Description:  strings.Join(details, "\n"),
+		Location:     strings.Join(duty.Locations, ", "),
+		LastModified: duty.UpdatedAt,
+	}
+}
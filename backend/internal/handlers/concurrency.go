@@ -0,0 +1,50 @@
+// backend/internal/handlers/concurrency.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// errInvalidIfMatch is returned for an If-Match header that is not a record version
+var errInvalidIfMatch = errors.New("invalid If-Match header")
+
+// ifMatchVersion reads the record version from the If-Match header.
+// ETags are record versions in quotes ("3"); weak tags (W/"3") are accepted too.
+// Returns 0 if the header is missing or "*", which skips the version check.
+func ifMatchVersion(r *http.Request) (int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	tag := strings.TrimPrefix(header, "W/")
+	tag = strings.Trim(tag, `"`)
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version < 1 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// setETag sends the record version as the response ETag.
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", `"`+strconv.FormatInt(version, 10)+`"`)
+}
+
+// writeVersionConflict answers a stale write with 409 Conflict and the
+// current server state, so the client can merge and retry.
+func writeVersionConflict(w http.ResponseWriter, message string, current any, version int64) {
+	if version > 0 {
+		setETag(w, version)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string]any{
+		"message": message,
+		"current": current,
+	})
+}
@@ -0,0 +1,148 @@
+// backend/internal/handlers/crew_duty.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"yopta-template/internal/crewduty"
+	"yopta-template/internal/models"
+)
+
+// System settings holding the crew duty limits
+const (
+	settingMaxDutyMinutes = "crew_max_duty_minutes"
+	settingMinRestMinutes = "crew_min_rest_minutes"
+)
+
+// CheckCrewDuties checks the crew duties of stored Antras imports: duty
+// length, rest between consecutive duties and overlapping assignments of one
+// personnel number. Violations are returned per employee and per day. Only
+// the latest import of each station and day is checked, and users other than
+// admins only check the stations of their depots.
+//
+// Query parameters: import_id to check a single import, date or date_from and
+// date_to to limit the reported days, and max_duty_minutes and
+// min_rest_minutes to override the configured limits.
+func CheckCrewDuties(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		editor, err := models.NewEditor(db, userID, isAdmin, getRequestIDFromContext(r))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų teisių", http.StatusInternalServerError)
+			return
+		}
+
+		query := r.URL.Query()
+
+		limits, err := crewDutyLimits(db)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti darbo laiko ribų: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for param, limit := range map[string]*int{
+			"max_duty_minutes": &limits.MaxDutyMinutes,
+			"min_rest_minutes": &limits.MinRestMinutes,
+		} {
+			if value := query.Get(param); value != "" {
+				n, err := strconv.Atoi(value)
+				if err != nil {
+					http.Error(w, "Neteisinga darbo laiko riba: "+param, http.StatusBadRequest)
+					return
+				}
+				*limit = n
+			}
+		}
+		if err := limits.Validate(); err != nil {
+			http.Error(w, "Neteisingos darbo laiko ribos: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		filter := models.AntrasCrewFilter{Stations: editor.AntrasStations()}
+		if value := query.Get("import_id"); value != "" {
+			filter.ImportID, err = strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				http.Error(w, "Netinkamas importo ID", http.StatusBadRequest)
+				return
+			}
+		}
+
+		var from, to *time.Time
+		if day := query.Get("date"); day != "" {
+			start, _, err := parseRangeBound(day)
+			if err != nil {
+				http.Error(w, "Neteisinga data: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			end := start.AddDate(0, 0, 1)
+			from, to = &start, &end
+		}
+		if value := query.Get("date_from"); value != "" {
+			start, _, err := parseRangeBound(value)
+			if err != nil {
+				http.Error(w, "Neteisinga pradžios data: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			from = &start
+		}
+		if value := query.Get("date_to"); value != "" {
+			end, dateOnly, err := parseRangeBound(value)
+			if err != nil {
+				http.Error(w, "Neteisinga pabaigos data: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if dateOnly {
+				end = end.AddDate(0, 0, 1)
+			}
+			to = &end
+		}
+
+		// Duties ending shortly before the range still limit the rest of the first one
+		if from != nil {
+			readFrom := from.Add(-time.Duration(limits.MinRestMinutes) * time.Minute)
+			filter.From = &readFrom
+		}
+		filter.To = to
+
+		assignments, err := models.GetAntrasCrewAssignments(db, filter)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti įgulų duomenų: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(crewduty.Check(assignments, limits, from, to))
+	}
+}
+
+// crewDutyLimits reads the configured crew duty limits,
+// falling back to crewduty.DefaultLimits.
+func crewDutyLimits(db *sql.DB) (crewduty.Limits, error) {
+	limits := crewduty.DefaultLimits
+
+	var err error
+	limits.MaxDutyMinutes, err = models.GetSystemSettingInt(db, settingMaxDutyMinutes, limits.MaxDutyMinutes)
+	if err != nil {
+		return limits, err
+	}
+	limits.MinRestMinutes, err = models.GetSystemSettingInt(db, settingMinRestMinutes, limits.MinRestMinutes)
+	if err != nil {
+		return limits, err
+	}
+
+	return limits, nil
+}
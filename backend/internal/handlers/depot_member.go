@@ -0,0 +1,154 @@
+// backend/internal/handlers/depot_member.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"yopta-template/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// DepotMemberRequest assigns a user to a depot team.
+type DepotMemberRequest struct {
+	UserID int    `json:"user_id"` // User to assign
+	Role   string `json:"role"`    // "editor" or "viewer"
+}
+
+// depotFromURL reads the station of a depot team from the URL and checks it exists.
+// Writes the error response and returns false if it does not.
+func depotFromURL(w http.ResponseWriter, r *http.Request, db *sql.DB) (int, bool) {
+	stationID, err := strconv.Atoi(chi.URLParam(r, "stationId"))
+	if err != nil {
+		http.Error(w, "Neteisingas stoties ID", http.StatusBadRequest)
+		return 0, false
+	}
+
+	if _, err := models.GetStationByID(db, stationID); err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Stotis nerasta", http.StatusNotFound)
+		} else {
+			http.Error(w, "Nepavyko gauti stoties: "+err.Error(), http.StatusInternalServerError)
+		}
+		return 0, false
+	}
+
+	return stationID, true
+}
+
+// GetDepotMembers returns the team of a depot with each member's role.
+func GetDepotMembers(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stationID, ok := depotFromURL(w, r, db)
+		if !ok {
+			return
+		}
+
+		members, err := models.GetDepotMembers(db, stationID)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti depo komandos: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(members)
+	}
+}
+
+// SetDepotMember adds a user to a depot team or changes their role.
+// Editors may change and delete schedule records touching the depot,
+// viewers may only read them.
+func SetDepotMember(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stationID, ok := depotFromURL(w, r, db)
+		if !ok {
+			return
+		}
+
+		var request DepotMemberRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Neteisingas užklausos formatas", http.StatusBadRequest)
+			return
+		}
+		if !models.IsDepotRole(request.Role) {
+			http.Error(w, "Nurodyta neteisinga rolė", http.StatusBadRequest)
+			return
+		}
+
+		var exists bool
+		err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)", request.UserID).
+			Scan(&exists)
+		if err != nil {
+			http.Error(w, "Duomenų bazės klaida", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Vartotojas nerastas", http.StatusNotFound)
+			return
+		}
+
+		if err := models.SetDepotMember(db, stationID, request.UserID, request.Role); err != nil {
+			http.Error(w, "Nepavyko priskirti vartotojo depui: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		members, err := models.GetDepotMembers(db, stationID)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti depo komandos: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(members)
+	}
+}
+
+// RemoveDepotMember removes a user from a depot team.
+func RemoveDepotMember(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stationID, ok := depotFromURL(w, r, db)
+		if !ok {
+			return
+		}
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "userId"))
+		if err != nil {
+			http.Error(w, "Neteisingas vartotojo ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := models.RemoveDepotMember(db, stationID, userID); err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Vartotojas nėra depo komandoje", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko pašalinti vartotojo iš depo: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetProfileDepots returns the depot teams of the current user.
+func GetProfileDepots(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		depots, err := models.GetUserDepots(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(depots)
+	}
+}
@@ -0,0 +1,331 @@
+// backend/internal/handlers/depot_plan.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"yopta-template/internal/models"
+	"yopta-template/internal/timeparse"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// planPublishedMessage answers changes to records of a published depot plan
+const planPublishedMessage = "Depo dienos planas paskelbtas: įrašų keisti negalima, kol administratorius jo neatidarys"
+
+// DepotPlanRequest starts a depot plan.
+type DepotPlanRequest struct {
+	Depot   string `json:"depot"`   // Depot (station) code
+	Date    string `json:"date"`    // Day of the plan
+	Comment string `json:"comment"` // Note stored in the plan history
+}
+
+// DepotPlanStatusRequest changes the state of a depot plan.
+type DepotPlanStatusRequest struct {
+	Status  string `json:"status"`  // "draft", "published" or "archived"
+	Comment string `json:"comment"` // Note stored in the plan history, e.g. why it was reopened
+}
+
+// isViewer reports whether the request comes from a user with the viewer role,
+// who may only see published depot plans.
+func isViewer(r *http.Request) bool {
+	role, _ := r.Context().Value("role").(string)
+	return role == "viewer"
+}
+
+// rejectViewer answers 403 to a viewer trying to change records or track
+// assignments. It reports whether the request was rejected.
+func rejectViewer(w http.ResponseWriter, r *http.Request) bool {
+	if !isViewer(r) {
+		return false
+	}
+	http.Error(w, "Peržiūros vartotojai negali keisti duomenų", http.StatusForbidden)
+	return true
+}
+
+// checkReadable returns sql.ErrNoRows if the user may not read the record.
+// Viewers read the records of published depot plans, other users the records
+// they own or that touch their depots.
+func checkReadable(db *sql.DB, r *http.Request, editor models.Editor, schedule *models.TrainSchedule) error {
+	if !isViewer(r) {
+		if !editor.CanView(schedule) {
+			return sql.ErrNoRows // Hide records of other users and depots
+		}
+		return nil
+	}
+	published, err := models.InPublishedPlan(db, schedule)
+	if err != nil {
+		return err
+	}
+	if !published {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetDepotPlans lists depot plans by day and depot.
+// Query parameters: depot, date_from, date_to (YYYY-MM-DD) and status.
+// Viewers only get published plans.
+func GetDepotPlans(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := models.DepotPlanFilter{
+			Depot:  strings.TrimSpace(query.Get("depot")),
+			Status: query.Get("status"),
+		}
+
+		for param, bound := range map[string]*string{"date_from": &filter.From, "date_to": &filter.To} {
+			if value := query.Get(param); value != "" {
+				day, err := timeparse.ParseDate(value)
+				if err != nil {
+					http.Error(w, "Neteisinga data: "+param, http.StatusBadRequest)
+					return
+				}
+				*bound = day.Format("2006-01-02")
+			}
+		}
+
+		switch filter.Status {
+		case "", models.PlanDraft, models.PlanPublished, models.PlanArchived:
+		default:
+			http.Error(w, "Neteisinga plano būsena", http.StatusBadRequest)
+			return
+		}
+		if isViewer(r) {
+			if filter.Status != "" && filter.Status != models.PlanPublished {
+				http.Error(w, "Galite matyti tik paskelbtus planus", http.StatusForbidden)
+				return
+			}
+			filter.Status = models.PlanPublished
+		}
+
+		plans, err := models.GetDepotPlans(db, filter)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti depo planų: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(plans)
+	}
+}
+
+// GetDepotPlan returns a depot plan with its state history and the records of
+// the depot day the user may see. The ETag header carries the plan version.
+func GetDepotPlan(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Neteisingas plano ID", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		plan, err := models.GetDepotPlanByID(db, id)
+		if err == nil && isViewer(r) && plan.Status != models.PlanPublished {
+			err = sql.ErrNoRows // Viewers only see published plans
+		}
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Depo planas nerastas", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko gauti depo plano: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		transitions, err := models.GetDepotPlanTransitions(db, id)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti plano istorijos: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// A published plan is shown whole; drafts only with the records the user may see
+		filter := models.TrainScheduleFilter{
+			VisibleTo: userID,
+			Depot:     plan.StationCode,
+			From:      &plan.StartsAt,
+			To:        &plan.EndsAt,
+			Sort:      "time",
+		}
+		if isAdmin || plan.Status == models.PlanPublished {
+			filter.VisibleTo = 0
+		}
+		list, err := models.GetTrainSchedules(db, filter)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti traukinių grafiko: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		setETag(w, plan.Version)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"plan":        plan,
+			"transitions": transitions,
+			"records":     list.Records,
+		})
+	}
+}
+
+// CreateDepotPlan starts a draft plan of a depot for a day.
+// Admins and the depot's editors may create plans.
+func CreateDepotPlan(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req DepotPlanRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Neteisingas užklausos formatas", http.StatusBadRequest)
+			return
+		}
+		req.Depot = strings.TrimSpace(req.Depot)
+		if req.Depot == "" {
+			http.Error(w, "Depas yra būtinas", http.StatusBadRequest)
+			return
+		}
+		day, err := timeparse.ParseDate(req.Date)
+		if err != nil {
+			http.Error(w, "Neteisinga data: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		editor, err := models.NewEditor(db, userID, isAdmin, getRequestIDFromContext(r))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų teisių", http.StatusInternalServerError)
+			return
+		}
+
+		exists, err := models.DepotPlanExists(db, req.Depot, day)
+		if err != nil {
+			http.Error(w, "Duomenų bazės klaida", http.StatusInternalServerError)
+			return
+		}
+		if exists {
+			http.Error(w, "Šio depo planas šiai dienai jau yra", http.StatusConflict)
+			return
+		}
+
+		id, err := models.CreateDepotPlan(db, req.Depot, day, editor, req.Comment)
+		if err != nil {
+			if err == models.ErrPlanForbidden {
+				http.Error(w, "Jūs neturite teisių planuoti šio depo", http.StatusForbidden)
+			} else if err == sql.ErrNoRows {
+				http.Error(w, "Depas nerastas", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko sukurti depo plano: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		plan, err := models.GetDepotPlanByID(db, id)
+		if err != nil {
+			http.Error(w, "Planas sukurtas, bet nepavyko jo grąžinti", http.StatusInternalServerError)
+			return
+		}
+
+		setETag(w, plan.Version)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(plan)
+	}
+}
+
+// UpdateDepotPlanStatus publishes, archives or reopens a depot plan.
+// Publishing and archiving are open to admins and the depot's editors,
+// reopening a plan as a draft only to admins. Send the plan's ETag in If-Match
+// to make sure nobody changed its state meanwhile.
+func UpdateDepotPlanStatus(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Neteisingas plano ID", http.StatusBadRequest)
+			return
+		}
+
+		var req DepotPlanStatusRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Neteisingas užklausos formatas", http.StatusBadRequest)
+			return
+		}
+		if len(req.Comment) > 255 {
+			http.Error(w, "Komentaras per ilgas", http.StatusBadRequest)
+			return
+		}
+
+		expectedVersion, err := ifMatchVersion(r)
+		if err != nil {
+			http.Error(w, "Neteisinga If-Match antraštė", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		editor, err := models.NewEditor(db, userID, isAdmin, getRequestIDFromContext(r))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų teisių", http.StatusInternalServerError)
+			return
+		}
+
+		plan, err := models.TransitionDepotPlan(db, id, req.Status, expectedVersion, editor, req.Comment)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrVersionConflict):
+				current, getErr := models.GetDepotPlanByID(db, id)
+				if getErr != nil {
+					http.Error(w, "Nepavyko gauti depo plano: "+getErr.Error(), http.StatusInternalServerError)
+					return
+				}
+				writeVersionConflict(w, "Plano būseną jau pakeitė kitas vartotojas", current, current.Version)
+			case errors.Is(err, models.ErrPlanTransition):
+				http.Error(w, "Plano negalima perkelti į šią būseną", http.StatusConflict)
+			case err == models.ErrPlanForbidden:
+				http.Error(w, "Jūs neturite teisių keisti šio plano būsenos", http.StatusForbidden)
+			case err == sql.ErrNoRows:
+				http.Error(w, "Depo planas nerastas", http.StatusNotFound)
+			default:
+				http.Error(w, "Nepavyko pakeisti plano būsenos: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		setETag(w, plan.Version)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(plan)
+	}
+}
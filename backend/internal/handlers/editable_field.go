@@ -0,0 +1,206 @@
+// backend/internal/handlers/editable_field.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"yopta-template/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetEditableFields returns all editable field descriptions, including inactive ones
+func GetEditableFields(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fields, err := models.GetEditableFields(db)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti redaguojamų laukų: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(fields)
+	}
+}
+
+// GetActiveEditableFields returns the fields users may edit with their rules,
+// so the schedule editor can offer only those and check values as they are typed
+func GetActiveEditableFields(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fields, err := models.GetActiveEditableFields(db)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti redaguojamų laukų: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(fields)
+	}
+}
+
+// GetEditableField returns a single editable field description by ID
+func GetEditableField(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Netinkamas ID", http.StatusBadRequest)
+			return
+		}
+
+		field, err := models.GetEditableFieldByID(db, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Redaguojamas laukas nerastas", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko gauti redaguojamo lauko: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(field)
+	}
+}
+
+// CreateEditableField makes another train schedule field editable
+func CreateEditableField(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var field models.EditableField
+		if err := json.NewDecoder(r.Body).Decode(&field); err != nil {
+			http.Error(w, "Netinkami duomenys: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !validateEditableField(w, db, &field, 0) {
+			return
+		}
+
+		if err := models.CreateEditableField(db, &field); err != nil {
+			http.Error(w, "Nepavyko sukurti redaguojamo lauko: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		created, err := models.GetEditableFieldByID(db, field.ID)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti redaguojamo lauko: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(created)
+	}
+}
+
+// UpdateEditableField changes the rules of an editable field
+func UpdateEditableField(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Netinkamas ID", http.StatusBadRequest)
+			return
+		}
+
+		var field models.EditableField
+		if err := json.NewDecoder(r.Body).Decode(&field); err != nil {
+			http.Error(w, "Netinkami duomenys: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !validateEditableField(w, db, &field, id) {
+			return
+		}
+
+		if err := models.UpdateEditableField(db, id, &field); err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Redaguojamas laukas nerastas", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko atnaujinti redaguojamo lauko: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		updated, err := models.GetEditableFieldByID(db, id)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti redaguojamo lauko: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(updated)
+	}
+}
+
+// DeleteEditableField makes a field read-only again by removing its description
+func DeleteEditableField(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Netinkamas ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := models.DeleteEditableField(db, id); err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Redaguojamas laukas nerastas", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko ištrinti redaguojamo lauko: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// validateEditableField fills defaults and checks an editable field description.
+// Writes the error response and returns false if it is invalid.
+func validateEditableField(w http.ResponseWriter, db *sql.DB, field *models.EditableField, id int) bool {
+	field.FieldName = strings.TrimSpace(field.FieldName)
+	if field.FieldName == "" {
+		http.Error(w, "Lauko pavadinimas yra būtinas", http.StatusBadRequest)
+		return false
+	}
+	if field.ValueType == "" {
+		field.ValueType = models.EditableText
+	}
+
+	if err := field.Check(); err != nil {
+		http.Error(w, "Netinkamas lauko aprašas: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	exists, err := models.EditableFieldExists(db, field.FieldName, id)
+	if err != nil {
+		http.Error(w, "Duomenų bazės klaida", http.StatusInternalServerError)
+		return false
+	}
+	if exists {
+		http.Error(w, "Šis laukas jau aprašytas", http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// fieldValueMessage describes a rejected field value for the user.
+func fieldValueMessage(err *models.FieldValueError) string {
+	switch err.Reason {
+	case models.FieldValueRequired:
+		return "Laukas " + err.Field + " negali būti tuščias"
+	case models.FieldValueTooLong:
+		return fmt.Sprintf("Lauko %s reikšmė ilgesnė nei %d simbolių", err.Field, err.Limit)
+	case models.FieldValueNotAllowed:
+		return "Lauko " + err.Field + " reikšmė neleidžiama"
+	case models.FieldValueInvalidInteger:
+		return "Lauko " + err.Field + " reikšmė turi būti sveikasis skaičius"
+	case models.FieldValueInvalidDateTime:
+		return "Lauko " + err.Field + " reikšmė turi būti data ir laikas (RFC 3339)"
+	default:
+		return "Netinkama lauko " + err.Field + " reikšmė"
+	}
+}
@@ -0,0 +1,70 @@
+// backend/internal/handlers/parking_plan.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"yopta-template/internal/models"
+	"yopta-template/internal/timeparse"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetParkingPlanProposal proposes track positions for the vehicles standing
+// at a station on a day (date=YYYY-MM-DD, Vilnius time). Locked track
+// assignments are kept; nothing is saved, dispatchers save the positions
+// they accept as track assignments.
+//
+// Response: {date, assignments, fixed, conflicts, issues, limitations, score};
+// issues explain the vehicles that could not be placed without conflicts,
+// limitations the track rules the plan could not check.
+func GetParkingPlanProposal(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if isViewer(r) {
+			http.Error(w, "Galite matyti tik paskelbtus planus", http.StatusForbidden)
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Neteisingas stoties ID", http.StatusBadRequest)
+			return
+		}
+
+		from, err := timeparse.ParseDate(r.URL.Query().Get("date"))
+		if err != nil {
+			http.Error(w, "Neteisinga data: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		station, err := models.GetStationByID(db, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Stotis nerasta", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko gauti stoties: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		proposal, err := models.ProposeParkingPlan(db, station, from, from.AddDate(0, 0, 1))
+		if err != nil {
+			http.Error(w, "Nepavyko sudaryti stovėjimo plano: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"date":        from.Format("2006-01-02"),
+			"assignments": proposal.Assignments,
+			"fixed":       proposal.Fixed,
+			"conflicts":   proposal.Conflicts,
+			"issues":      proposal.Issues,
+			"limitations": proposal.Limitations,
+			"score":       proposal.Score,
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// backend/internal/handlers/punctuality.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"yopta-template/internal/models"
+	"yopta-template/internal/punctuality"
+)
+
+// settingOnTimeMinutes is the system setting holding the on-time threshold
+const settingOnTimeMinutes = "punctuality_on_time_minutes"
+
+// maxPunctualityDays limits the range of a punctuality report
+const maxPunctualityDays = 366
+
+// GetPunctuality reports how punctual trains were: average delay, share of
+// movements on time and the most delayed movements, per train number, depot,
+// week and delay reason. Delays are measured between the planned times and
+// the actual times recorded by dispatchers.
+//
+// Query parameters: date or date_from and date_to (required), depot,
+// user_id (admins only), on_time_minutes to override the configured
+// threshold and worst for the number of most delayed movements to list.
+func GetPunctuality(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		filter, message := trainScheduleFilterFromQuery(r, userID, isAdmin)
+		if message != "" {
+			http.Error(w, message, http.StatusBadRequest)
+			return
+		}
+		if filter.From == nil || filter.To == nil || !filter.From.Before(*filter.To) {
+			http.Error(w, "Reikia nurodyti laikotarpį", http.StatusBadRequest)
+			return
+		}
+		if filter.To.Sub(*filter.From) > maxPunctualityDays*24*time.Hour {
+			http.Error(w, "Per ilgas laikotarpis", http.StatusBadRequest)
+			return
+		}
+
+		opts := punctuality.Options{
+			From:  *filter.From,
+			To:    *filter.To,
+			Depot: filter.Depot,
+			Worst: punctuality.DefaultWorst,
+		}
+		opts.OnTimeMinutes, err = models.GetSystemSettingInt(db, settingOnTimeMinutes, punctuality.DefaultOnTimeMinutes)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti punktualumo ribos: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		query := r.URL.Query()
+		for param, value := range map[string]*int{
+			"on_time_minutes": &opts.OnTimeMinutes,
+			"worst":           &opts.Worst,
+		} {
+			if text := query.Get(param); text != "" {
+				n, err := strconv.Atoi(text)
+				if err != nil {
+					http.Error(w, "Neteisingas parametras: "+param, http.StatusBadRequest)
+					return
+				}
+				*value = n
+			}
+		}
+		if err := opts.Validate(); err != nil {
+			http.Error(w, "Neteisingi parametrai: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Only the range, depot and visibility matter; the rest of the list filters do not apply
+		list, err := models.GetTrainSchedules(db, models.TrainScheduleFilter{
+			UserID:    filter.UserID,
+			VisibleTo: filter.VisibleTo,
+			Published: filter.Published,
+			Depot:     filter.Depot,
+			From:      filter.From,
+			To:        filter.To,
+			Sort:      "time",
+		})
+		if err != nil {
+			http.Error(w, "Nepavyko gauti traukinių grafiko: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(punctuality.Summarize(list.Records, opts))
+	}
+}
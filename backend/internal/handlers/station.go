@@ -4,6 +4,7 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -58,7 +59,8 @@ func GetStationByID(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		// Return as JSON
+		// Return as JSON, with the version as ETag for later If-Match writes
+		setETag(w, station.Version)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(station)
 	}
@@ -89,6 +91,9 @@ func CreateStation(db *sql.DB) http.HandlerFunc {
 			http.Error(w, "Stoties pavadinimas ir kodas yra būtini", http.StatusBadRequest)
 			return
 		}
+		if !stationCodeAvailable(w, db, station.Code) {
+			return
+		}
 
 		// Create station in database
 		stationID, err := models.CreateStation(db, station)
@@ -138,6 +143,16 @@ func UpdateStation(db *sql.DB) http.HandlerFunc {
 		// Ensure ID in URL matches ID in body
 		station.ID = id
 
+		// If-Match takes precedence over the version sent in the body
+		expectedVersion, err := ifMatchVersion(r)
+		if err != nil {
+			http.Error(w, "Neteisinga If-Match antraštė", http.StatusBadRequest)
+			return
+		}
+		if expectedVersion > 0 {
+			station.Version = expectedVersion
+		}
+
 		// Verify station exists
 		existingStation, err := models.GetStationByID(db, id)
 		if err != nil {
@@ -165,9 +180,16 @@ func UpdateStation(db *sql.DB) http.HandlerFunc {
 			http.Error(w, "Stoties pavadinimas ir kodas yra būtini", http.StatusBadRequest)
 			return
 		}
+		if station.Code != existingStation.Code && !stationCodeAvailable(w, db, station.Code) {
+			return
+		}
 
 		// Update station
 		if err := models.UpdateStation(db, station); err != nil {
+			if errors.Is(err, models.ErrVersionConflict) {
+				writeStationConflict(w, db, id)
+				return
+			}
 			http.Error(
 				w,
 				"Nepavyko atnaujinti stoties: "+err.Error(),
@@ -188,14 +210,16 @@ func UpdateStation(db *sql.DB) http.HandlerFunc {
 		}
 
 		// Return updated station
+		setETag(w, updatedStation.Version)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(updatedStation)
 	}
 }
 
-// DeleteStation removes a station and all its tracks from the database.
-// Like demolishing an old train station to make way for something new,
-// this endpoint completely removes a station and all its associated data.
+// DeleteStation moves a station with all its tracks to the trash.
+// Like closing an old train station before it is demolished, the station
+// disappears from every list but admins can still restore it until the
+// trash is purged.
 func DeleteStation(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Get station ID from URL
@@ -228,8 +252,18 @@ func DeleteStation(db *sql.DB) http.HandlerFunc {
 			}
 		}
 
+		expectedVersion, err := ifMatchVersion(r)
+		if err != nil {
+			http.Error(w, "Neteisinga If-Match antraštė", http.StatusBadRequest)
+			return
+		}
+
 		// Delete station
-		if err := models.DeleteStation(db, id); err != nil {
+		if err := models.DeleteStation(db, id, expectedVersion, userID); err != nil {
+			if errors.Is(err, models.ErrVersionConflict) {
+				writeStationConflict(w, db, id)
+				return
+			}
 			http.Error(w, "Nepavyko ištrinti stoties: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -239,6 +273,46 @@ func DeleteStation(db *sql.DB) http.HandlerFunc {
 	}
 }
 
+// stationCodeAvailable checks that no station in the trash holds the code.
+// Writes the error response and returns false if one does.
+func stationCodeAvailable(w http.ResponseWriter, db *sql.DB, code string) bool {
+	inTrash, err := models.StationCodeInTrash(db, code)
+	if err != nil {
+		http.Error(w, "Duomenų bazės klaida", http.StatusInternalServerError)
+		return false
+	}
+	if inTrash {
+		http.Error(
+			w,
+			"Stotis su šiuo kodu yra šiukšliadėžėje: atkurkite ją arba ištrinkite visam laikui",
+			http.StatusConflict,
+		)
+		return false
+	}
+	return true
+}
+
+// writeStationConflict answers a stale station or track write with 409
+// and the station, including its tracks, as it is stored now.
+func writeStationConflict(w http.ResponseWriter, db *sql.DB, stationID int) {
+	current, err := models.GetStationByID(db, stationID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Stotis buvo ištrinta kito vartotojo", http.StatusConflict)
+		} else {
+			http.Error(w, "Nepavyko gauti stoties: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeVersionConflict(
+		w,
+		"Stotį jau pakeitė kitas vartotojas", // The station was already changed by another user
+		current,
+		current.Version,
+	)
+}
+
 // Helper function to check if a user is an admin
 func isUserAdmin(db *sql.DB, userID int) (bool, error) {
 	var role string
@@ -322,6 +396,7 @@ func AddTrack(db *sql.DB) http.HandlerFunc {
 		}
 
 		// Return updated station
+		setETag(w, updatedStation.Version)
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(updatedStation)
@@ -351,6 +426,16 @@ func UpdateTrack(db *sql.DB) http.HandlerFunc {
 		// Set track ID
 		track.ID = trackID
 
+		// If-Match takes precedence over the version sent in the body
+		expectedVersion, err := ifMatchVersion(r)
+		if err != nil {
+			http.Error(w, "Neteisinga If-Match antraštė", http.StatusBadRequest)
+			return
+		}
+		if expectedVersion > 0 {
+			track.Version = expectedVersion
+		}
+
 		// Get track to check its station
 		var stationID int
 		err = db.QueryRow("SELECT station_id FROM tracks WHERE id = ?", trackID).Scan(&stationID)
@@ -395,6 +480,10 @@ func UpdateTrack(db *sql.DB) http.HandlerFunc {
 
 		// Update track
 		if err := models.UpdateTrack(db, track); err != nil {
+			if errors.Is(err, models.ErrVersionConflict) {
+				writeStationConflict(w, db, stationID)
+				return
+			}
 			http.Error(w, "Nepavyko atnaujinti kelio: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -411,6 +500,7 @@ func UpdateTrack(db *sql.DB) http.HandlerFunc {
 		}
 
 		// Return updated station
+		setETag(w, updatedStation.Version)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(updatedStation)
 	}
@@ -459,8 +549,18 @@ func DeleteTrack(db *sql.DB) http.HandlerFunc {
 			}
 		}
 
+		expectedVersion, err := ifMatchVersion(r)
+		if err != nil {
+			http.Error(w, "Neteisinga If-Match antraštė", http.StatusBadRequest)
+			return
+		}
+
 		// Delete track
-		if err := models.DeleteTrack(db, trackID); err != nil {
+		if err := models.DeleteTrack(db, trackID, expectedVersion); err != nil {
+			if errors.Is(err, models.ErrVersionConflict) {
+				writeStationConflict(w, db, stationID)
+				return
+			}
 			http.Error(w, "Nepavyko ištrinti kelio: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -477,6 +577,7 @@ func DeleteTrack(db *sql.DB) http.HandlerFunc {
 		}
 
 		// Return updated station
+		setETag(w, updatedStation.Version)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(updatedStation)
 	}
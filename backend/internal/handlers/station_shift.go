@@ -0,0 +1,176 @@
+// backend/internal/handlers/station_shift.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"yopta-template/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetStationShifts returns the shift windows of a station.
+func GetStationShifts(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stationID, err := strconv.Atoi(chi.URLParam(r, "stationId"))
+		if err != nil {
+			http.Error(w, "Neteisingas stoties ID", http.StatusBadRequest)
+			return
+		}
+
+		shifts, err := models.GetShiftsByStationID(db, stationID)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti pamainų: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(shifts)
+	}
+}
+
+// CreateStationShift adds a shift window to a station.
+func CreateStationShift(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stationID, err := strconv.Atoi(chi.URLParam(r, "stationId"))
+		if err != nil {
+			http.Error(w, "Neteisingas stoties ID", http.StatusBadRequest)
+			return
+		}
+
+		station, err := models.GetStationByID(db, stationID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Stotis nerasta", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko gauti stoties: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		var shift models.StationShift
+		if err := json.NewDecoder(r.Body).Decode(&shift); err != nil {
+			http.Error(w, "Neteisingas užklausos formatas", http.StatusBadRequest)
+			return
+		}
+		shift.StationID = stationID
+
+		if !validateStationShift(w, &shift, station.Shifts) {
+			return
+		}
+
+		id, err := models.CreateShift(db, shift)
+		if err != nil {
+			http.Error(w, "Nepavyko sukurti pamainos: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		created, err := models.GetShiftByID(db, id)
+		if err != nil {
+			http.Error(w, "Pamaina sukurta, bet nepavyko jos grąžinti", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(created)
+	}
+}
+
+// UpdateStationShift changes a shift window.
+func UpdateStationShift(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		shiftID, err := strconv.Atoi(chi.URLParam(r, "shiftId"))
+		if err != nil {
+			http.Error(w, "Neteisingas pamainos ID", http.StatusBadRequest)
+			return
+		}
+
+		existing, err := models.GetShiftByID(db, shiftID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Pamaina nerasta", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko gauti pamainos: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		var shift models.StationShift
+		if err := json.NewDecoder(r.Body).Decode(&shift); err != nil {
+			http.Error(w, "Neteisingas užklausos formatas", http.StatusBadRequest)
+			return
+		}
+		shift.ID = shiftID
+		shift.StationID = existing.StationID
+
+		siblings, err := models.GetShiftsByStationID(db, existing.StationID)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti pamainų: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !validateStationShift(w, &shift, siblings) {
+			return
+		}
+
+		if err := models.UpdateShift(db, shift); err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Pamaina nerasta", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko atnaujinti pamainos: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		updated, err := models.GetShiftByID(db, shiftID)
+		if err != nil {
+			http.Error(w, "Pamaina atnaujinta, bet nepavyko jos grąžinti", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(updated)
+	}
+}
+
+// DeleteStationShift removes a shift window.
+func DeleteStationShift(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		shiftID, err := strconv.Atoi(chi.URLParam(r, "shiftId"))
+		if err != nil {
+			http.Error(w, "Neteisingas pamainos ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := models.DeleteShift(db, shiftID); err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Pamaina nerasta", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko ištrinti pamainos: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// validateStationShift checks a shift and that no other shift of the station
+// has the same name. Writes a 400/409 response and returns false if invalid.
+func validateStationShift(w http.ResponseWriter, shift *models.StationShift, siblings []models.StationShift) bool {
+	if err := models.ValidateStationShift(shift); err != nil {
+		http.Error(w, "Netinkama pamaina: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	for _, other := range siblings {
+		if other.ID != shift.ID && other.Name == shift.Name {
+			http.Error(w, "Pamaina tokiu pavadinimu jau yra", http.StatusConflict)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,244 @@
+// backend/internal/handlers/timetable_template.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"yopta-template/internal/models"
+	"yopta-template/internal/timeparse"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxGenerationDays limits the range of a single generation run
+const maxGenerationDays = 366
+
+// GetTimetableTemplates returns all timetable templates, including inactive ones
+func GetTimetableTemplates(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templates, err := models.GetTimetableTemplates(db)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti tvarkaraščio šablonų: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(templates)
+	}
+}
+
+// GetTimetableTemplate returns a single timetable template by ID
+func GetTimetableTemplate(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Netinkamas ID", http.StatusBadRequest)
+			return
+		}
+
+		template, err := models.GetTimetableTemplateByID(db, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Tvarkaraščio šablonas nerastas", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko gauti tvarkaraščio šablono: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(template)
+	}
+}
+
+// CreateTimetableTemplate adds a recurring train to a timetable period
+func CreateTimetableTemplate(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		var template models.TimetableTemplate
+		if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+			http.Error(w, "Netinkami duomenys: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		template.UserID = &userID
+
+		if err := models.ValidateTimetableTemplate(&template); err != nil {
+			http.Error(w, "Netinkamas tvarkaraščio šablonas: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := models.CreateTimetableTemplate(db, &template); err != nil {
+			http.Error(w, "Nepavyko sukurti tvarkaraščio šablono: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		created, err := models.GetTimetableTemplateByID(db, template.ID)
+		if err != nil {
+			http.Error(w, "Šablonas sukurtas, bet nepavyko jo grąžinti", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(created)
+	}
+}
+
+// UpdateTimetableTemplate changes a timetable template. Records generated
+// from it follow the change on the next generation run unless edited by hand.
+func UpdateTimetableTemplate(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Netinkamas ID", http.StatusBadRequest)
+			return
+		}
+
+		var template models.TimetableTemplate
+		if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+			http.Error(w, "Netinkami duomenys: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := models.ValidateTimetableTemplate(&template); err != nil {
+			http.Error(w, "Netinkamas tvarkaraščio šablonas: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := models.UpdateTimetableTemplate(db, id, &template); err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Tvarkaraščio šablonas nerastas", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko atnaujinti tvarkaraščio šablono: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		updated, err := models.GetTimetableTemplateByID(db, id)
+		if err != nil {
+			http.Error(w, "Šablonas atnaujintas, bet nepavyko jo grąžinti", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(updated)
+	}
+}
+
+// DeleteTimetableTemplate removes a timetable template. Records generated
+// from it are kept.
+func DeleteTimetableTemplate(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Netinkamas ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := models.DeleteTimetableTemplate(db, id); err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Tvarkaraščio šablonas nerastas", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko ištrinti tvarkaraščio šablono: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GenerateTrainSchedules materializes train schedule records from the
+// timetable templates for a range of service days.
+//
+// Body: {"from": "2025-09-01", "to": "2025-09-30", "template_ids": [1, 2], "dry_run": true}
+// Without template_ids every active template valid in the range is used.
+// Records edited by hand, deleted or imported are left untouched and listed
+// in "skipped"; with dry_run nothing is saved.
+func GenerateTrainSchedules(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			From        string `json:"from"`
+			To          string `json:"to"`
+			TemplateIDs []int  `json:"template_ids"`
+			DryRun      bool   `json:"dry_run"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Netinkami duomenys: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		first, err := timeparse.ParseDate(req.From)
+		if err != nil {
+			http.Error(w, "Neteisinga pradžios data", http.StatusBadRequest)
+			return
+		}
+		last, err := timeparse.ParseDate(req.To)
+		if err != nil {
+			http.Error(w, "Neteisinga pabaigos data", http.StatusBadRequest)
+			return
+		}
+		if last.Before(first) {
+			http.Error(w, "Pabaigos data turi būti ne ankstesnė už pradžios datą", http.StatusBadRequest)
+			return
+		}
+		if last.After(first.AddDate(0, 0, maxGenerationDays-1)) {
+			http.Error(w, "Per ilgas laikotarpis", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		editor, err := models.NewEditor(db, userID, isAdmin, getRequestIDFromContext(r))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų teisių", http.StatusInternalServerError)
+			return
+		}
+
+		templates, err := models.GetTimetableTemplatesFor(db, first.Format("2006-01-02"), last.Format("2006-01-02"))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti tvarkaraščio šablonų: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(req.TemplateIDs) > 0 {
+			wanted := make(map[int]bool, len(req.TemplateIDs))
+			for _, id := range req.TemplateIDs {
+				wanted[id] = true
+			}
+			selected := templates[:0]
+			for _, template := range templates {
+				if wanted[template.ID] {
+					selected = append(selected, template)
+				}
+			}
+			templates = selected
+		}
+
+		result, err := models.GenerateTrainSchedules(db, templates, first, last, editor, req.DryRun)
+		if err != nil {
+			http.Error(w, "Nepavyko sugeneruoti traukinių grafiko: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
@@ -0,0 +1,341 @@
+// backend/internal/handlers/track_assignment.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"yopta-template/internal/models"
+	"yopta-template/internal/parking"
+	"yopta-template/internal/timeparse"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetStationTrackAssignments returns the parking plan of a station for a day:
+// the track assignments standing at any time of the day (date=YYYY-MM-DD,
+// Vilnius time) and the conflicts between them. Viewers only get the plans
+// of days whose depot plan is published.
+func GetStationTrackAssignments(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Neteisingas stoties ID", http.StatusBadRequest)
+			return
+		}
+
+		from, err := timeparse.ParseDate(r.URL.Query().Get("date"))
+		if err != nil {
+			http.Error(w, "Neteisinga data: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		to := from.AddDate(0, 0, 1)
+		date := from.Format("2006-01-02")
+
+		station, err := models.GetStationByID(db, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Stotis nerasta", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko gauti stoties: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		assignments := []models.TrackAssignment{}
+		published := !isViewer(r)
+		if !published {
+			plans, err := models.GetDepotPlans(db, models.DepotPlanFilter{
+				Depot: station.Code, From: date, To: date, Status: models.PlanPublished,
+			})
+			if err != nil {
+				http.Error(w, "Nepavyko gauti depo planų: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			published = len(plans) > 0
+		}
+		if published {
+			assignments, err = models.GetTrackAssignments(db, id, from, to)
+			if err != nil {
+				http.Error(w, "Nepavyko gauti kelių priskyrimų: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		checked := make([]parking.Assignment, len(assignments))
+		for i, assignment := range assignments {
+			checked[i] = assignment.Parking()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"date":        date,
+			"assignments": assignments,
+			"conflicts":   parking.Detect(station.ParkingTracks(), checked),
+		})
+	}
+}
+
+// GetTrackAssignment returns a single track assignment by ID.
+// The ETag header carries the assignment version.
+func GetTrackAssignment(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Neteisingas priskyrimo ID", http.StatusBadRequest)
+			return
+		}
+
+		assignment, err := models.GetTrackAssignmentByID(db, id)
+		if err == nil && isViewer(r) {
+			var published bool
+			published, err = models.AssignmentInPublishedPlan(db, assignment)
+			if err == nil && !published {
+				err = sql.ErrNoRows // Viewers only see published plans
+			}
+		}
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Kelio priskyrimas nerastas", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko gauti kelio priskyrimo: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		setETag(w, assignment.Version)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(assignment)
+	}
+}
+
+// writeTrackAssignmentError answers a failed write of a track assignment.
+// Returns false if the error is not one of the expected ones.
+func writeTrackAssignmentError(w http.ResponseWriter, err error) bool {
+	var trackErr *models.TrackConflictError
+	switch {
+	case errors.As(err, &trackErr):
+		writeTrackConflicts(w, trackErr.Conflicts)
+	case err == models.ErrAssignmentTrack:
+		http.Error(w, "Kelias nerastas", http.StatusBadRequest)
+	case err == models.ErrAssignmentPosition:
+		http.Error(w, "Kelyje nėra tokios pozicijos", http.StatusBadRequest)
+	case err == models.ErrAssignmentSchedule:
+		http.Error(w, "Įrašas nerastas arba neatvyksta į šią stotį ir neišvyksta iš jos", http.StatusBadRequest)
+	case err == models.ErrPlanForbidden:
+		http.Error(w, "Jūs neturite teisių planuoti šio depo", http.StatusForbidden)
+	case err == models.ErrPlanPublished:
+		http.Error(w, "Depo dienos planas paskelbtas: priskyrimų keisti negalima", http.StatusConflict)
+	default:
+		return false
+	}
+	return true
+}
+
+// writeTrackAssignment answers a saved track assignment with its current
+// state and the conflicts allowed as exceptions of its track.
+func writeTrackAssignment(w http.ResponseWriter, db *sql.DB, id int, conflicts []parking.Conflict, status int) {
+	saved, err := models.GetTrackAssignmentByID(db, id)
+	if err != nil {
+		http.Error(w, "Priskyrimas išsaugotas, bet nepavyko jo grąžinti", http.StatusInternalServerError)
+		return
+	}
+
+	setETag(w, saved.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"assignment": saved,
+		"conflicts":  conflicts,
+	})
+}
+
+// CreateTrackAssignment parks the vehicle of a record on a track position.
+// Admins and the depot's editors may plan the tracks. Placements that break
+// the rules of a track without exceptions are rejected with 409 and the conflicts.
+func CreateTrackAssignment(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if rejectViewer(w, r) {
+			return
+		}
+		var assignment models.TrackAssignment
+		if err := json.NewDecoder(r.Body).Decode(&assignment); err != nil {
+			http.Error(w, "Netinkami duomenys: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := models.ValidateTrackAssignment(&assignment); err != nil {
+			http.Error(w, "Netinkamas kelio priskyrimas: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		editor, err := models.NewEditor(db, userID, isAdmin, getRequestIDFromContext(r))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų teisių", http.StatusInternalServerError)
+			return
+		}
+
+		conflicts, err := models.CreateTrackAssignment(db, &assignment, editor)
+		if err != nil {
+			if !writeTrackAssignmentError(w, err) {
+				http.Error(w, "Nepavyko sukurti kelio priskyrimo: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeTrackAssignment(w, db, assignment.ID, conflicts, http.StatusCreated)
+	}
+}
+
+// UpdateTrackAssignment moves a track assignment to another track, position
+// or time. Send the assignment's ETag in If-Match so changes made by another
+// dispatcher meanwhile are not overwritten.
+func UpdateTrackAssignment(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if rejectViewer(w, r) {
+			return
+		}
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Neteisingas priskyrimo ID", http.StatusBadRequest)
+			return
+		}
+
+		var assignment models.TrackAssignment
+		if err := json.NewDecoder(r.Body).Decode(&assignment); err != nil {
+			http.Error(w, "Netinkami duomenys: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := models.ValidateTrackAssignment(&assignment); err != nil {
+			http.Error(w, "Netinkamas kelio priskyrimas: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// If-Match takes precedence over the version sent in the body
+		expectedVersion, err := ifMatchVersion(r)
+		if err != nil {
+			http.Error(w, "Neteisinga If-Match antraštė", http.StatusBadRequest)
+			return
+		}
+		if expectedVersion == 0 {
+			expectedVersion = assignment.Version
+		}
+
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		editor, err := models.NewEditor(db, userID, isAdmin, getRequestIDFromContext(r))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų teisių", http.StatusInternalServerError)
+			return
+		}
+
+		conflicts, err := models.UpdateTrackAssignment(db, id, &assignment, expectedVersion, editor)
+		if err != nil {
+			if errors.Is(err, models.ErrVersionConflict) {
+				writeTrackAssignmentConflict(w, db, id)
+			} else if err == sql.ErrNoRows {
+				http.Error(w, "Kelio priskyrimas nerastas", http.StatusNotFound)
+			} else if !writeTrackAssignmentError(w, err) {
+				http.Error(w, "Nepavyko atnaujinti kelio priskyrimo: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeTrackAssignment(w, db, id, conflicts, http.StatusOK)
+	}
+}
+
+// DeleteTrackAssignment removes a track assignment from the parking plan.
+func DeleteTrackAssignment(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if rejectViewer(w, r) {
+			return
+		}
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Neteisingas priskyrimo ID", http.StatusBadRequest)
+			return
+		}
+
+		expectedVersion, err := ifMatchVersion(r)
+		if err != nil {
+			http.Error(w, "Neteisinga If-Match antraštė", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		editor, err := models.NewEditor(db, userID, isAdmin, getRequestIDFromContext(r))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų teisių", http.StatusInternalServerError)
+			return
+		}
+
+		if err := models.DeleteTrackAssignment(db, id, expectedVersion, editor); err != nil {
+			if errors.Is(err, models.ErrVersionConflict) {
+				writeTrackAssignmentConflict(w, db, id)
+			} else if err == sql.ErrNoRows {
+				http.Error(w, "Kelio priskyrimas nerastas", http.StatusNotFound)
+			} else if !writeTrackAssignmentError(w, err) {
+				http.Error(w, "Nepavyko ištrinti kelio priskyrimo: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// writeTrackAssignmentConflict answers a stale write with the current assignment.
+func writeTrackAssignmentConflict(w http.ResponseWriter, db *sql.DB, id int) {
+	current, err := models.GetTrackAssignmentByID(db, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Priskyrimas buvo ištrintas kito vartotojo", http.StatusConflict)
+		} else {
+			http.Error(w, "Nepavyko gauti kelio priskyrimo: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeVersionConflict(
+		w,
+		"Priskyrimą jau pakeitė kitas vartotojas", // The assignment was already changed by another user
+		current,
+		current.Version,
+	)
+}
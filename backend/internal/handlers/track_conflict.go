@@ -0,0 +1,92 @@
+// backend/internal/handlers/track_conflict.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"yopta-template/internal/models"
+	"yopta-template/internal/parking"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TrackConflictRequest lists the track assignments to check at a station.
+type TrackConflictRequest struct {
+	Assignments []parking.Assignment `json:"assignments"`
+}
+
+// writeTrackConflicts answers a change that breaks the rules of station
+// tracks with 409 Conflict and the conflicts it causes.
+func writeTrackConflicts(w http.ResponseWriter, conflicts []parking.Conflict) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string]any{
+		"message":   "Pakeitimas pažeidžia kelio taisykles", // The change breaks the track rules
+		"conflicts": conflicts,
+	})
+}
+
+// CheckTrackConflicts checks a set of track assignments against the tracks
+// of a station: every assignment needs an existing track and position, two
+// vehicles may not stand on one position at once, and vehicles must enter
+// and leave in the order the fifo or filo rule of the track allows.
+//
+// Body: {"assignments": [{"id": "a1", "vehicle": "2M62-0110", "track": "3",
+// "position": 2, "start": "2025-09-01T18:40:00Z", "end": "2025-09-02T05:10:00Z"}]}
+// The response lists the conflicts in time order; "blocking" counts those on
+// tracks that allow no exceptions. Nothing is saved.
+func CheckTrackConflicts(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Neteisingas stoties ID", http.StatusBadRequest)
+			return
+		}
+
+		var req TrackConflictRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Neteisingas užklausos formatas", http.StatusBadRequest)
+			return
+		}
+
+		seen := make(map[string]bool, len(req.Assignments))
+		for i, assignment := range req.Assignments {
+			if assignment.ID == "" || seen[assignment.ID] {
+				http.Error(w, "Kiekvienam priskyrimui reikia unikalaus ID (nr. "+strconv.Itoa(i+1)+")", http.StatusBadRequest)
+				return
+			}
+			seen[assignment.ID] = true
+			if assignment.Start.IsZero() || !assignment.End.After(assignment.Start) {
+				http.Error(w, "Priskyrimo "+assignment.ID+" pabaiga turi būti vėlesnė už pradžią", http.StatusBadRequest)
+				return
+			}
+		}
+
+		station, err := models.GetStationByID(db, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Stotis nerasta", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko gauti stoties: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		conflicts := parking.Detect(station.ParkingTracks(), req.Assignments)
+		blocking := 0
+		for _, conflict := range conflicts {
+			if !conflict.Exception {
+				blocking++
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"conflicts": conflicts,
+			"blocking":  blocking,
+		})
+	}
+}
@@ -0,0 +1,857 @@
+// backend/internal/handlers/train_schedule.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"yopta-template/internal/importer"
+	"yopta-template/internal/models"
+	"yopta-template/internal/timeparse"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TrainScheduleSaveRequest represents a batch of schedule records sent by the client.
+// The Klasika page sends the whole parsed clipboard import in one request.
+type TrainScheduleSaveRequest struct {
+	Records []models.TrainSchedule `json:"records"` // Records to insert or update
+}
+
+// TrainScheduleFieldUpdate represents a single-field change of a schedule record.
+// Used when a dispatcher assigns a track or writes a note.
+type TrainScheduleFieldUpdate struct {
+	Field string `json:"field"` // Field name in camelCase (e.g., "targetTrack")
+	Value string `json:"value"` // New value for the field
+}
+
+// TrainScheduleImportRequest carries raw text pasted from the planning system.
+// Clients may also send the text directly with a text/plain content type and
+// pass the options as dry_run, fingerprint and remove_missing query parameters.
+type TrainScheduleImportRequest struct {
+	Text          string `json:"text"`          // Tab-separated export including the header line
+	DryRun        bool   `json:"dryRun"`        // Only compare with the stored records, save nothing
+	Fingerprint   string `json:"fingerprint"`   // Fingerprint of the confirmed preview, required unless DryRun
+	RemoveMissing bool   `json:"removeMissing"` // Delete stored records the import no longer contains
+}
+
+// maxImportSize limits the size of an uploaded import (10 MB).
+const maxImportSize = 10 << 20
+
+// Page sizes of the train schedule list
+const (
+	defaultTrainScheduleLimit = 200
+	maxTrainScheduleLimit     = 1000
+)
+
+// GetTrainSchedules returns one page of stored train schedule records.
+// Regular users see their own records, while admins see everything
+// and may narrow the list down with the user_id query parameter.
+//
+// Query parameters:
+//   - depot: starting or end location code
+//   - date: a single day (YYYY-MM-DD), or date_from / date_to as days or timestamps
+//   - shift: shift name of the depot on the given date; the response then
+//     includes the shift window and its early and late spill-over records
+//   - train_number, vehicle, employee, track: further filters
+//   - sort: time (default), departure, arrival, train_number, vehicle, updated,
+//     deleted (trash listing only)
+//   - order: desc (default) or asc
+//   - limit: page size (default 200, max 1000)
+//   - cursor: nextCursor from the previous page
+func GetTrainSchedules(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		filter, message := trainScheduleFilterFromQuery(r, userID, isAdmin)
+		if message != "" {
+			http.Error(w, message, http.StatusBadRequest)
+			return
+		}
+
+		var list *models.TrainScheduleList
+		if shiftName := r.URL.Query().Get("shift"); shiftName != "" {
+			// A shift is defined per depot and placed on the requested day
+			day, err := timeparse.ParseDate(r.URL.Query().Get("date"))
+			if filter.Depot == "" || err != nil {
+				http.Error(w, "Pamainai reikia nurodyti depą ir datą", http.StatusBadRequest)
+				return
+			}
+
+			shift, err := models.GetShiftByName(db, filter.Depot, shiftName)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					http.Error(w, "Pamaina nerasta", http.StatusNotFound)
+				} else {
+					http.Error(w, "Nepavyko gauti pamainos: "+err.Error(), http.StatusInternalServerError)
+				}
+				return
+			}
+
+			list, err = models.GetTrainSchedulesInShift(db, filter, shift, day)
+		} else {
+			list, err = models.GetTrainSchedules(db, filter)
+		}
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidCursor) {
+				http.Error(w, "Neteisingas puslapio žymeklis", http.StatusBadRequest)
+				return
+			}
+			http.Error(
+				w,
+				"Nepavyko gauti traukinių grafiko: "+err.Error(),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// GetTrainScheduleDepots returns the depot codes found in the visible records.
+// Accepts the same filters as GetTrainSchedules except depot, so the depot
+// picker can offer only depots that have trains on the selected day.
+func GetTrainScheduleDepots(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		filter, message := trainScheduleFilterFromQuery(r, userID, isAdmin)
+		if message != "" {
+			http.Error(w, message, http.StatusBadRequest)
+			return
+		}
+
+		depots, err := models.GetTrainScheduleDepots(db, filter)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti depų sąrašo: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(depots)
+	}
+}
+
+// trainScheduleFilterFromQuery reads the list filters from the query string.
+// Returns a user-facing message if a parameter is invalid.
+func trainScheduleFilterFromQuery(r *http.Request, userID int, isAdmin bool) (models.TrainScheduleFilter, string) {
+	query := r.URL.Query()
+	filter := models.TrainScheduleFilter{
+		VisibleTo:   userID,
+		Depot:       strings.TrimSpace(query.Get("depot")),
+		TrainNumber: strings.TrimSpace(query.Get("train_number")),
+		VehicleName: strings.TrimSpace(query.Get("vehicle")),
+		Employee:    strings.TrimSpace(query.Get("employee")),
+		Track:       strings.TrimSpace(query.Get("track")),
+		Sort:        query.Get("sort"),
+		Descending:  true,
+		Limit:       defaultTrainScheduleLimit,
+		Cursor:      query.Get("cursor"),
+	}
+
+	// Users see their own records and those touching their depots;
+	// admins see all records unless a specific user is requested;
+	// viewers see the records of published depot plans
+	if isViewer(r) {
+		filter.VisibleTo = 0
+		filter.Published = true
+	}
+	if isAdmin {
+		filter.VisibleTo = 0
+		if userIDParam := query.Get("user_id"); userIDParam != "" {
+			id, err := strconv.Atoi(userIDParam)
+			if err != nil {
+				return filter, "Neteisingas vartotojo ID"
+			}
+			filter.UserID = id
+		}
+	}
+
+	if filter.Sort != "" && !models.IsTrainScheduleSort(filter.Sort) {
+		return filter, "Neteisingas rikiavimo laukas"
+	}
+
+	switch query.Get("order") {
+	case "", "desc":
+	case "asc":
+		filter.Descending = false
+	default:
+		return filter, "Neteisinga rikiavimo kryptis"
+	}
+
+	if limitParam := query.Get("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit < 1 || limit > maxTrainScheduleLimit {
+			return filter, "Neteisingas įrašų skaičius puslapyje"
+		}
+		filter.Limit = limit
+	}
+
+	// A single day is shorthand for a range from its midnight to the next one
+	if day := query.Get("date"); day != "" {
+		from, err := timeparse.ParseDate(day)
+		if err != nil {
+			return filter, "Neteisinga data: " + err.Error()
+		}
+		to := from.AddDate(0, 0, 1)
+		filter.From, filter.To = &from, &to
+	}
+
+	if value := query.Get("date_from"); value != "" {
+		from, _, err := parseRangeBound(value)
+		if err != nil {
+			return filter, "Neteisinga pradžios data: " + err.Error()
+		}
+		filter.From = &from
+	}
+
+	if value := query.Get("date_to"); value != "" {
+		to, dateOnly, err := parseRangeBound(value)
+		if err != nil {
+			return filter, "Neteisinga pabaigos data: " + err.Error()
+		}
+		// A date without time includes the whole day
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = &to
+	}
+
+	return filter, ""
+}
+
+// parseRangeBound parses a date or a timestamp in Vilnius time.
+// Reports whether the value was a date without a time.
+func parseRangeBound(value string) (time.Time, bool, error) {
+	if strings.Contains(value, ":") {
+		t, err := timeparse.ParseTimestamp(value)
+		return t, false, err
+	}
+	t, err := timeparse.ParseDate(value)
+	return t, true, err
+}
+
+// GetTrainSchedule returns a single train schedule record.
+// The ETag header carries the record version for later If-Match writes.
+func GetTrainSchedule(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			http.Error(w, "Neteisingas įrašo ID", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		editor, err := models.NewEditor(db, userID, isAdmin, getRequestIDFromContext(r))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų teisių", http.StatusInternalServerError)
+			return
+		}
+
+		schedule, err := models.GetTrainScheduleByID(db, id)
+		if err == nil {
+			err = checkReadable(db, r, editor, schedule)
+		}
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Įrašas nerastas", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko gauti įrašo: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		setETag(w, schedule.Version)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(schedule)
+	}
+}
+
+// SaveTrainSchedules stores a batch of train schedule records.
+// New records are inserted and existing ones are updated by ID,
+// so re-sending the same import is safe. Records that cannot be saved
+// (other owner, stale version, missing ID, a change of a field that is not
+// editable or a value its rules reject) are listed in "failed" while
+// the rest of the batch is stored. Records that park a vehicle against the
+// rules of a station track are stored and listed in "trackConflicts".
+// Send "Accept: application/x-ndjson" to receive progress lines while a
+// large batch is being written.
+func SaveTrainSchedules(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if rejectViewer(w, r) {
+			return
+		}
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		var request TrainScheduleSaveRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Neteisingas užklausos formatas", http.StatusBadRequest)
+			return
+		}
+
+		if len(request.Records) == 0 {
+			http.Error(w, "Nėra įrašų, kuriuos reikia išsaugoti", http.StatusBadRequest)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		editor, err := models.NewEditor(db, userID, isAdmin, getRequestIDFromContext(r))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų teisių", http.StatusInternalServerError)
+			return
+		}
+
+		progress := newBulkProgress(w, r)
+		result, err := models.SaveTrainSchedules(
+			db,
+			request.Records,
+			editor,
+			models.TrainScheduleSaveOptions{Progress: progress.callback()},
+		)
+		if err != nil {
+			writeBulkError(
+				w,
+				progress,
+				"Nepavyko išsaugoti traukinių grafiko: "+err.Error(),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		message := "Traukinių grafikas sėkmingai išsaugotas" // Train schedule successfully saved
+		if len(result.Failed) > 0 {
+			message = "Traukinių grafikas išsaugotas iš dalies" // Train schedule partially saved
+		}
+
+		writeBulkResult(w, progress, saveResultStatus(result), map[string]any{
+			"message":        message,
+			"processed":      result.Processed,
+			"created":        result.Created,
+			"updated":        result.Updated,
+			"unchanged":      result.Unchanged,
+			"failed":         result.Failed,
+			"trackConflicts": result.TrackConflicts,
+		})
+	}
+}
+
+// UpdateTrainScheduleField changes a single field of a train schedule record.
+// This is what the dispatcher uses to move a locomotive to another track
+// or to leave a note, without re-sending the whole record. Which fields can
+// be changed and the values they accept are configured by admins.
+// A move that breaks the rules of a station track is rejected with 409 and
+// the conflicts, unless the track allows exceptions.
+func UpdateTrainScheduleField(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if rejectViewer(w, r) {
+			return
+		}
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			http.Error(w, "Neteisingas įrašo ID", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		expectedVersion, err := ifMatchVersion(r)
+		if err != nil {
+			http.Error(w, "Neteisinga If-Match antraštė", http.StatusBadRequest)
+			return
+		}
+
+		var update TrainScheduleFieldUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, "Neteisingas užklausos formatas", http.StatusBadRequest)
+			return
+		}
+
+		if update.Field == "" {
+			http.Error(w, "Lauko pavadinimas yra būtinas", http.StatusBadRequest)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		editor, err := models.NewEditor(db, userID, isAdmin, getRequestIDFromContext(r))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų teisių", http.StatusInternalServerError)
+			return
+		}
+		version, conflicts, err := models.UpdateTrainScheduleField(
+			db, id, update.Field, update.Value, expectedVersion, editor,
+		)
+		var valueErr *models.FieldValueError
+		var trackErr *models.TrackConflictError
+		if err != nil {
+			if errors.Is(err, models.ErrVersionConflict) {
+				writeTrainScheduleConflict(w, db, id)
+			} else if errors.As(err, &valueErr) {
+				http.Error(w, fieldValueMessage(valueErr), http.StatusBadRequest)
+			} else if errors.As(err, &trackErr) {
+				writeTrackConflicts(w, trackErr.Conflicts)
+			} else if err == models.ErrPlanPublished {
+				http.Error(w, planPublishedMessage, http.StatusConflict)
+			} else if err == models.ErrScheduleForbidden {
+				http.Error(w, "Jūs neredaguojate nė vieno įrašo depo", http.StatusForbidden)
+			} else if err == sql.ErrNoRows {
+				http.Error(w, "Įrašas nerastas arba laukas neredaguojamas", http.StatusNotFound)
+			} else {
+				http.Error(
+					w,
+					"Nepavyko atnaujinti įrašo: "+err.Error(),
+					http.StatusInternalServerError,
+				)
+			}
+			return
+		}
+
+		setETag(w, version)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"message":        "Įrašas sėkmingai atnaujintas", // Record successfully updated
+			"version":        version,
+			"trackConflicts": conflicts, // Conflicts allowed as exceptions or there before
+		})
+	}
+}
+
+// DeleteTrainSchedule moves a train schedule record to the trash, from where
+// it can be restored until the trash is purged.
+// Only users who may edit the record can delete it.
+func DeleteTrainSchedule(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if rejectViewer(w, r) {
+			return
+		}
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			http.Error(w, "Neteisingas įrašo ID", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		expectedVersion, err := ifMatchVersion(r)
+		if err != nil {
+			http.Error(w, "Neteisinga If-Match antraštė", http.StatusBadRequest)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		editor, err := models.NewEditor(db, userID, isAdmin, getRequestIDFromContext(r))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų teisių", http.StatusInternalServerError)
+			return
+		}
+		if err := models.DeleteTrainSchedule(db, id, expectedVersion, editor); err != nil {
+			if errors.Is(err, models.ErrVersionConflict) {
+				writeTrainScheduleConflict(w, db, id)
+			} else if err == models.ErrPlanPublished {
+				http.Error(w, planPublishedMessage, http.StatusConflict)
+			} else if err == sql.ErrNoRows {
+				http.Error(w, "Įrašas nerastas", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko ištrinti įrašo: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ImportTrainSchedules parses a pasted tab-separated export and stores the valid rows.
+// Columns are mapped through field_mappings, so required flags and field types
+// configured by admins apply here. Rows that fail validation are reported back
+// with their line number instead of being dropped silently.
+//
+// Every import is compared with the stored records of the same depot days.
+// With dryRun the comparison is returned and nothing is saved; the dispatcher
+// reviews it and sends the import again with the preview's fingerprint. An
+// import without a fingerprint is refused with 428 and the diff; if the
+// stored records changed in between, it is refused with 409 and the new diff.
+// Track assignments and notes missing from the export are kept. Rows matching
+// records of depots the user does not see are listed in diff.forbidden and
+// not saved.
+func ImportTrainSchedules(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if rejectViewer(w, r) {
+			return
+		}
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		// Accept either {"text": "..."} or the raw text itself
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+		var request TrainScheduleImportRequest
+		if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				http.Error(w, "Neteisingas užklausos formatas", http.StatusBadRequest)
+				return
+			}
+		} else {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "Nepavyko nuskaityti užklausos", http.StatusBadRequest)
+				return
+			}
+			query := r.URL.Query()
+			request = TrainScheduleImportRequest{
+				Text:          string(body),
+				DryRun:        queryFlag(query.Get("dry_run")),
+				Fingerprint:   query.Get("fingerprint"),
+				RemoveMissing: queryFlag(query.Get("remove_missing")),
+			}
+		}
+
+		if strings.TrimSpace(request.Text) == "" {
+			http.Error(w, "Nėra duomenų importavimui", http.StatusBadRequest)
+			return
+		}
+
+		mappings, err := models.GetAllFieldMappings(db)
+		if err != nil {
+			http.Error(
+				w,
+				"Nepavyko gauti laukų atvaizdavimų: "+err.Error(),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		result, err := importer.ParseClipboard(request.Text, mappings)
+		if err != nil {
+			http.Error(w, "Netinkamas duomenų formatas: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		editor, err := models.NewEditor(db, userID, isAdmin, getRequestIDFromContext(r))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų teisių", http.StatusInternalServerError)
+			return
+		}
+
+		// Users only see and replace the records they may edit
+		diff, err := models.DiffTrainScheduleImport(db, result.Records, editor)
+		if err != nil {
+			http.Error(
+				w,
+				"Nepavyko palyginti su išsaugotu grafiku: "+err.Error(),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		response := map[string]any{
+			"records":   result.Records,
+			"depots":    result.Depots,
+			"dates":     result.Dates,
+			"errors":    result.Errors,
+			"totalRows": result.TotalRows,
+			"diff":      diff,
+		}
+
+		if request.DryRun {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+
+		// Imports are only saved after the dispatcher confirmed a preview
+		if request.Fingerprint == "" {
+			response["message"] = "Pirmiausia peržiūrėkite pakeitimus ir patvirtinkite importą"
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusPreconditionRequired)
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+
+		// The stored records changed since the dispatcher reviewed the preview
+		if request.Fingerprint != diff.Fingerprint {
+			response["message"] = "Grafikas pasikeitė nuo peržiūros, patikrinkite pakeitimus dar kartą"
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+
+		progress := newBulkProgress(w, r)
+		saved := &models.TrainScheduleSaveResult{Failed: []models.TrainScheduleRowError{}}
+		if len(diff.Records) > 0 {
+			saved, err = models.SaveTrainSchedules(
+				db,
+				diff.Records,
+				editor,
+				models.TrainScheduleSaveOptions{Progress: progress.callback(), Imported: true},
+			)
+			if err != nil {
+				writeBulkError(
+					w,
+					progress,
+					"Nepavyko išsaugoti traukinių grafiko: "+err.Error(),
+					http.StatusInternalServerError,
+				)
+				return
+			}
+		}
+
+		removed, removeFailed := 0, []models.TrainScheduleRowError{}
+		if request.RemoveMissing && len(diff.Removed) > 0 {
+			removed, removeFailed = models.DeleteTrainSchedules(db, diff.Removed, editor)
+		}
+
+		response["processed"] = saved.Processed
+		response["created"] = saved.Created
+		response["updated"] = saved.Updated
+		response["unchanged"] = saved.Unchanged
+		response["failed"] = saved.Failed
+		response["trackConflicts"] = saved.TrackConflicts
+		response["removed"] = removed
+		response["removeFailed"] = removeFailed
+		writeBulkResult(w, progress, http.StatusOK, response)
+	}
+}
+
+// queryFlag reads a boolean query parameter ("1" or "true").
+func queryFlag(value string) bool {
+	return value == "1" || strings.EqualFold(value, "true")
+}
+
+// GetTrainScheduleRevisions returns the change history of a train schedule record.
+// Regular users see the history of their own records; admins see every record,
+// including deleted ones.
+func GetTrainScheduleRevisions(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			http.Error(w, "Neteisingas įrašo ID", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		editor, err := models.NewEditor(db, userID, isAdmin, getRequestIDFromContext(r))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų teisių", http.StatusInternalServerError)
+			return
+		}
+
+		record, err := models.TrainScheduleHistoryRecord(db, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Įrašas nerastas", http.StatusNotFound)
+			} else {
+				http.Error(
+					w,
+					"Nepavyko gauti įrašo istorijos: "+err.Error(),
+					http.StatusInternalServerError,
+				)
+			}
+			return
+		}
+		if err := checkReadable(db, r, editor, record); err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Įrašas nerastas", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko patikrinti depo plano: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		revisions, err := models.GetTrainScheduleRevisions(db, id)
+		if err != nil {
+			http.Error(
+				w,
+				"Nepavyko gauti įrašo istorijos: "+err.Error(),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		if revisions == nil {
+			revisions = []models.TrainScheduleRevision{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(revisions)
+	}
+}
+
+// RevertTrainSchedule restores a train schedule record to the state it had
+// right after the chosen revision. The revert itself is recorded in the history.
+// Like a field update, it takes the record version in If-Match and is rejected
+// with 409 if the record changed meanwhile or a track rule would be broken.
+func RevertTrainSchedule(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if rejectViewer(w, r) {
+			return
+		}
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			http.Error(w, "Neteisingas įrašo ID", http.StatusBadRequest)
+			return
+		}
+
+		revisionID, err := strconv.ParseInt(chi.URLParam(r, "revisionId"), 10, 64)
+		if err != nil {
+			http.Error(w, "Neteisingas versijos ID", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		expectedVersion, err := ifMatchVersion(r)
+		if err != nil {
+			http.Error(w, "Neteisinga If-Match antraštė", http.StatusBadRequest)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		editor, err := models.NewEditor(db, userID, isAdmin, getRequestIDFromContext(r))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų teisių", http.StatusInternalServerError)
+			return
+		}
+		schedule, err := models.RevertTrainSchedule(db, id, revisionID, expectedVersion, editor)
+		var trackErr *models.TrackConflictError
+		if err != nil {
+			if errors.Is(err, models.ErrVersionConflict) {
+				writeTrainScheduleConflict(w, db, id)
+			} else if errors.As(err, &trackErr) {
+				writeTrackConflicts(w, trackErr.Conflicts)
+			} else if err == sql.ErrNoRows {
+				http.Error(w, "Įrašas arba versija nerasta", http.StatusNotFound)
+			} else if err == models.ErrPlanPublished {
+				http.Error(w, planPublishedMessage, http.StatusConflict)
+			} else if err == models.ErrScheduleForbidden {
+				http.Error(w, "Jūs neredaguojate nė vieno įrašo depo", http.StatusForbidden)
+			} else {
+				http.Error(
+					w,
+					"Nepavyko atkurti įrašo versijos: "+err.Error(),
+					http.StatusInternalServerError,
+				)
+			}
+			return
+		}
+
+		setETag(w, schedule.Version)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(schedule)
+	}
+}
+
+// writeTrainScheduleConflict answers a stale train schedule write with 409
+// and the record as it is stored now.
+func writeTrainScheduleConflict(w http.ResponseWriter, db *sql.DB, id string) {
+	current, err := models.GetTrainScheduleByID(db, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Įrašas buvo ištrintas kito vartotojo", http.StatusConflict)
+		} else {
+			http.Error(w, "Nepavyko gauti įrašo: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeVersionConflict(
+		w,
+		"Įrašą jau pakeitė kitas vartotojas", // The record was already changed by another user
+		current,
+		current.Version,
+	)
+}
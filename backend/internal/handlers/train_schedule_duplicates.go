@@ -0,0 +1,119 @@
+// backend/internal/handlers/train_schedule_duplicates.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"yopta-template/internal/models"
+)
+
+// TrainScheduleMergeRequest lists the duplicate groups an admin confirmed.
+// Each group holds the IDs of records to merge into one.
+type TrainScheduleMergeRequest struct {
+	Groups [][]string `json:"groups"`
+}
+
+// TrainScheduleMergeResult is the outcome of merging one group.
+type TrainScheduleMergeResult struct {
+	IDs     []string              `json:"ids"`               // IDs of the merged records
+	Record  *models.TrainSchedule `json:"record,omitempty"`  // Resulting record
+	Message string                `json:"message,omitempty"` // Why the merge failed
+
+	// Parking of the other records, removed so the merged record stands in one place
+	DroppedAssignments []models.TrackAssignment `json:"droppedAssignments,omitempty"`
+}
+
+// FindTrainScheduleDuplicates lists groups of records that probably describe
+// the same train, with a preview of the merged record. The optional date_from
+// and date_to parameters (days or timestamps) limit the search.
+func FindTrainScheduleDuplicates(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var from, to *time.Time
+		if value := r.URL.Query().Get("date_from"); value != "" {
+			t, _, err := parseRangeBound(value)
+			if err != nil {
+				http.Error(w, "Neteisinga pradžios data: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			from = &t
+		}
+		if value := r.URL.Query().Get("date_to"); value != "" {
+			t, dateOnly, err := parseRangeBound(value)
+			if err != nil {
+				http.Error(w, "Neteisinga pabaigos data: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if dateOnly {
+				t = t.AddDate(0, 0, 1)
+			}
+			to = &t
+		}
+
+		groups, err := models.FindTrainScheduleDuplicates(db, from, to)
+		if err != nil {
+			http.Error(w, "Nepavyko rasti dublikatų: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(groups)
+	}
+}
+
+// MergeTrainScheduleDuplicates merges the confirmed duplicate groups.
+// Each group is merged in its own transaction; a failed group does not
+// stop the others and is reported with its message. Track assignments the
+// merged record cannot keep are removed and listed with their group.
+func MergeTrainScheduleDuplicates(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		var request TrainScheduleMergeRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Neteisingas užklausos formatas", http.StatusBadRequest)
+			return
+		}
+		if len(request.Groups) == 0 {
+			http.Error(w, "Nenurodyta jokių įrašų grupių", http.StatusBadRequest)
+			return
+		}
+
+		// Only admins reach this handler
+		editor := models.Editor{UserID: userID, IsAdmin: true, RequestID: getRequestIDFromContext(r)}
+
+		results := make([]TrainScheduleMergeResult, 0, len(request.Groups))
+		merged := 0
+		for _, ids := range request.Groups {
+			result := TrainScheduleMergeResult{IDs: ids}
+
+			record, dropped, err := models.MergeTrainSchedules(db, ids, editor)
+			switch {
+			case err == nil:
+				result.Record = record
+				result.DroppedAssignments = dropped
+				merged++
+			case err == sql.ErrNoRows:
+				result.Message = "Kai kurie įrašai nerasti"
+			case err == models.ErrPlanPublished:
+				result.Message = planPublishedMessage
+			default:
+				result.Message = "Nepavyko sujungti įrašų: " + err.Error()
+			}
+
+			results = append(results, result)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"merged":  merged,
+			"results": results,
+		})
+	}
+}
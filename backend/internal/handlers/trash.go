@@ -0,0 +1,214 @@
+// backend/internal/handlers/trash.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"yopta-template/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetTrainScheduleTrash lists the deleted train schedule records the user
+// may see. Accepts the same filters and pagination as GetTrainSchedules;
+// sort=deleted orders the records by the time they were deleted.
+func GetTrainScheduleTrash(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		filter, message := trainScheduleFilterFromQuery(r, userID, isAdmin)
+		if message != "" {
+			http.Error(w, message, http.StatusBadRequest)
+			return
+		}
+		filter.Deleted = true
+
+		list, err := models.GetTrainSchedules(db, filter)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidCursor) {
+				http.Error(w, "Neteisingas puslapio žymeklis", http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "Nepavyko gauti šiukšliadėžės: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// RestoreTrainSchedule takes a deleted record out of the trash and returns it.
+// Only users who may edit the record can restore it, and a restore that
+// breaks the rules of a track is rejected with the conflicts.
+// The ETag header carries the new record version.
+func RestoreTrainSchedule(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if rejectViewer(w, r) {
+			return
+		}
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			http.Error(w, "Neteisingas įrašo ID", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		editor, err := models.NewEditor(db, userID, isAdmin, getRequestIDFromContext(r))
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo depų teisių", http.StatusInternalServerError)
+			return
+		}
+
+		restored, err := models.RestoreTrainSchedule(db, id, editor)
+		if err != nil {
+			var trackErr *models.TrackConflictError
+			if err == sql.ErrNoRows {
+				http.Error(w, "Įrašas nerastas šiukšliadėžėje", http.StatusNotFound)
+			} else if err == models.ErrPlanPublished {
+				http.Error(w, planPublishedMessage, http.StatusConflict)
+			} else if errors.As(err, &trackErr) {
+				writeTrackConflicts(w, trackErr.Conflicts)
+			} else {
+				http.Error(w, "Nepavyko atkurti įrašo: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		setETag(w, restored.Version)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(restored)
+	}
+}
+
+// PurgeTrainSchedule removes a deleted record for good.
+// Its revision history stays available.
+func PurgeTrainSchedule(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			http.Error(w, "Neteisingas įrašo ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := models.PurgeTrainSchedule(db, id); err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Įrašas nerastas šiukšliadėžėje", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko ištrinti įrašo: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetStationTrash lists the deleted stations with their tracks, most recently deleted first
+func GetStationTrash(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stations, err := models.GetDeletedStations(db)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti šiukšliadėžės: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stations)
+	}
+}
+
+// RestoreStation takes a deleted station out of the trash with its tracks,
+// shifts and depot team, and returns it
+func RestoreStation(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Neteisingas stoties ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := models.RestoreStation(db, id); err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Stotis nerasta šiukšliadėžėje", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko atkurti stoties: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		restored, err := models.GetStationByID(db, id)
+		if err != nil {
+			http.Error(w, "Stotis atkurta, bet nepavyko jos grąžinti", http.StatusInternalServerError)
+			return
+		}
+
+		setETag(w, restored.Version)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(restored)
+	}
+}
+
+// PurgeStation removes a deleted station for good, together with its tracks,
+// shifts and depot team
+func PurgeStation(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Neteisingas stoties ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := models.PurgeStation(db, id); err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Stotis nerasta šiukšliadėžėje", http.StatusNotFound)
+			} else {
+				http.Error(w, "Nepavyko ištrinti stoties: "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// PurgeExpiredTrash purges the records and stations kept in the trash longer
+// than the trash_retention_days setting allows, without waiting for the
+// hourly purge, and returns how many were removed
+func PurgeExpiredTrash(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		purged, err := models.PurgeExpiredTrash(db)
+		if err != nil {
+			http.Error(w, "Nepavyko išvalyti šiukšliadėžės: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(purged)
+	}
+}
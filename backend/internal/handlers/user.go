@@ -230,6 +230,13 @@ func getUserIDFromContext(r *http.Request) (int, error) {
 	return userID, nil
 }
 
+// getRequestIDFromContext returns the request ID set by the request ID middleware.
+// Returns an empty string if the request has none.
+func getRequestIDFromContext(r *http.Request) string {
+	requestID, _ := r.Context().Value("request_id").(string)
+	return requestID
+}
+
 // Test is a simple handler for testing purposes.
 // Sometimes we all need a simple "Hello World" equivalent
 // to verify our system is working as expected!
@@ -0,0 +1,205 @@
+// backend/internal/handlers/vehicle_name_rules.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"yopta-template/internal/models"
+	"yopta-template/internal/vehiclename"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// VehicleNameTestRequest is a type/number pair to resolve with the rules.
+// When Rule is given, only that (possibly unsaved) rule is tried, so admins
+// can check a new rule before storing it.
+type VehicleNameTestRequest struct {
+	TechnicalVehicleType string                  `json:"technical_vehicle_type"` // e.g. "630M,630P"
+	VehicleNo            string                  `json:"vehicle_no"`             // e.g. "632-010,631-010"
+	Rule                 *models.VehicleNameRule `json:"rule,omitempty"`         // Optional draft rule
+}
+
+// GetVehicleNameRules returns all vehicle name rules in the order they are tried
+func GetVehicleNameRules(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rules, err := models.GetAllVehicleNameRules(db)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti riedmenų pavadinimų taisyklių: "+err.Error(),
+				http.StatusInternalServerError)
+			return
+		}
+
+		if rules == nil {
+			rules = []models.VehicleNameRule{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rules)
+	}
+}
+
+// GetVehicleNameRule returns a single vehicle name rule by ID
+func GetVehicleNameRule(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Netinkamas ID", http.StatusBadRequest)
+			return
+		}
+
+		rule, err := models.GetVehicleNameRuleByID(db, id)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti riedmens pavadinimo taisyklės: "+err.Error(),
+				http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rule)
+	}
+}
+
+// CreateVehicleNameRule creates a new vehicle name rule
+func CreateVehicleNameRule(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var rule models.VehicleNameRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, "Netinkami duomenys: "+err.Error(),
+				http.StatusBadRequest)
+			return
+		}
+
+		if !validateVehicleNameRule(w, &rule) {
+			return
+		}
+
+		if err := models.CreateVehicleNameRule(db, &rule); err != nil {
+			http.Error(w, "Nepavyko sukurti riedmens pavadinimo taisyklės: "+err.Error(),
+				http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(rule)
+	}
+}
+
+// UpdateVehicleNameRule updates an existing vehicle name rule
+func UpdateVehicleNameRule(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Netinkamas ID", http.StatusBadRequest)
+			return
+		}
+
+		var rule models.VehicleNameRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, "Netinkami duomenys: "+err.Error(),
+				http.StatusBadRequest)
+			return
+		}
+
+		if !validateVehicleNameRule(w, &rule) {
+			return
+		}
+
+		if err := models.UpdateVehicleNameRule(db, id, &rule); err != nil {
+			http.Error(w, "Nepavyko atnaujinti riedmens pavadinimo taisyklės: "+err.Error(),
+				http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rule)
+	}
+}
+
+// DeleteVehicleNameRule deletes a vehicle name rule by ID
+func DeleteVehicleNameRule(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Netinkamas ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := models.DeleteVehicleNameRule(db, id); err != nil {
+			http.Error(w, "Nepavyko ištrinti riedmens pavadinimo taisyklės: "+err.Error(),
+				http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// TestVehicleNameRules shows how a type/number pair resolves,
+// including which rule matched and whether its fallback was used
+func TestVehicleNameRules(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request VehicleNameTestRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Netinkami duomenys: "+err.Error(),
+				http.StatusBadRequest)
+			return
+		}
+
+		var rules []models.VehicleNameRule
+		if request.Rule != nil {
+			if !validateVehicleNameRule(w, request.Rule) {
+				return
+			}
+			request.Rule.IsActive = true
+			rules = []models.VehicleNameRule{*request.Rule}
+		} else {
+			var err error
+			rules, err = models.GetActiveVehicleNameRules(db)
+			if err != nil {
+				http.Error(w, "Nepavyko gauti riedmenų pavadinimų taisyklių: "+err.Error(),
+					http.StatusInternalServerError)
+				return
+			}
+		}
+
+		engine, err := vehiclename.New(rules)
+		if err != nil {
+			http.Error(w, "Netinkama taisyklė: "+err.Error(),
+				http.StatusUnprocessableEntity)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(engine.Resolve(request.TechnicalVehicleType, request.VehicleNo))
+	}
+}
+
+// validateVehicleNameRule fills defaults and writes a 400 response if the rule is invalid.
+// Returns false when the request has already been answered.
+func validateVehicleNameRule(w http.ResponseWriter, rule *models.VehicleNameRule) bool {
+	if rule.Name == "" {
+		http.Error(w, "Taisyklės pavadinimas yra privalomas",
+			http.StatusBadRequest)
+		return false
+	}
+	if rule.Strategy == "" {
+		rule.Strategy = vehiclename.StrategyFirst
+	}
+	if rule.OutputTemplate == "" {
+		rule.OutputTemplate = "{vehicle}"
+	}
+	if rule.FallbackTemplate == "" {
+		rule.FallbackTemplate = "{vehicle}"
+	}
+
+	if err := vehiclename.Validate(*rule); err != nil {
+		http.Error(w, "Netinkama taisyklė: "+err.Error(),
+			http.StatusBadRequest)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+// backend/internal/handlers/vehicle_rotation.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"yopta-template/internal/models"
+)
+
+// maxRotationDays limits the range of a rotation request
+const maxRotationDays = 31
+
+// GetVehicleRotations returns the turnaround chains of vehicles: where each
+// vehicle arrives, how long it stays and which train takes it out, with gaps,
+// overlaps and stranded vehicles marked.
+//
+// Query parameters: date or date_from and date_to (required), vehicle
+// (substring), user_id (admins only) and issues_only to return only vehicles
+// with problems.
+func GetVehicleRotations(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := getUserIDFromContext(r)
+		if err != nil {
+			http.Error(w, "Nepavyko gauti vartotojo ID", http.StatusUnauthorized)
+			return
+		}
+
+		isAdmin, err := isUserAdmin(db, userID)
+		if err != nil {
+			http.Error(w, "Nepavyko patikrinti vartotojo teisių", http.StatusInternalServerError)
+			return
+		}
+
+		filter, message := trainScheduleFilterFromQuery(r, userID, isAdmin)
+		if message != "" {
+			http.Error(w, message, http.StatusBadRequest)
+			return
+		}
+		if filter.From == nil || filter.To == nil || !filter.From.Before(*filter.To) {
+			http.Error(w, "Reikia nurodyti laikotarpį", http.StatusBadRequest)
+			return
+		}
+		if filter.To.Sub(*filter.From) > maxRotationDays*24*time.Hour {
+			http.Error(w, "Per ilgas laikotarpis", http.StatusBadRequest)
+			return
+		}
+
+		rotations, err := models.GetVehicleRotations(db, filter, *filter.From, *filter.To)
+		if err != nil {
+			http.Error(w, "Nepavyko sudaryti riedmenų apyvartos: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if queryFlag(r.URL.Query().Get("issues_only")) {
+			withIssues := []models.VehicleRotation{}
+			for _, rotation := range rotations {
+				if rotation.Issues > 0 {
+					withIssues = append(withIssues, rotation)
+				}
+			}
+			rotations = withIssues
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rotations)
+	}
+}
@@ -0,0 +1,132 @@
+// backend/internal/ical/ical.go
+package ical
+
+// This package writes iCalendar (RFC 5545) files with plain timed events,
+// which is all the duty feeds need. Times are written in UTC, so calendars
+// show them in the subscriber's own timezone without VTIMEZONE definitions.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Calendar is a named collection of events.
+type Calendar struct {
+	Name    string        // Calendar name shown by calendar apps
+	Refresh time.Duration // How often subscribers should reload the feed (0 to leave it to them)
+	Events  []Event
+}
+
+// Event is a timed calendar event.
+type Event struct {
+	UID          string    // Stable identifier, so updated events replace the old copy
+	Start        time.Time // Event start
+	End          time.Time // Event end
+	Summary      string    // Title
+	Description  string    // Details, may span several lines
+	Location     string    // Place of the event
+	LastModified time.Time // When the event last changed (zero if unknown)
+}
+
+// utcFormat is the RFC 5545 form of a UTC date-time
+const utcFormat = "20060102T150405Z"
+
+// Write writes the calendar in iCalendar format.
+func (c Calendar) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	now := time.Now().UTC().Format(utcFormat)
+
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//yopta//duty feed//LT")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escapeText(c.Name))
+	}
+	if c.Refresh > 0 {
+		duration := formatDuration(c.Refresh)
+		writeFolded(bw, "REFRESH-INTERVAL;VALUE=DURATION:"+duration)
+		line("X-PUBLISHED-TTL", duration)
+	}
+
+	for _, e := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", escapeText(e.UID))
+		line("DTSTAMP", now)
+		line("DTSTART", e.Start.UTC().Format(utcFormat))
+		line("DTEND", e.End.UTC().Format(utcFormat))
+		line("SUMMARY", escapeText(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escapeText(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION", escapeText(e.Location))
+		}
+		if !e.LastModified.IsZero() {
+			line("LAST-MODIFIED", e.LastModified.UTC().Format(utcFormat))
+		}
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// formatDuration writes a duration as an RFC 5545 DURATION in whole minutes.
+func formatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	duration := "PT"
+	if minutes >= 60 {
+		duration += fmt.Sprintf("%dH", minutes/60)
+	}
+	if minutes%60 > 0 || minutes < 60 {
+		duration += fmt.Sprintf("%dM", minutes%60)
+	}
+	return duration
+}
+
+// textEscaper escapes the characters RFC 5545 reserves in TEXT values
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+// escapeText escapes a TEXT property value.
+func escapeText(value string) string {
+	return textEscaper.Replace(value)
+}
+
+// writeFolded writes a content line, folding it into lines of at most 75
+// octets without splitting UTF-8 characters, each ended by CRLF.
+func writeFolded(w *bufio.Writer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts towards the limit
+		limit = 74
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+// isRuneStart reports whether b starts a UTF-8 encoded character.
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,417 @@
+// backend/internal/importer/antras.go
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"yopta-template/internal/models"
+	"yopta-template/internal/timeparse"
+	"yopta-template/internal/vehiclename"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// AntrasResult is the outcome of parsing an Antras workbook.
+// Import holds the summary counters and records ready to be persisted.
+type AntrasResult struct {
+	Import models.AntrasImport `json:"import"` // Parsed import with records and staff
+	Sheets []string            `json:"sheets"` // Normalized codes of imported sheets
+	Errors []RowError          `json:"errors"` // Sheet- and row-level problems
+}
+
+// depotSheetPattern matches depot sheet names such as LTE+D and LTE-D
+var depotSheetPattern = regexp.MustCompile(`[+-]D$`)
+
+// ParseAntrasWorkbook reads an Antras .xlsx export and converts every sheet row
+// into an AntrasRecord. The rules follow the Antras page:
+//   - depot sheets (LTE+D, LTE-D) are merged into one station code (LTE.D)
+//     and rows repeated across them are skipped
+//   - sheets without the required headers are skipped and reported
+//   - times such as "23:59 (+1)" are resolved against the row's validity date
+//     in the Europe/Vilnius timezone
+//
+// Parameters:
+//   - r: Workbook contents
+//   - fileName: Original file name, kept for the audit trail
+//   - mappings: Antras field mappings (external name, internal name, type, required flag)
+//   - names: Vehicle name rules used to build display names
+//
+// Returns:
+//   - Parsed import with per-sheet and per-row errors
+//   - Error if the workbook cannot be opened or contains no usable sheet
+func ParseAntrasWorkbook(
+	r io.Reader,
+	fileName string,
+	mappings []models.AntrasFieldMapping,
+	names *vehiclename.Engine,
+) (*AntrasResult, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workbook: %w", err)
+	}
+	defer f.Close()
+
+	byExternal := make(map[string]models.AntrasFieldMapping, len(mappings))
+	for _, m := range mappings {
+		byExternal[m.ExternalName] = m
+	}
+
+	result := &AntrasResult{
+		Import: models.AntrasImport{
+			FileName: fileName,
+			Records:  []models.AntrasRecord{},
+		},
+		Sheets: []string{},
+		Errors: []RowError{},
+	}
+	processedKeys := make(map[string]bool) // Deduplication across depot sheets
+	sheets := make(map[string]bool)
+
+	for _, sheetName := range f.GetSheetList() {
+		// Raw values keep dates as Excel serial numbers instead of locale-formatted text
+		rows, err := f.GetRows(sheetName, excelize.Options{RawCellValue: true})
+		if err != nil {
+			result.Errors = append(result.Errors, RowError{Sheet: sheetName, Message: err.Error()})
+			result.Import.SkippedSheets++
+			continue
+		}
+
+		// Sheets need at least a header row and one data row
+		if len(rows) < 2 {
+			result.Import.SkippedSheets++
+			continue
+		}
+
+		headers := make([]string, len(rows[0]))
+		present := make(map[string]bool, len(rows[0]))
+		for i, h := range rows[0] {
+			headers[i] = strings.TrimSpace(h)
+			present[headers[i]] = true
+		}
+
+		var missing []string
+		for _, m := range mappings {
+			if m.IsRequired && !present[m.ExternalName] {
+				missing = append(missing, m.ExternalName)
+			}
+		}
+		if len(missing) > 0 {
+			result.Errors = append(result.Errors, RowError{
+				Sheet:   sheetName,
+				Row:     1,
+				Message: "missing required headers: " + strings.Join(missing, ", "),
+			})
+			result.Import.SkippedSheets++
+			continue
+		}
+
+		stationCode := NormalizeDepotCode(sheetName)
+		isDepot := IsDepotSheet(sheetName)
+
+		for i := 1; i < len(rows); i++ {
+			rowNumber := i + 1
+			if isEmptyRow(rows[i]) {
+				continue
+			}
+
+			// Map cells to internal names, validating by field type
+			mapped := make(map[string]string)
+			var rowErrors []RowError
+			for j, header := range headers {
+				m, ok := byExternal[header]
+				if !ok {
+					continue
+				}
+				value := ""
+				if j < len(rows[i]) {
+					value = strings.TrimSpace(rows[i][j])
+				}
+				if value == "" {
+					if m.IsRequired {
+						rowErrors = append(rowErrors, RowError{
+							Sheet: sheetName, Row: rowNumber, Field: header, Message: "required field is empty",
+						})
+					}
+					continue
+				}
+				if err := validateAntrasValue(m, value); err != nil {
+					rowErrors = append(rowErrors, RowError{
+						Sheet: sheetName, Row: rowNumber, Field: header, Message: err.Error(),
+					})
+					continue
+				}
+				mapped[m.InternalName] = value
+			}
+			if len(rowErrors) > 0 {
+				result.Errors = append(result.Errors, rowErrors...)
+				continue
+			}
+
+			if isDepot {
+				key := antrasDedupeKey(mapped)
+				if processedKeys[key] {
+					result.Import.DuplicatesSkipped++
+					continue
+				}
+				processedKeys[key] = true
+			}
+
+			record, err := buildAntrasRecord(mapped, names, stationCode, sheetName, rowNumber)
+			if err != nil {
+				result.Errors = append(result.Errors, RowError{
+					Sheet: sheetName, Row: rowNumber, Message: err.Error(),
+				})
+				continue
+			}
+
+			result.Import.Records = append(result.Import.Records, record)
+			sheets[stationCode] = true
+		}
+	}
+
+	result.Sheets = sortedKeys(sheets)
+	result.Import.SheetsCount = len(result.Sheets)
+	result.Import.RecordsCount = len(result.Import.Records)
+
+	if result.Import.RecordsCount == 0 && len(result.Errors) == 0 {
+		return nil, fmt.Errorf("workbook contains no data")
+	}
+
+	return result, nil
+}
+
+// NormalizeDepotCode merges depot sheet names: LTE+D or LTE-D becomes LTE.D.
+func NormalizeDepotCode(sheetName string) string {
+	return depotSheetPattern.ReplaceAllString(sheetName, ".D")
+}
+
+// IsDepotSheet reports whether a sheet holds depot data (+D or -D suffix).
+func IsDepotSheet(sheetName string) bool {
+	return depotSheetPattern.MatchString(sheetName)
+}
+
+// buildAntrasRecord converts mapped row values into a record with parsed
+// dates, vehicle names and crew members.
+func buildAntrasRecord(
+	mapped map[string]string,
+	names *vehiclename.Engine,
+	stationCode, sheetName string,
+	rowNumber int,
+) (models.AntrasRecord, error) {
+	record := models.AntrasRecord{
+		StationCode:       stationCode,
+		OriginalSheet:     sheetName,
+		SheetRow:          rowNumber,
+		NetworkPointName:  firstNonEmpty(mapped["networkPointName"], stationCode),
+		TrainNoIn:         mapped["trainNoIn"],
+		TrainNoOut:        mapped["trainNoOut"],
+		VehicleIn:         names.Name(mapped["technicalVehicleTypeIn"], mapped["vehicleNoIn"]),
+		VehicleOut:        names.Name(mapped["technicalVehicleTypeOut"], mapped["vehicleNoOut"]),
+		VehicleWorkingIn:  mapped["vehicleWorkingIn"],
+		VehicleWorkingOut: mapped["vehicleWorkingOut"],
+	}
+
+	var err error
+	if record.ValidityIn, err = parseValidityDate(mapped["validityIn"]); err != nil {
+		return record, fmt.Errorf("Validity.in: %w", err)
+	}
+	if record.ValidityOut, err = parseValidityDate(mapped["validityOut"]); err != nil {
+		return record, fmt.Errorf("Validity.out: %w", err)
+	}
+
+	if record.ArrivalDateTime, err = timeparse.ParseDateTime(record.ValidityIn, mapped["arrival"]); err != nil {
+		return record, fmt.Errorf("Arrival: %w", err)
+	}
+	if record.DepartureDateTime, err = timeparse.ParseDateTime(record.ValidityOut, mapped["departure"]); err != nil {
+		return record, fmt.Errorf("Departure: %w", err)
+	}
+
+	if record.TrainNoIn == "" && record.TrainNoOut == "" && record.VehicleIn == "" && record.VehicleOut == "" {
+		return record, fmt.Errorf("row has neither a train nor a vehicle")
+	}
+
+	staffIn, err := parseAntrasStaff(mapped, "In", "in", record.ValidityIn)
+	if err != nil {
+		return record, err
+	}
+	staffOut, err := parseAntrasStaff(mapped, "Out", "out", record.ValidityOut)
+	if err != nil {
+		return record, err
+	}
+	record.Staff = append(staffIn, staffOut...)
+
+	rawData, err := json.Marshal(mapped)
+	if err != nil {
+		return record, err
+	}
+	record.RawData = string(rawData)
+
+	return record, nil
+}
+
+// parseAntrasStaff extracts crew members from the comma-separated driver,
+// phone, personnel number, duty and duty time columns of one direction.
+// The first letter of the duty code tells the occupation: M = driver,
+// K = conductor, R = reserve followed by the actual occupation letter.
+func parseAntrasStaff(mapped map[string]string, suffix, direction, validityDate string) ([]models.AntrasStaff, error) {
+	drivers := splitList(mapped["driver"+suffix])
+	phones := splitList(mapped["phone"+suffix])
+	personnelNumbers := splitList(mapped["driverPersonnelNumber"+suffix])
+	duties := splitList(mapped["duty"+suffix])
+	startingTimes := splitList(mapped["dutyStartingTime"+suffix])
+	endTimes := splitList(mapped["dutyEndTime"+suffix])
+
+	var staff []models.AntrasStaff
+	for i, name := range drivers {
+		if name == "" {
+			continue
+		}
+
+		duty := listItem(duties, i)
+		occ := ""
+		if duty != "" {
+			occ = strings.ToUpper(duty[:1])
+			if occ == "R" && len(duty) > 1 {
+				occ = strings.ToUpper(duty[1:2])
+			}
+			if occ != "M" && occ != "K" {
+				occ = ""
+			}
+		}
+
+		dutyStart, err := timeparse.ParseDateTime(validityDate, listItem(startingTimes, i))
+		if err != nil {
+			return nil, fmt.Errorf("Duty.StartingTime.%s: %w", direction, err)
+		}
+		dutyEnd, err := timeparse.ParseDateTime(validityDate, listItem(endTimes, i))
+		if err != nil {
+			return nil, fmt.Errorf("Duty.EndTime.%s: %w", direction, err)
+		}
+
+		staff = append(staff, models.AntrasStaff{
+			Direction:       direction,
+			PersonnelNumber: listItem(personnelNumbers, i),
+			Name:            name,
+			Phone:           listItem(phones, i),
+			Occupation:      occ,
+			Duty:            duty,
+			DutyStart:       dutyStart,
+			DutyEnd:         dutyEnd,
+		})
+	}
+
+	return staff, nil
+}
+
+// parseValidityDate converts a Validity cell into YYYY-MM-DD.
+// Cells hold either a date string or an Excel serial number.
+func parseValidityDate(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	date, err := timeparse.ParseDate(value)
+	if err != nil {
+		return "", err
+	}
+	return date.Format("2006-01-02"), nil
+}
+
+// validateAntrasValue checks a cell against the mapping's field type.
+// Time fields may hold a comma-separated list (one value per crew member).
+func validateAntrasValue(m models.AntrasFieldMapping, value string) error {
+	switch m.FieldType {
+	case "date":
+		_, err := timeparse.ParseDate(value)
+		return err
+	case "time":
+		for _, item := range splitList(value) {
+			if item == "" {
+				continue
+			}
+			if _, err := timeparse.ParseTimeCell(item); err != nil {
+				return err
+			}
+		}
+	case "datetime":
+		_, err := timeparse.ParseTimestamp(value)
+		return err
+	case "number":
+		if _, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64); err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(strings.ToLower(value)); err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+	}
+	return nil
+}
+
+// antrasDedupeKey identifies a row repeated across the +D and -D sheets of a depot.
+// Parts are normalized the same way as record IDs (see models.TrainScheduleKey),
+// so a row exported once as "23.05.2025 8:05" and once as "2025-05-23 08:05"
+// is still recognised as the same row.
+func antrasDedupeKey(mapped map[string]string) string {
+	return strings.Join([]string{
+		dedupeDate(mapped["validityIn"]),
+		dedupeDate(mapped["validityOut"]),
+		models.NormalizeKeyPart(mapped["trainNoIn"]),
+		models.NormalizeKeyPart(mapped["trainNoOut"]),
+		dedupeClock(mapped["arrival"]),
+		dedupeClock(mapped["departure"]),
+		models.NormalizeKeyPart(mapped["vehicleNoIn"]),
+		models.NormalizeKeyPart(mapped["vehicleNoOut"]),
+	}, "|")
+}
+
+// dedupeDate formats a date cell as YYYYMMDD, or normalizes it if it is not a date.
+func dedupeDate(value string) string {
+	if day, err := timeparse.ParseDate(value); err == nil {
+		return day.Format("20060102")
+	}
+	return models.NormalizeKeyPart(value)
+}
+
+// dedupeClock formats a time cell as HHMMSS with its day offset,
+// or normalizes it if it is not a time.
+func dedupeClock(value string) string {
+	if clock, err := timeparse.ParseTimeCell(value); err == nil {
+		return fmt.Sprintf("%02d%02d%02d%+d", clock.Hour, clock.Minute, clock.Second, clock.DayOffset)
+	}
+	return models.NormalizeKeyPart(value)
+}
+
+// splitList splits a comma-separated cell into trimmed items.
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	items := strings.Split(value, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
+
+// listItem returns the i-th item of a list or an empty string.
+func listItem(items []string, i int) string {
+	if i < len(items) {
+		return items[i]
+	}
+	return ""
+}
+
+// isEmptyRow reports whether all cells of a row are blank.
+func isEmptyRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
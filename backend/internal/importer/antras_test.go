@@ -0,0 +1,26 @@
+// backend/internal/importer/antras_test.go
+package importer
+
+import (
+	"testing"
+
+	"yopta-template/internal/models"
+)
+
+func TestValidateAntrasTimeList(t *testing.T) {
+	m := models.AntrasFieldMapping{FieldType: "time"}
+
+	for _, value := range []string{"08:30", "08:30, 45800.25", "0.5,"} {
+		if err := validateAntrasValue(m, value); err != nil {
+			t.Errorf("validateAntrasValue(%q) error = %v", value, err)
+		}
+	}
+	for _, value := range []string{"830", "08:30, 25:00"} {
+		if err := validateAntrasValue(m, value); err == nil {
+			t.Errorf("validateAntrasValue(%q) succeeded, want an error", value)
+		}
+	}
+	if dedupeClock("45800.25") != dedupeClock("06:00") {
+		t.Errorf("dedupeClock() tells 45800.25 and 06:00 apart")
+	}
+}
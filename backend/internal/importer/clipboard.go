@@ -0,0 +1,269 @@
+// backend/internal/importer/clipboard.go
+package importer
+
+// This package turns external schedule exports (clipboard TSV, Excel workbooks)
+// into train schedule records. Parsing lives here so every import path,
+// whether it is the Klasika page or another tool, goes through the same rules.
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"yopta-template/internal/models"
+	"yopta-template/internal/timeparse"
+)
+
+// RowError describes a problem with a single imported row.
+// Rows with errors are left out of the result instead of being dropped silently.
+type RowError struct {
+	Sheet   string `json:"sheet,omitempty"` // Worksheet name, for workbook imports
+	Row     int    `json:"row"`             // Line or row number in the source (header is row 1)
+	Field   string `json:"field,omitempty"` // External column name, if the error concerns one column
+	Message string `json:"message"`         // Human-readable explanation
+}
+
+// ClipboardResult is the outcome of parsing a pasted Klasika export.
+type ClipboardResult struct {
+	Records   []models.TrainSchedule `json:"records"`   // Successfully parsed records
+	Depots    []string               `json:"depots"`    // Sorted list of depots found in the records
+	Dates     []string               `json:"dates"`     // Sorted list of dates (YYYY-MM-DD) found in the records
+	Errors    []RowError             `json:"errors"`    // Per-row problems
+	TotalRows int                    `json:"totalRows"` // Number of non-empty data rows in the input
+}
+
+// ParseClipboard parses tab-separated text copied from the planning system.
+// The first line must contain the column headers. Columns are mapped to internal
+// names through the field mappings; unknown columns are ignored.
+//
+// Parameters:
+//   - text: Raw pasted text
+//   - mappings: Field mappings (external name, internal name, type, required flag)
+//
+// Returns:
+//   - Parsed records together with depots, dates and per-row errors
+//   - Error if the text has no data rows or required headers are missing
+func ParseClipboard(text string, mappings []models.FieldMapping) (*ClipboardResult, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("no data rows found")
+	}
+
+	// Index mappings by external name
+	byExternal := make(map[string]models.FieldMapping, len(mappings))
+	for _, m := range mappings {
+		byExternal[m.ExternalName] = m
+	}
+
+	// Extract headers from first line
+	headers := strings.Split(strings.TrimRight(lines[0], "\r"), "\t")
+	present := make(map[string]bool, len(headers))
+	for i := range headers {
+		headers[i] = strings.TrimSpace(headers[i])
+		present[headers[i]] = true
+	}
+
+	// Every required mapping must have a column
+	var missing []string
+	for _, m := range mappings {
+		if m.IsRequired && !present[m.ExternalName] {
+			missing = append(missing, m.ExternalName)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required headers: %s", strings.Join(missing, ", "))
+	}
+
+	result := &ClipboardResult{
+		Records: []models.TrainSchedule{},
+		Errors:  []RowError{},
+	}
+	depots := make(map[string]bool)
+	dates := make(map[string]bool)
+
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		result.TotalRows++
+		lineNo := i + 1
+
+		values := strings.Split(lines[i], "\t")
+		if len(values) != len(headers) {
+			result.Errors = append(result.Errors, RowError{
+				Row: lineNo,
+				Message: fmt.Sprintf(
+					"expected %d columns, got %d", len(headers), len(values),
+				),
+			})
+			continue
+		}
+
+		// Populate fields from headers and values, validating as we go
+		record := make(map[string]string)
+		var rowErrors []RowError
+		for j, header := range headers {
+			m, ok := byExternal[header]
+			if !ok {
+				continue
+			}
+			value := strings.TrimSpace(values[j])
+			if err := validateClipboardValue(m, value); err != nil {
+				rowErrors = append(rowErrors, RowError{Row: lineNo, Field: header, Message: err.Error()})
+				continue
+			}
+			record[m.InternalName] = value
+		}
+		if len(rowErrors) > 0 {
+			result.Errors = append(result.Errors, rowErrors...)
+			continue
+		}
+
+		schedule, err := clipboardRecordToSchedule(record)
+		if err != nil {
+			result.Errors = append(result.Errors, RowError{Row: lineNo, Message: err.Error()})
+			continue
+		}
+
+		if schedule.DepartureDateTime != nil {
+			dates[schedule.DepartureDateTime.Format("2006-01-02")] = true
+		}
+		if schedule.ArrivalDateTime != nil {
+			dates[schedule.ArrivalDateTime.Format("2006-01-02")] = true
+		}
+		if schedule.StartingLocation != "" {
+			depots[schedule.StartingLocation] = true
+		}
+		if schedule.EndLocation != "" {
+			depots[schedule.EndLocation] = true
+		}
+
+		result.Records = append(result.Records, schedule)
+	}
+
+	if result.TotalRows == 0 {
+		return nil, fmt.Errorf("no data rows found")
+	}
+
+	result.Depots = sortedKeys(depots)
+	result.Dates = sortedKeys(dates)
+	return result, nil
+}
+
+// validateClipboardValue checks a single cell against its mapping.
+// Empty optional cells are always accepted.
+func validateClipboardValue(m models.FieldMapping, value string) error {
+	if value == "" {
+		if m.IsRequired {
+			return fmt.Errorf("required field is empty")
+		}
+		return nil
+	}
+
+	switch m.FieldType {
+	case "date":
+		if _, err := timeparse.ParseDate(value); err != nil {
+			return err
+		}
+	case "time":
+		if _, err := timeparse.ParseTimeCell(value); err != nil {
+			return err
+		}
+	case "datetime":
+		if _, err := timeparse.ParseTimestamp(value); err != nil {
+			return err
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64); err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(strings.ToLower(value)); err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+	}
+	return nil
+}
+
+// clipboardRecordToSchedule converts a mapped row into a TrainSchedule.
+// At least one of the departure or arrival date/time pairs must be present.
+func clipboardRecordToSchedule(record map[string]string) (models.TrainSchedule, error) {
+	var schedule models.TrainSchedule
+
+	departure, err := timeparse.ParseDateTime(
+		firstNonEmpty(record["departureDate"], record["date"]),
+		record["departurePlanned"],
+	)
+	if err != nil {
+		return schedule, fmt.Errorf("departure: %w", err)
+	}
+	arrival, err := timeparse.ParseDateTime(
+		firstNonEmpty(record["arrivalDate"], record["date"]),
+		record["arrivalPlanned"],
+	)
+	if err != nil {
+		return schedule, fmt.Errorf("arrival: %w", err)
+	}
+	if departure == nil && arrival == nil {
+		return schedule, fmt.Errorf("row has neither a planned departure nor a planned arrival")
+	}
+
+	rawData, err := json.Marshal(record)
+	if err != nil {
+		return schedule, err
+	}
+
+	id, err := models.TrainScheduleKeyFromRecord(record)
+	if err != nil {
+		return schedule, fmt.Errorf("cannot build record ID: %w", err)
+	}
+
+	schedule = models.TrainSchedule{
+		ID: id,
+		TrainNumberDeparture: firstNonEmpty(
+			record["departureTrainNumber"],
+			record["departureNetworkTrainNumber"],
+		),
+		TrainNumberArrival: firstNonEmpty(
+			record["arrivalTrainNumber"],
+			record["arrivalNetworkTrainNumber"],
+		),
+		VehicleName:        firstNonEmpty(record["vehicle"], record["vehicleName"]),
+		StartingLocation:   firstNonEmpty(record["startingLocation"], record["departureDepot"]),
+		EndLocation:        firstNonEmpty(record["endLocation"], record["arrivalDepot"]),
+		DepartureDateTime:  departure,
+		ArrivalDateTime:    arrival,
+		StartingTrack:      record["startingTrack"],
+		TargetTrack:        record["targetTrack"],
+		Employee1Departure: record["departureEmployee1"],
+		Employee1Arrival:   record["arrivalEmployee1"],
+		DutyDeparture:      record["departureDuty"],
+		DutyArrival:        record["arrivalDuty"],
+		RawData:            string(rawData),
+	}
+
+	return schedule, nil
+}
+
+// firstNonEmpty returns the first non-empty string from the arguments.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// sortedKeys returns the keys of a set in ascending order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
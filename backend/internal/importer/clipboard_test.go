@@ -0,0 +1,47 @@
+// backend/internal/importer/clipboard_test.go
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"yopta-template/internal/models"
+	"yopta-template/internal/timeparse"
+)
+
+func TestParseClipboardTimeCells(t *testing.T) {
+	mappings := []models.FieldMapping{
+		{ExternalName: "Darbinis", InternalName: "vehicleWorkingDesignation", FieldType: "text", IsRequired: true},
+		{ExternalName: "Data", InternalName: "departureDate", FieldType: "date", IsRequired: true},
+		{ExternalName: "Reisas", InternalName: "departureTripNumber", FieldType: "text", IsRequired: true},
+		{ExternalName: "Išvykimas", InternalName: "departurePlanned", FieldType: "time"},
+	}
+	text := "Darbinis\tData\tReisas\tIšvykimas\n" +
+		"A1\t2025-06-01\t101\t08:30\n" +
+		"A2\t2025-06-01\t102\t45800.25\n" + // Excel date and time; its time of day counts
+		"A3\t2025-06-01\t103\t0.75\n" +
+		"A4\t2025-06-01\t104\t830\n"
+
+	result, err := ParseClipboard(text, mappings)
+	if err != nil {
+		t.Fatalf("ParseClipboard() error = %v", err)
+	}
+
+	want := []time.Time{
+		time.Date(2025, 6, 1, 8, 30, 0, 0, timeparse.Vilnius),
+		time.Date(2025, 6, 1, 6, 0, 0, 0, timeparse.Vilnius),
+		time.Date(2025, 6, 1, 18, 0, 0, 0, timeparse.Vilnius),
+	}
+	if len(result.Records) != len(want) {
+		t.Fatalf("ParseClipboard() returned %d records, want %d (errors: %+v)", len(result.Records), len(want), result.Errors)
+	}
+	for i, record := range result.Records {
+		if record.DepartureDateTime == nil || !record.DepartureDateTime.Equal(want[i]) {
+			t.Errorf("record %d departs at %v, want %s", i, record.DepartureDateTime, want[i])
+		}
+	}
+
+	if len(result.Errors) != 1 || result.Errors[0].Row != 5 || result.Errors[0].Field != "Išvykimas" {
+		t.Errorf("ParseClipboard() errors = %+v, want one for the time cell on row 5", result.Errors)
+	}
+}
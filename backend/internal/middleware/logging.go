@@ -14,6 +14,8 @@ import (
 	"time"
 
 	"yopta-template/internal/models"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 )
 
 // BufferedResponseWriter - структура-обертка для http.ResponseWriter
@@ -41,6 +43,24 @@ func shouldSkipLogging(path string) bool {
 	return false
 }
 
+// RequestLogger логирует запросы стандартным логгером chi, кроме путей с
+// указанными префиксами: их URL содержит секретный токен, который не должен
+// попасть в журнал (например, ленты календаря /api/v1/calendar/)
+func RequestLogger(secretPrefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		logged := chimiddleware.Logger(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, prefix := range secretPrefixes {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			logged.ServeHTTP(w, r)
+		})
+	}
+}
+
 // NewBufferedResponseWriter создает новый BufferedResponseWriter
 func NewBufferedResponseWriter(w http.ResponseWriter) *BufferedResponseWriter {
 	return &BufferedResponseWriter{
@@ -62,6 +82,14 @@ func (bw *BufferedResponseWriter) WriteHeader(statusCode int) {
 	bw.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Flush передает накопленные данные клиенту, чтобы потоковые ответы
+// (например, прогресс импорта) не задерживались до конца запроса
+func (bw *BufferedResponseWriter) Flush() {
+	if flusher, ok := bw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 // LoggingMiddleware создает middleware для логирования запросов и ответов HTTP
 func LoggingMiddleware(loggerType string) func(http.Handler) http.Handler {
 	var logFile *os.File
@@ -0,0 +1,171 @@
+// backend/internal/models/antras_crew.go
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"yopta-template/internal/timeparse"
+)
+
+// AntrasCrewAssignment is a crew member listed on an Antras row,
+// together with the station and train of the row.
+type AntrasCrewAssignment struct {
+	AntrasStaff
+	ImportID    int64  `json:"import_id"`
+	StationCode string `json:"station_code"`
+	TrainNo     string `json:"train_no"` // Incoming or outgoing train, by direction
+
+	recordTime sql.NullTime // Departure, or arrival, of the row; decides its day
+}
+
+// AntrasCrewFilter selects crew assignments of stored Antras imports.
+type AntrasCrewFilter struct {
+	ImportID        int64      // Only this import (0 for the latest import of every station and day)
+	Stations        []string   // Only rows of these stations (nil for all, see Editor.AntrasStations)
+	PersonnelNumber string     // Only this employee (empty for all employees)
+	From            *time.Time // Duties ending at or after this time
+	To              *time.Time // Duties starting before this time
+}
+
+// GetAntrasCrewAssignments retrieves the crew members of stored Antras rows
+// that have a personnel number, ordered by personnel number and duty start.
+// The same duty is usually listed on several rows. Without an import ID only
+// the latest import of each station and day counts, so a corrected workbook
+// replaces the one uploaded before it.
+//
+// Parameters:
+//   - db: Database connection
+//   - filter: Import and duty time range
+//
+// Returns:
+//   - Crew assignments
+//   - Error if the database operation fails
+func GetAntrasCrewAssignments(db *sql.DB, filter AntrasCrewFilter) ([]AntrasCrewAssignment, error) {
+	query := `
+		SELECT s.id, s.record_id, s.direction, s.personnel_number, s.name, s.phone,
+		       s.occupation, s.duty, s.duty_start, s.duty_end,
+		       r.import_id, r.station_code, IF(s.direction = 'in', r.train_no_in, r.train_no_out),
+		       COALESCE(r.departure_date_time, r.arrival_date_time)
+		FROM antras_staff s
+		JOIN antras_records r ON r.id = s.record_id
+		WHERE s.personnel_number <> ''`
+	var params []any
+
+	if filter.ImportID > 0 {
+		query += " AND r.import_id = ?"
+		params = append(params, filter.ImportID)
+	}
+	condition, args := antrasStationCondition(filter.Stations)
+	query += condition
+	params = append(params, args...)
+	if filter.PersonnelNumber != "" {
+		query += " AND s.personnel_number = ?"
+		params = append(params, filter.PersonnelNumber)
+	}
+	if filter.From != nil {
+		query += " AND COALESCE(s.duty_end, s.duty_start) >= ?"
+		params = append(params, *filter.From)
+	}
+	if filter.To != nil {
+		query += " AND COALESCE(s.duty_start, s.duty_end) < ?"
+		params = append(params, *filter.To)
+	}
+	query += " ORDER BY s.personnel_number, s.duty_start, s.id"
+
+	rows, err := db.Query(query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query antras crew: %w", err)
+	}
+	defer rows.Close()
+
+	var assignments []AntrasCrewAssignment
+	for rows.Next() {
+		var a AntrasCrewAssignment
+		var dutyStart, dutyEnd sql.NullTime
+		if err := rows.Scan(
+			&a.ID, &a.RecordID, &a.Direction, &a.PersonnelNumber, &a.Name, &a.Phone,
+			&a.Occupation, &a.Duty, &dutyStart, &dutyEnd,
+			&a.ImportID, &a.StationCode, &a.TrainNo, &a.recordTime,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan antras crew: %w", err)
+		}
+		if dutyStart.Valid {
+			a.DutyStart = &dutyStart.Time
+		}
+		if dutyEnd.Valid {
+			a.DutyEnd = &dutyEnd.Time
+		}
+		assignments = append(assignments, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating antras crew: %w", err)
+	}
+
+	if filter.ImportID > 0 || len(assignments) == 0 {
+		return assignments, nil
+	}
+	latest, err := latestAntrasImports(db, filter)
+	if err != nil {
+		return nil, err
+	}
+	current := assignments[:0]
+	for _, a := range assignments {
+		if latest[antrasDayKey(a.StationCode, a.recordTime)] == a.ImportID {
+			current = append(current, a)
+		}
+	}
+	return current, nil
+}
+
+// antrasDayKey identifies the Vilnius day of an Antras row at a station.
+func antrasDayKey(station string, at sql.NullTime) string {
+	day := ""
+	if at.Valid {
+		day = at.Time.In(timeparse.Vilnius).Format("2006-01-02")
+	}
+	return station + "\x00" + day
+}
+
+// latestAntrasImports returns the newest import holding rows of each station
+// and day within the filter's time range (widened by a day, as rows are
+// dated by train times and duties by crew times), keyed by antrasDayKey.
+func latestAntrasImports(db *sql.DB, filter AntrasCrewFilter) (map[string]int64, error) {
+	query := `
+		SELECT r.import_id, r.station_code, COALESCE(r.departure_date_time, r.arrival_date_time)
+		FROM antras_records r
+		WHERE TRUE`
+	condition, params := antrasStationCondition(filter.Stations)
+	query += condition
+	if filter.From != nil {
+		query += " AND (COALESCE(r.departure_date_time, r.arrival_date_time) IS NULL" +
+			" OR COALESCE(r.departure_date_time, r.arrival_date_time) >= ?)"
+		params = append(params, filter.From.AddDate(0, 0, -1))
+	}
+	if filter.To != nil {
+		query += " AND (COALESCE(r.departure_date_time, r.arrival_date_time) IS NULL" +
+			" OR COALESCE(r.departure_date_time, r.arrival_date_time) < ?)"
+		params = append(params, filter.To.AddDate(0, 0, 1))
+	}
+
+	rows, err := db.Query(query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query antras imports by day: %w", err)
+	}
+	defer rows.Close()
+
+	latest := make(map[string]int64)
+	for rows.Next() {
+		var importID int64
+		var station string
+		var at sql.NullTime
+		if err := rows.Scan(&importID, &station, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan antras import day: %w", err)
+		}
+		if key := antrasDayKey(station, at); importID > latest[key] {
+			latest[key] = importID
+		}
+	}
+	return latest, rows.Err()
+}
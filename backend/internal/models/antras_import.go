@@ -0,0 +1,397 @@
+// backend/internal/models/antras_import.go
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AntrasImport represents one uploaded Antras workbook.
+// It keeps the audit information (who, when, which file) together with
+// summary counters, while the parsed rows live in AntrasRecord.
+type AntrasImport struct {
+	ID                int64           `json:"id"`
+	FileName          string          `json:"file_name"`
+	SheetsCount       int             `json:"sheets_count"`
+	RecordsCount      int             `json:"records_count"`
+	SkippedSheets     int             `json:"skipped_sheets"`
+	DuplicatesSkipped int             `json:"duplicates_skipped"`
+	Errors            json.RawMessage `json:"errors"`
+	UserID            *int            `json:"user_id"`
+	CreatedAt         time.Time       `json:"created_at"`
+	Records           []AntrasRecord  `json:"records,omitempty"`
+}
+
+// AntrasRecord represents a single row of an Antras sheet.
+// A row pairs the incoming train with the outgoing train at one network point.
+type AntrasRecord struct {
+	ID                int64         `json:"id"`
+	ImportID          int64         `json:"import_id"`
+	StationCode       string        `json:"station_code"`
+	OriginalSheet     string        `json:"original_sheet"`
+	SheetRow          int           `json:"sheet_row"`
+	NetworkPointName  string        `json:"network_point_name"`
+	TrainNoIn         string        `json:"train_no_in"`
+	TrainNoOut        string        `json:"train_no_out"`
+	VehicleIn         string        `json:"vehicle_in"`
+	VehicleOut        string        `json:"vehicle_out"`
+	VehicleWorkingIn  string        `json:"vehicle_working_in"`
+	VehicleWorkingOut string        `json:"vehicle_working_out"`
+	ValidityIn        string        `json:"validity_in"` // YYYY-MM-DD or empty
+	ValidityOut       string        `json:"validity_out"`
+	ArrivalDateTime   *time.Time    `json:"arrival_date_time"`
+	DepartureDateTime *time.Time    `json:"departure_date_time"`
+	RawData           string        `json:"raw_data"`
+	Staff             []AntrasStaff `json:"staff"`
+}
+
+// AntrasStaff represents a crew member listed on an Antras row.
+type AntrasStaff struct {
+	ID              int64      `json:"id"`
+	RecordID        int64      `json:"record_id"`
+	Direction       string     `json:"direction"` // "in" or "out"
+	PersonnelNumber string     `json:"personnel_number"`
+	Name            string     `json:"name"`
+	Phone           string     `json:"phone"`
+	Occupation      string     `json:"occupation"` // "M" (driver), "K" (conductor) or empty
+	Duty            string     `json:"duty"`
+	DutyStart       *time.Time `json:"duty_start"`
+	DutyEnd         *time.Time `json:"duty_end"`
+}
+
+// SaveAntrasImport stores an import together with all of its records and staff.
+// Everything is written in one transaction, so a failed import leaves no traces.
+// The generated IDs are written back into the passed structure.
+func SaveAntrasImport(db *sql.DB, imp *AntrasImport) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	errorsJSON := imp.Errors
+	if len(errorsJSON) == 0 {
+		errorsJSON = json.RawMessage("[]")
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO antras_imports
+			(file_name, sheets_count, records_count, skipped_sheets,
+			 duplicates_skipped, errors, user_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`,
+		imp.FileName, imp.SheetsCount, imp.RecordsCount, imp.SkippedSheets,
+		imp.DuplicatesSkipped, string(errorsJSON), imp.UserID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create antras import: %w", err)
+	}
+	imp.ID, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	recordStmt, err := tx.Prepare(`
+		INSERT INTO antras_records
+			(import_id, station_code, original_sheet, sheet_row, network_point_name,
+			 train_no_in, train_no_out, vehicle_in, vehicle_out,
+			 vehicle_working_in, vehicle_working_out, validity_in, validity_out,
+			 arrival_date_time, departure_date_time, raw_data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer recordStmt.Close()
+
+	staffStmt, err := tx.Prepare(`
+		INSERT INTO antras_staff
+			(record_id, direction, personnel_number, name, phone,
+			 occupation, duty, duty_start, duty_end)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer staffStmt.Close()
+
+	for i := range imp.Records {
+		rec := &imp.Records[i]
+		rec.ImportID = imp.ID
+
+		result, err := recordStmt.Exec(
+			rec.ImportID, rec.StationCode, rec.OriginalSheet, rec.SheetRow, rec.NetworkPointName,
+			rec.TrainNoIn, rec.TrainNoOut, rec.VehicleIn, rec.VehicleOut,
+			rec.VehicleWorkingIn, rec.VehicleWorkingOut,
+			nullableDate(rec.ValidityIn), nullableDate(rec.ValidityOut),
+			rec.ArrivalDateTime, rec.DepartureDateTime, rec.RawData,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save row %d of sheet %s: %w", rec.SheetRow, rec.OriginalSheet, err)
+		}
+		rec.ID, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
+
+		for j := range rec.Staff {
+			staff := &rec.Staff[j]
+			staff.RecordID = rec.ID
+
+			result, err := staffStmt.Exec(
+				staff.RecordID, staff.Direction, staff.PersonnelNumber, staff.Name, staff.Phone,
+				staff.Occupation, staff.Duty, staff.DutyStart, staff.DutyEnd,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to save staff of row %d: %w", rec.SheetRow, err)
+			}
+			staff.ID, err = result.LastInsertId()
+			if err != nil {
+				return fmt.Errorf("failed to get last insert id: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// AntrasStations returns the Antras station codes whose rows the editor may
+// read: the codes of their depots, with the depot sheets (LTE.D for LTE).
+// Returns nil for admins, who read every station.
+func (e Editor) AntrasStations() []string {
+	if e.IsAdmin {
+		return nil
+	}
+	stations := []string{}
+	for depot := range e.Depots {
+		stations = append(stations, depot, depot+".D")
+	}
+	return stations
+}
+
+// FilterAntrasRecords keeps the records of the given stations
+// (nil keeps every record).
+func FilterAntrasRecords(records []AntrasRecord, stations []string) []AntrasRecord {
+	if stations == nil {
+		return records
+	}
+	allowed := make(map[string]bool, len(stations))
+	for _, station := range stations {
+		allowed[station] = true
+	}
+	kept := []AntrasRecord{}
+	for _, record := range records {
+		if allowed[record.StationCode] {
+			kept = append(kept, record)
+		}
+	}
+	return kept
+}
+
+// antrasStationCondition limits a query to rows of the given stations, with
+// r as the antras_records alias (nil for all stations).
+func antrasStationCondition(stations []string) (string, []any) {
+	if stations == nil {
+		return "", nil
+	}
+	if len(stations) == 0 {
+		return " AND FALSE", nil
+	}
+	args := make([]any, len(stations))
+	for i, station := range stations {
+		args[i] = station
+	}
+	return " AND r.station_code IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(stations)), ", ") + ")", args
+}
+
+// GetAntrasImports retrieves the import history, newest first, without records.
+// With stations (see Editor.AntrasStations), only imports holding rows of
+// those stations are listed; nil lists every import.
+func GetAntrasImports(db *sql.DB, stations []string) ([]AntrasImport, error) {
+	query := `
+		SELECT id, file_name, sheets_count, records_count, skipped_sheets,
+		       duplicates_skipped, errors, user_id, created_at
+		FROM antras_imports i`
+	condition, args := antrasStationCondition(stations)
+	if condition != "" {
+		query += " WHERE EXISTS (SELECT 1 FROM antras_records r WHERE r.import_id = i.id" + condition + ")"
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query antras imports: %w", err)
+	}
+	defer rows.Close()
+
+	var imports []AntrasImport
+	for rows.Next() {
+		imp, err := scanAntrasImport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan antras import: %w", err)
+		}
+		imports = append(imports, imp)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating antras imports: %w", err)
+	}
+
+	return imports, nil
+}
+
+// GetAntrasImportByID retrieves a single import including its records and staff.
+func GetAntrasImportByID(db *sql.DB, id int64) (*AntrasImport, error) {
+	row := db.QueryRow(`
+		SELECT id, file_name, sheets_count, records_count, skipped_sheets,
+		       duplicates_skipped, errors, user_id, created_at
+		FROM antras_imports
+		WHERE id = ?
+	`, id)
+
+	imp, err := scanAntrasImport(row)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get antras import: %w", err)
+	}
+
+	records, err := GetAntrasRecords(db, id)
+	if err != nil {
+		return nil, err
+	}
+	imp.Records = records
+
+	return &imp, nil
+}
+
+// GetAntrasRecords retrieves all records of an import with their staff,
+// ordered the same way they appeared in the workbook.
+func GetAntrasRecords(db *sql.DB, importID int64) ([]AntrasRecord, error) {
+	rows, err := db.Query(`
+		SELECT id, import_id, station_code, original_sheet, sheet_row, network_point_name,
+		       train_no_in, train_no_out, vehicle_in, vehicle_out,
+		       vehicle_working_in, vehicle_working_out, validity_in, validity_out,
+		       arrival_date_time, departure_date_time, raw_data
+		FROM antras_records
+		WHERE import_id = ?
+		ORDER BY id ASC
+	`, importID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query antras records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []AntrasRecord
+	index := make(map[int64]int)
+	for rows.Next() {
+		var rec AntrasRecord
+		var validityIn, validityOut, arrival, departure sql.NullTime
+		if err := rows.Scan(
+			&rec.ID, &rec.ImportID, &rec.StationCode, &rec.OriginalSheet, &rec.SheetRow,
+			&rec.NetworkPointName, &rec.TrainNoIn, &rec.TrainNoOut, &rec.VehicleIn, &rec.VehicleOut,
+			&rec.VehicleWorkingIn, &rec.VehicleWorkingOut, &validityIn, &validityOut,
+			&arrival, &departure, &rec.RawData,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan antras record: %w", err)
+		}
+
+		if validityIn.Valid {
+			rec.ValidityIn = validityIn.Time.Format("2006-01-02")
+		}
+		if validityOut.Valid {
+			rec.ValidityOut = validityOut.Time.Format("2006-01-02")
+		}
+		if arrival.Valid {
+			rec.ArrivalDateTime = &arrival.Time
+		}
+		if departure.Valid {
+			rec.DepartureDateTime = &departure.Time
+		}
+		rec.Staff = []AntrasStaff{}
+
+		index[rec.ID] = len(records)
+		records = append(records, rec)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating antras records: %w", err)
+	}
+
+	// Attach staff to their records
+	staffRows, err := db.Query(`
+		SELECT s.id, s.record_id, s.direction, s.personnel_number, s.name, s.phone,
+		       s.occupation, s.duty, s.duty_start, s.duty_end
+		FROM antras_staff s
+		JOIN antras_records r ON r.id = s.record_id
+		WHERE r.import_id = ?
+		ORDER BY s.id ASC
+	`, importID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query antras staff: %w", err)
+	}
+	defer staffRows.Close()
+
+	for staffRows.Next() {
+		var staff AntrasStaff
+		var dutyStart, dutyEnd sql.NullTime
+		if err := staffRows.Scan(
+			&staff.ID, &staff.RecordID, &staff.Direction, &staff.PersonnelNumber, &staff.Name,
+			&staff.Phone, &staff.Occupation, &staff.Duty, &dutyStart, &dutyEnd,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan antras staff: %w", err)
+		}
+		if dutyStart.Valid {
+			staff.DutyStart = &dutyStart.Time
+		}
+		if dutyEnd.Valid {
+			staff.DutyEnd = &dutyEnd.Time
+		}
+
+		if i, ok := index[staff.RecordID]; ok {
+			records[i].Staff = append(records[i].Staff, staff)
+		}
+	}
+	if err = staffRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating antras staff: %w", err)
+	}
+
+	return records, nil
+}
+
+// scanAntrasImport reads an antras_imports row from a query result.
+func scanAntrasImport(row interface{ Scan(...any) error }) (AntrasImport, error) {
+	var imp AntrasImport
+	var errorsJSON string
+	var userID sql.NullInt64
+	err := row.Scan(
+		&imp.ID, &imp.FileName, &imp.SheetsCount, &imp.RecordsCount, &imp.SkippedSheets,
+		&imp.DuplicatesSkipped, &errorsJSON, &userID, &imp.CreatedAt,
+	)
+	if err != nil {
+		return imp, err
+	}
+
+	imp.Errors = json.RawMessage(errorsJSON)
+	if userID.Valid {
+		id := int(userID.Int64)
+		imp.UserID = &id
+	}
+
+	return imp, nil
+}
+
+// nullableDate converts an empty date string to NULL for DATE columns.
+func nullableDate(date string) any {
+	if date == "" {
+		return nil
+	}
+	return date
+}
@@ -0,0 +1,174 @@
+// backend/internal/models/calendar_feed.go
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// CalendarFeed is a secret iCalendar URL publishing one employee's duties.
+// The employee is identified by the Antras personnel number and by the name
+// used in the schedule employee fields; either may be empty.
+type CalendarFeed struct {
+	ID              int        `json:"id"`
+	PersonnelNumber string     `json:"personnel_number"`
+	EmployeeName    string     `json:"employee_name"`
+	UserID          *int       `json:"user_id"`
+	CreatedAt       time.Time  `json:"created_at"`
+	RevokedAt       *time.Time `json:"revoked_at"`
+	LastAccessedAt  *time.Time `json:"last_accessed_at"`
+}
+
+// calendarFeedColumns lists the columns read by scanCalendarFeed, in order.
+const calendarFeedColumns = `
+	id, personnel_number, employee_name, user_id, created_at, revoked_at, last_accessed_at
+`
+
+// scanCalendarFeed reads one calendar_feeds row selected with calendarFeedColumns.
+func scanCalendarFeed(row interface{ Scan(...any) error }) (CalendarFeed, error) {
+	var feed CalendarFeed
+	var userID sql.NullInt64
+	var revokedAt, accessedAt sql.NullTime
+
+	if err := row.Scan(
+		&feed.ID, &feed.PersonnelNumber, &feed.EmployeeName, &userID,
+		&feed.CreatedAt, &revokedAt, &accessedAt,
+	); err != nil {
+		return feed, err
+	}
+
+	if userID.Valid {
+		id := int(userID.Int64)
+		feed.UserID = &id
+	}
+	if revokedAt.Valid {
+		feed.RevokedAt = &revokedAt.Time
+	}
+	if accessedAt.Valid {
+		feed.LastAccessedAt = &accessedAt.Time
+	}
+
+	return feed, nil
+}
+
+// hashFeedToken returns the stored form of a feed token.
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateCalendarFeed creates a feed and returns it with its token.
+// Only a hash of the token is stored, so it cannot be shown again later.
+//
+// Parameters:
+//   - db: Database connection
+//   - personnelNumber, employeeName: Employee of the feed (at least one)
+//   - userID: User creating the feed
+//
+// Returns:
+//   - Created feed and its token
+//   - Error if the database operation fails
+func CreateCalendarFeed(db *sql.DB, personnelNumber, employeeName string, userID int) (*CalendarFeed, string, error) {
+	if personnelNumber == "" && employeeName == "" {
+		return nil, "", fmt.Errorf("personnel number or employee name is required")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	result, err := db.Exec(`
+		INSERT INTO calendar_feeds (token_hash, personnel_number, employee_name, user_id)
+		VALUES (?, ?, ?, ?)
+	`, hashFeedToken(token), personnelNumber, employeeName, userID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create calendar feed: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	feed, err := GetCalendarFeedByID(db, int(id))
+	if err != nil {
+		return nil, "", err
+	}
+	return feed, token, nil
+}
+
+// GetCalendarFeeds retrieves all feeds, newest first.
+func GetCalendarFeeds(db *sql.DB) ([]CalendarFeed, error) {
+	rows, err := db.Query("SELECT " + calendarFeedColumns + " FROM calendar_feeds ORDER BY created_at DESC, id DESC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query calendar feeds: %w", err)
+	}
+	defer rows.Close()
+
+	feeds := []CalendarFeed{}
+	for rows.Next() {
+		feed, err := scanCalendarFeed(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan calendar feed: %w", err)
+		}
+		feeds = append(feeds, feed)
+	}
+
+	return feeds, rows.Err()
+}
+
+// GetCalendarFeedByID retrieves a single feed.
+// Returns sql.ErrNoRows if the feed does not exist.
+func GetCalendarFeedByID(db *sql.DB, id int) (*CalendarFeed, error) {
+	feed, err := scanCalendarFeed(db.QueryRow(
+		"SELECT "+calendarFeedColumns+" FROM calendar_feeds WHERE id = ?", id,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// GetCalendarFeedByToken retrieves the active feed with the given token and
+// records the access. Returns sql.ErrNoRows for unknown and revoked tokens.
+func GetCalendarFeedByToken(db *sql.DB, token string) (*CalendarFeed, error) {
+	feed, err := scanCalendarFeed(db.QueryRow(
+		"SELECT "+calendarFeedColumns+" FROM calendar_feeds WHERE token_hash = ? AND revoked_at IS NULL",
+		hashFeedToken(token),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec("UPDATE calendar_feeds SET last_accessed_at = NOW() WHERE id = ?", feed.ID); err != nil {
+		return nil, err
+	}
+
+	return &feed, nil
+}
+
+// RevokeCalendarFeed stops a feed from serving. Calendars subscribed to it
+// keep their last copy but no longer receive updates.
+// Returns sql.ErrNoRows if the feed does not exist or is already revoked.
+func RevokeCalendarFeed(db *sql.DB, id int) error {
+	result, err := db.Exec("UPDATE calendar_feeds SET revoked_at = NOW() WHERE id = ? AND revoked_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// backend/internal/models/depot_member.go
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Per-depot roles
+const (
+	DepotRoleEditor = "editor" // May edit and delete records touching the depot
+	DepotRoleViewer = "viewer" // May only read records touching the depot
+)
+
+// IsDepotRole reports whether role is a known per-depot role.
+func IsDepotRole(role string) bool {
+	return role == DepotRoleEditor || role == DepotRoleViewer
+}
+
+// DepotMember assigns a user to a depot team with a role.
+type DepotMember struct {
+	ID          int       `json:"id"`
+	StationID   int       `json:"station_id"`
+	StationCode string    `json:"station_code"`
+	UserID      int       `json:"user_id"`
+	Username    string    `json:"username"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// depotMemberQuery selects members with their station code and username
+const depotMemberQuery = `
+	SELECT m.id, m.station_id, s.code, m.user_id, u.username, m.role, m.created_at
+	FROM depot_members m
+	JOIN stations s ON s.id = m.station_id
+	JOIN users u ON u.id = m.user_id
+`
+
+// depotMemberCodes selects the depot codes a user (the only parameter) is a member of
+const depotMemberCodes = `
+	SELECT s.code FROM depot_members m JOIN stations s ON s.id = m.station_id
+	WHERE m.user_id = ? AND s.deleted_at IS NULL
+`
+
+// queryDepotMembers runs depotMemberQuery with the given condition.
+func queryDepotMembers(db *sql.DB, where string, args ...any) ([]DepotMember, error) {
+	rows, err := db.Query(depotMemberQuery+where+" ORDER BY s.code, u.username", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query depot members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []DepotMember{}
+	for rows.Next() {
+		var m DepotMember
+		if err := rows.Scan(
+			&m.ID, &m.StationID, &m.StationCode, &m.UserID, &m.Username, &m.Role, &m.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan depot member: %w", err)
+		}
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
+
+// GetDepotMembers retrieves the team of a depot.
+func GetDepotMembers(db *sql.DB, stationID int) ([]DepotMember, error) {
+	return queryDepotMembers(db, " WHERE m.station_id = ?", stationID)
+}
+
+// GetUserDepots retrieves the depot teams a user belongs to.
+// Teams of stations in the trash are left out.
+func GetUserDepots(db *sql.DB, userID int) ([]DepotMember, error) {
+	return queryDepotMembers(db, " WHERE m.user_id = ? AND s.deleted_at IS NULL", userID)
+}
+
+// SetDepotMember adds a user to a depot team or changes their role.
+//
+// Parameters:
+//   - db: Database connection
+//   - stationID: Depot station
+//   - userID: User to assign
+//   - role: DepotRoleEditor or DepotRoleViewer
+//
+// Returns:
+//   - Error if the role is unknown or the database operation fails
+func SetDepotMember(db *sql.DB, stationID, userID int, role string) error {
+	if !IsDepotRole(role) {
+		return fmt.Errorf("unknown depot role %q", role)
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO depot_members (station_id, user_id, role)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE role = VALUES(role)
+	`, stationID, userID, role)
+	return err
+}
+
+// RemoveDepotMember removes a user from a depot team.
+// Returns sql.ErrNoRows if the user is not a member.
+func RemoveDepotMember(db *sql.DB, stationID, userID int) error {
+	result, err := db.Exec("DELETE FROM depot_members WHERE station_id = ? AND user_id = ?", stationID, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// NewEditor identifies a user making changes to train schedule records,
+// with the depot teams that decide which records of others they may touch.
+//
+// Parameters:
+//   - db: Database connection
+//   - userID: User performing the change
+//   - isAdmin: Whether the user is an admin (admins may edit everything)
+//   - requestID: Request ID stored in the revision history
+//
+// Returns:
+//   - Editor with the user's depot roles
+//   - Error if the database operation fails
+func NewEditor(db *sql.DB, userID int, isAdmin bool, requestID string) (Editor, error) {
+	editor := Editor{UserID: userID, IsAdmin: isAdmin, RequestID: requestID}
+	if isAdmin {
+		return editor, nil
+	}
+
+	depots, err := GetUserDepots(db, userID)
+	if err != nil {
+		return editor, err
+	}
+	editor.Depots = make(map[string]string, len(depots))
+	for _, depot := range depots {
+		editor.Depots[depot.StationCode] = depot.Role
+	}
+
+	return editor, nil
+}
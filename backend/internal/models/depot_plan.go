@@ -0,0 +1,447 @@
+// backend/internal/models/depot_plan.go
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"yopta-template/internal/timeparse"
+)
+
+// Depot plan states
+const (
+	PlanDraft     = "draft"     // Being prepared; its records may be changed
+	PlanPublished = "published" // Finished; read-only and visible to viewers
+	PlanArchived  = "archived"  // Past plan kept for reference; read-only
+)
+
+// ErrPlanPublished is returned for changes to records of a depot day whose
+// plan is published or archived.
+var ErrPlanPublished = errors.New("the depot plan of the day is published")
+
+// ErrPlanTransition is returned for a change of state the workflow does not allow.
+var ErrPlanTransition = errors.New("depot plan cannot change to this state")
+
+// ErrPlanForbidden is returned when the user may not change a depot plan:
+// only admins and the depot's editors change plans, and only admins reopen them.
+var ErrPlanForbidden = errors.New("not allowed to change the depot plan")
+
+// planTransitions lists the allowed changes of state. Going back to a draft
+// reopens the plan and is reserved for admins.
+var planTransitions = map[string][]string{
+	PlanDraft:     {PlanPublished},
+	PlanPublished: {PlanArchived, PlanDraft},
+	PlanArchived:  {PlanDraft},
+}
+
+// DepotPlan is the schedule of one depot on one day: the records departing
+// from or arriving at the depot within the day.
+type DepotPlan struct {
+	ID          int       `json:"id"`
+	StationID   int       `json:"station_id"`
+	StationCode string    `json:"station_code"` // Depot code
+	Date        string    `json:"date"`         // Local (Vilnius) day, YYYY-MM-DD
+	StartsAt    time.Time `json:"starts_at"`    // Start of the day
+	EndsAt      time.Time `json:"ends_at"`      // End of the day (exclusive)
+	Status      string    `json:"status"`       // PlanDraft, PlanPublished or PlanArchived
+	Version     int64     `json:"version"`      // Incremented on every change of state
+	CreatedBy   *int      `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DepotPlanTransition is one change of state of a depot plan.
+type DepotPlanTransition struct {
+	ID         int64     `json:"id"`
+	PlanID     int       `json:"plan_id"`
+	FromStatus *string   `json:"from_status"` // nil when the plan was created
+	ToStatus   string    `json:"to_status"`
+	UserID     *int      `json:"user_id"`
+	Username   string    `json:"username,omitempty"`
+	Comment    string    `json:"comment"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// DepotPlanFilter selects depot plans.
+type DepotPlanFilter struct {
+	Depot  string // Depot code (empty for all depots)
+	From   string // First day, YYYY-MM-DD (empty for no limit)
+	To     string // Last day, YYYY-MM-DD (empty for no limit)
+	Status string // Only plans in this state (empty for all)
+}
+
+// depotPlanColumns lists the columns read by scanDepotPlan, in order.
+const depotPlanColumns = `
+	p.id, p.station_id, s.code, DATE_FORMAT(p.plan_date, '%Y-%m-%d'), p.starts_at, p.ends_at,
+	p.status, p.version, p.created_by, p.created_at, p.updated_at
+`
+
+// scanDepotPlan reads one depot_plans row selected with depotPlanColumns.
+func scanDepotPlan(row interface{ Scan(...any) error }) (DepotPlan, error) {
+	var p DepotPlan
+	err := row.Scan(
+		&p.ID, &p.StationID, &p.StationCode, &p.Date, &p.StartsAt, &p.EndsAt,
+		&p.Status, &p.Version, &p.CreatedBy, &p.CreatedAt, &p.UpdatedAt,
+	)
+	return p, err
+}
+
+// publishedPlanCondition matches train_schedules rows that belong to a
+// published depot plan through their departure or their arrival.
+const publishedPlanCondition = `EXISTS (
+	SELECT 1 FROM depot_plans p JOIN stations s ON s.id = p.station_id
+	WHERE p.status = 'published' AND (
+		(s.code = train_schedules.starting_location
+			AND train_schedules.departure_date_time >= p.starts_at AND train_schedules.departure_date_time < p.ends_at)
+		OR (s.code = train_schedules.end_location
+			AND train_schedules.arrival_date_time >= p.starts_at AND train_schedules.arrival_date_time < p.ends_at)
+	)
+)`
+
+// GetDepotPlans retrieves the plans matching the filter by day and depot.
+func GetDepotPlans(db *sql.DB, filter DepotPlanFilter) ([]DepotPlan, error) {
+	where := " WHERE 1 = 1"
+	var args []any
+	if filter.Depot != "" {
+		where += " AND s.code = ?"
+		args = append(args, filter.Depot)
+	}
+	if filter.From != "" {
+		where += " AND p.plan_date >= ?"
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		where += " AND p.plan_date <= ?"
+		args = append(args, filter.To)
+	}
+	if filter.Status != "" {
+		where += " AND p.status = ?"
+		args = append(args, filter.Status)
+	}
+
+	rows, err := db.Query(
+		"SELECT "+depotPlanColumns+" FROM depot_plans p JOIN stations s ON s.id = p.station_id"+
+			where+" ORDER BY p.plan_date, s.code",
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query depot plans: %w", err)
+	}
+	defer rows.Close()
+
+	plans := []DepotPlan{}
+	for rows.Next() {
+		p, err := scanDepotPlan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan depot plan: %w", err)
+		}
+		plans = append(plans, p)
+	}
+
+	return plans, rows.Err()
+}
+
+// GetDepotPlanByID retrieves a single plan.
+// Returns sql.ErrNoRows if it does not exist.
+func GetDepotPlanByID(db *sql.DB, id int) (*DepotPlan, error) {
+	p, err := scanDepotPlan(db.QueryRow(
+		"SELECT "+depotPlanColumns+" FROM depot_plans p JOIN stations s ON s.id = p.station_id WHERE p.id = ?", id,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetDepotPlanTransitions retrieves the changes of state of a plan, oldest first.
+func GetDepotPlanTransitions(db *sql.DB, planID int) ([]DepotPlanTransition, error) {
+	rows, err := db.Query(`
+		SELECT t.id, t.plan_id, t.from_status, t.to_status, t.user_id, COALESCE(u.username, ''), t.comment, t.created_at
+		FROM depot_plan_transitions t
+		LEFT JOIN users u ON u.id = t.user_id
+		WHERE t.plan_id = ?
+		ORDER BY t.id
+	`, planID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transitions := []DepotPlanTransition{}
+	for rows.Next() {
+		var t DepotPlanTransition
+		if err := rows.Scan(
+			&t.ID, &t.PlanID, &t.FromStatus, &t.ToStatus, &t.UserID, &t.Username, &t.Comment, &t.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, t)
+	}
+
+	return transitions, rows.Err()
+}
+
+// canChangePlan reports whether the editor may change the plans of a depot.
+func (e Editor) canChangePlan(depot string) bool {
+	return e.IsAdmin || e.Depots[depot] == DepotRoleEditor
+}
+
+// CreateDepotPlan starts a draft plan of a depot for a day.
+//
+// Parameters:
+//   - db: Database connection
+//   - depot: Depot (station) code
+//   - day: Day of the plan, read in Vilnius time
+//   - editor: User creating the plan (admin or editor of the depot)
+//   - comment: Note stored with the creation
+//
+// Returns:
+//   - ID of the new plan
+//   - sql.ErrNoRows if the depot does not exist
+//   - ErrPlanForbidden if the user may not plan the depot
+//   - Error if the database operation fails (a duplicate key if the plan exists)
+func CreateDepotPlan(db *sql.DB, depot string, day time.Time, editor Editor, comment string) (int, error) {
+	if !editor.canChangePlan(depot) {
+		return 0, ErrPlanForbidden
+	}
+
+	var stationID int
+	err := db.QueryRow("SELECT id FROM stations WHERE code = ? AND deleted_at IS NULL", depot).Scan(&stationID)
+	if err != nil {
+		return 0, err
+	}
+
+	day = day.In(timeparse.Vilnius)
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, timeparse.Vilnius)
+	end := start.AddDate(0, 0, 1)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO depot_plans (station_id, plan_date, starts_at, ends_at, status, created_by)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, stationID, start.Format("2006-01-02"), start.UTC(), end.UTC(), PlanDraft, editor.UserID)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	if err := insertDepotPlanTransition(tx, int(id), nil, PlanDraft, editor, comment); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// DepotPlanExists reports whether a depot already has a plan for the day.
+func DepotPlanExists(db *sql.DB, depot string, day time.Time) (bool, error) {
+	var exists bool
+	err := db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM depot_plans p JOIN stations s ON s.id = p.station_id
+			WHERE s.code = ? AND p.plan_date = ?
+		)
+	`, depot, day.In(timeparse.Vilnius).Format("2006-01-02")).Scan(&exists)
+	return exists, err
+}
+
+// TransitionDepotPlan moves a plan to another state and records the change.
+// Publishing and archiving are open to admins and the depot's editors;
+// reopening a published or archived plan as a draft only to admins.
+//
+// Parameters:
+//   - db: Database connection
+//   - id: Plan ID
+//   - status: New state
+//   - expectedVersion: Version the client saw (0 skips the check)
+//   - editor: User changing the state
+//   - comment: Note stored with the change, e.g. why the plan was reopened
+//
+// Returns:
+//   - The plan in its new state
+//   - sql.ErrNoRows if the plan does not exist
+//   - ErrPlanTransition if the workflow does not allow the change
+//   - ErrPlanForbidden if the user may not make the change
+//   - Error if the database operation fails (*VersionConflictError if the plan was changed meanwhile)
+func TransitionDepotPlan(
+	db *sql.DB,
+	id int,
+	status string,
+	expectedVersion int64,
+	editor Editor,
+	comment string,
+) (*DepotPlan, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := scanDepotPlan(tx.QueryRow(
+		"SELECT "+depotPlanColumns+" FROM depot_plans p JOIN stations s ON s.id = p.station_id WHERE p.id = ? FOR UPDATE",
+		id,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := false
+	for _, next := range planTransitions[current.Status] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: %s to %s", ErrPlanTransition, current.Status, status)
+	}
+	if !editor.canChangePlan(current.StationCode) || (status == PlanDraft && !editor.IsAdmin) {
+		return nil, ErrPlanForbidden
+	}
+
+	if err := checkVersion("depot_plan", strconv.Itoa(id), expectedVersion, current.Version); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE depot_plans SET status = ?, version = version + 1 WHERE id = ?", status, id,
+	); err != nil {
+		return nil, err
+	}
+	from := current.Status
+	if err := insertDepotPlanTransition(tx, id, &from, status, editor, comment); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	current.Status = status
+	current.Version++
+	return &current, nil
+}
+
+// insertDepotPlanTransition records a change of state of a plan.
+func insertDepotPlanTransition(tx *sql.Tx, planID int, from *string, to string, editor Editor, comment string) error {
+	_, err := tx.Exec(`
+		INSERT INTO depot_plan_transitions (plan_id, from_status, to_status, user_id, comment)
+		VALUES (?, ?, ?, ?, ?)
+	`, planID, from, to, editor.UserID, comment)
+	return err
+}
+
+// planDay is a depot on a local (Vilnius) day.
+type planDay struct {
+	depot string
+	date  string // YYYY-MM-DD
+}
+
+// planDays returns the depot days a record belongs to: the day of its
+// departure at the starting location and the day of its arrival at the end location.
+func planDays(s *TrainSchedule) []planDay {
+	var days []planDay
+	if s.StartingLocation != "" && s.DepartureDateTime != nil {
+		days = append(days, planDay{s.StartingLocation, s.DepartureDateTime.In(timeparse.Vilnius).Format("2006-01-02")})
+	}
+	if s.EndLocation != "" && s.ArrivalDateTime != nil {
+		days = append(days, planDay{s.EndLocation, s.ArrivalDateTime.In(timeparse.Vilnius).Format("2006-01-02")})
+	}
+	return days
+}
+
+// queryer runs queries on a database or within a transaction.
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// planDaysInState returns which of the depot days have a plan in one of the given states.
+func planDaysInState(q queryer, days []planDay, states ...string) (map[planDay]bool, error) {
+	found := make(map[planDay]bool)
+	if len(days) == 0 {
+		return found, nil
+	}
+
+	args := make([]any, 0, 2*len(days)+len(states))
+	for _, day := range days {
+		args = append(args, day.depot, day.date)
+	}
+	for _, state := range states {
+		args = append(args, state)
+	}
+
+	rows, err := q.Query(
+		"SELECT s.code, DATE_FORMAT(p.plan_date, '%Y-%m-%d') FROM depot_plans p JOIN stations s ON s.id = p.station_id"+
+			" WHERE (s.code, p.plan_date) IN ("+strings.TrimSuffix(strings.Repeat("(?, ?), ", len(days)), ", ")+")"+
+			" AND p.status IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(states)), ", ")+")",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var day planDay
+		if err := rows.Scan(&day.depot, &day.date); err != nil {
+			return nil, err
+		}
+		found[day] = true
+	}
+
+	return found, rows.Err()
+}
+
+// closedPlanDays returns the depot days of the records whose plan is
+// published or archived, so their records may not change.
+func closedPlanDays(q queryer, records ...*TrainSchedule) (map[planDay]bool, error) {
+	var days []planDay
+	for _, record := range records {
+		days = append(days, planDays(record)...)
+	}
+	return planDaysInState(q, days, PlanPublished, PlanArchived)
+}
+
+// inClosedPlan reports whether a record belongs to one of the closed depot days.
+func inClosedPlan(closed map[planDay]bool, s *TrainSchedule) bool {
+	for _, day := range planDays(s) {
+		if closed[day] {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPlansOpen returns ErrPlanPublished if any of the records belongs to a
+// published or archived depot plan.
+func checkPlansOpen(q queryer, records ...*TrainSchedule) error {
+	closed, err := closedPlanDays(q, records...)
+	if err != nil {
+		return err
+	}
+	if len(closed) > 0 {
+		return ErrPlanPublished
+	}
+	return nil
+}
+
+// InPublishedPlan reports whether a record belongs to a published depot plan,
+// i.e. whether users with the viewer role may see it.
+func InPublishedPlan(db *sql.DB, s *TrainSchedule) (bool, error) {
+	published, err := planDaysInState(db, planDays(s), PlanPublished)
+	return len(published) > 0, err
+}
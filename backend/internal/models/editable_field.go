@@ -0,0 +1,337 @@
+// backend/internal/models/editable_field.go
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Value types of editable fields
+const (
+	EditableText     = "text"     // Free text
+	EditableInteger  = "integer"  // Whole number written as text, e.g. a train number
+	EditableDateTime = "datetime" // RFC 3339 timestamp
+)
+
+// Reasons a value is rejected by an editable field
+const (
+	FieldValueRequired        = "required"         // The field may not be cleared
+	FieldValueTooLong         = "too_long"         // The value is longer than the field allows
+	FieldValueNotAllowed      = "not_allowed"      // The value is not one of the allowed values
+	FieldValueInvalidInteger  = "invalid_integer"  // The value is not a whole number
+	FieldValueInvalidDateTime = "invalid_datetime" // The value is not an RFC 3339 timestamp
+)
+
+// EditableField makes a train schedule field editable one value at a time
+// and describes the values it accepts. Fields without an active entry are
+// read-only for single-field updates.
+type EditableField struct {
+	ID            int       `json:"id"`
+	FieldName     string    `json:"field_name"`     // TrainSchedule JSON field, e.g. "vehicleName"
+	ValueType     string    `json:"value_type"`     // EditableText, EditableInteger or EditableDateTime
+	MaxLength     *int      `json:"max_length"`     // Longest value in characters, nil for the column size
+	AllowedValues []string  `json:"allowed_values"` // Values the field may take, empty for any value
+	IsRequired    bool      `json:"is_required"`    // Whether the field may be cleared
+	IsActive      bool      `json:"is_active"`
+	Description   *string   `json:"description,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// FieldValueError reports a value rejected by an editable field.
+type FieldValueError struct {
+	Field  string // Field name
+	Reason string // One of the FieldValue* reasons
+	Limit  int    // Longest allowed value for FieldValueTooLong
+}
+
+func (e *FieldValueError) Error() string {
+	if e.Reason == FieldValueTooLong {
+		return fmt.Sprintf("value of %s is longer than %d characters", e.Field, e.Limit)
+	}
+	return fmt.Sprintf("invalid value of %s: %s", e.Field, e.Reason)
+}
+
+// Check validates the field description itself: the field must be a tracked
+// schedule field, the type must suit its column and the limits must fit it.
+func (f *EditableField) Check() error {
+	column, ok := trainScheduleColumnOf(f.FieldName)
+	if !ok {
+		return fmt.Errorf("unknown train schedule field %q", f.FieldName)
+	}
+
+	switch f.ValueType {
+	case EditableDateTime:
+		if column.Size > 0 {
+			return fmt.Errorf("field %s does not hold a date and time", f.FieldName)
+		}
+		if f.MaxLength != nil || len(f.AllowedValues) > 0 {
+			return fmt.Errorf("date and time fields take no length limit or allowed values")
+		}
+		return nil
+	case EditableText, EditableInteger:
+		if column.Size == 0 {
+			return fmt.Errorf("field %s holds a date and time", f.FieldName)
+		}
+	default:
+		return fmt.Errorf("unknown value type %q", f.ValueType)
+	}
+
+	if f.MaxLength != nil && (*f.MaxLength < 1 || *f.MaxLength > column.Size) {
+		return fmt.Errorf("max length of %s must be between 1 and %d", f.FieldName, column.Size)
+	}
+	for _, value := range f.AllowedValues {
+		if _, err := f.Normalize(value); err != nil {
+			return fmt.Errorf("allowed value %q: %w", value, err)
+		}
+	}
+	return nil
+}
+
+// Normalize checks a new value against the field's rules and returns it in
+// the form stored in the revision history: trimmed, times in UTC, and nil
+// for a cleared date and time.
+// Returns *FieldValueError if the value is rejected.
+func (f *EditableField) Normalize(value string) (*string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		if f.IsRequired {
+			return nil, &FieldValueError{Field: f.FieldName, Reason: FieldValueRequired}
+		}
+		if f.ValueType == EditableDateTime {
+			return nil, nil
+		}
+		return &value, nil
+	}
+
+	switch f.ValueType {
+	case EditableDateTime:
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, &FieldValueError{Field: f.FieldName, Reason: FieldValueInvalidDateTime}
+		}
+		return formatRevisionTime(&t), nil
+	case EditableInteger:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, &FieldValueError{Field: f.FieldName, Reason: FieldValueInvalidInteger}
+		}
+	}
+
+	limit := 0
+	if column, ok := trainScheduleColumnOf(f.FieldName); ok {
+		limit = column.Size
+	}
+	if f.MaxLength != nil {
+		limit = *f.MaxLength
+	}
+	if limit > 0 && utf8.RuneCountInString(value) > limit {
+		return nil, &FieldValueError{Field: f.FieldName, Reason: FieldValueTooLong, Limit: limit}
+	}
+
+	if len(f.AllowedValues) > 0 {
+		allowed := false
+		for _, v := range f.AllowedValues {
+			if v == value {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, &FieldValueError{Field: f.FieldName, Reason: FieldValueNotAllowed}
+		}
+	}
+
+	return &value, nil
+}
+
+// editableFieldColumns lists the columns read by scanEditableField, in order.
+const editableFieldColumns = `
+	id, field_name, value_type, max_length, allowed_values,
+	is_required, is_active, description, created_at, updated_at
+`
+
+// scanEditableField reads one editable_fields row selected with editableFieldColumns.
+func scanEditableField(row interface{ Scan(...any) error }) (EditableField, error) {
+	var f EditableField
+	var maxLength sql.NullInt64
+	var allowedValues sql.NullString
+
+	if err := row.Scan(
+		&f.ID, &f.FieldName, &f.ValueType, &maxLength, &allowedValues,
+		&f.IsRequired, &f.IsActive, &f.Description, &f.CreatedAt, &f.UpdatedAt,
+	); err != nil {
+		return f, err
+	}
+
+	if maxLength.Valid {
+		length := int(maxLength.Int64)
+		f.MaxLength = &length
+	}
+	f.AllowedValues = []string{}
+	if allowedValues.Valid && allowedValues.String != "" {
+		if err := json.Unmarshal([]byte(allowedValues.String), &f.AllowedValues); err != nil {
+			return f, fmt.Errorf("invalid allowed values of %s: %w", f.FieldName, err)
+		}
+	}
+
+	return f, nil
+}
+
+// allowedValuesColumn converts allowed values to the stored JSON, nil for any value.
+func allowedValuesColumn(values []string) (any, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// GetEditableFields retrieves all editable field descriptions, including inactive ones.
+func GetEditableFields(db *sql.DB) ([]EditableField, error) {
+	return queryEditableFields(db, "")
+}
+
+// GetActiveEditableFields retrieves the fields users may currently edit.
+func GetActiveEditableFields(db *sql.DB) ([]EditableField, error) {
+	return queryEditableFields(db, "WHERE is_active = TRUE")
+}
+
+// queryEditableFields runs the field listing query with an optional WHERE clause.
+func queryEditableFields(db *sql.DB, where string) ([]EditableField, error) {
+	rows, err := db.Query("SELECT " + editableFieldColumns + " FROM editable_fields " + where + " ORDER BY field_name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query editable fields: %w", err)
+	}
+	defer rows.Close()
+
+	fields := []EditableField{}
+	for rows.Next() {
+		f, err := scanEditableField(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan editable field: %w", err)
+		}
+		fields = append(fields, f)
+	}
+
+	return fields, rows.Err()
+}
+
+// GetEditableFieldByID retrieves a single field description.
+// Returns sql.ErrNoRows if it does not exist.
+func GetEditableFieldByID(db *sql.DB, id int) (*EditableField, error) {
+	f, err := scanEditableField(db.QueryRow(
+		"SELECT "+editableFieldColumns+" FROM editable_fields WHERE id = ?", id,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// GetEditableField retrieves the rules of a field users may edit.
+// Returns sql.ErrNoRows if the field is not described or not active.
+func GetEditableField(db *sql.DB, fieldName string) (*EditableField, error) {
+	f, err := scanEditableField(db.QueryRow(
+		"SELECT "+editableFieldColumns+" FROM editable_fields WHERE field_name = ? AND is_active = TRUE",
+		fieldName,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// EditableFieldExists reports whether a field is already described by
+// another entry than exceptID (0 to check all entries).
+func EditableFieldExists(db *sql.DB, fieldName string, exceptID int) (bool, error) {
+	var exists bool
+	err := db.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM editable_fields WHERE field_name = ? AND id <> ?)",
+		fieldName, exceptID,
+	).Scan(&exists)
+	return exists, err
+}
+
+// CreateEditableField stores a new field description.
+// The description must pass Check first.
+func CreateEditableField(db *sql.DB, f *EditableField) error {
+	allowedValues, err := allowedValuesColumn(f.AllowedValues)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec(`
+		INSERT INTO editable_fields
+			(field_name, value_type, max_length, allowed_values, is_required, is_active, description)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, f.FieldName, f.ValueType, f.MaxLength, allowedValues, f.IsRequired, f.IsActive, f.Description)
+	if err != nil {
+		return fmt.Errorf("failed to create editable field: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	f.ID = int(id)
+	return nil
+}
+
+// UpdateEditableField changes a field description.
+// The description must pass Check first. Returns sql.ErrNoRows if it does not exist.
+func UpdateEditableField(db *sql.DB, id int, f *EditableField) error {
+	allowedValues, err := allowedValuesColumn(f.AllowedValues)
+	if err != nil {
+		return err
+	}
+
+	if _, err := GetEditableFieldByID(db, id); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		UPDATE editable_fields
+		SET
+			field_name = ?,
+			value_type = ?,
+			max_length = ?,
+			allowed_values = ?,
+			is_required = ?,
+			is_active = ?,
+			description = ?
+		WHERE id = ?
+	`, f.FieldName, f.ValueType, f.MaxLength, allowedValues, f.IsRequired, f.IsActive, f.Description, id)
+	if err != nil {
+		return fmt.Errorf("failed to update editable field: %w", err)
+	}
+
+	f.ID = id
+	return nil
+}
+
+// DeleteEditableField removes a field description, making the field read-only.
+// Returns sql.ErrNoRows if it does not exist.
+func DeleteEditableField(db *sql.DB, id int) error {
+	result, err := db.Exec("DELETE FROM editable_fields WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete editable field: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
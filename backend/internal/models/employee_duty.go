@@ -0,0 +1,208 @@
+// backend/internal/models/employee_duty.go
+package models
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dutyGap is the longest break between two trains of the same duty code
+// that still belong to one duty. Duty codes repeat from day to day.
+const dutyGap = 6 * time.Hour
+
+// EmployeeDuty is one duty of an employee, assembled from the schedule
+// records and Antras rows that list the employee.
+type EmployeeDuty struct {
+	Duty      string    `json:"duty"`       // Duty code, empty if the records have none
+	Start     time.Time `json:"start"`      // Duty start (Antras duty time, or the first train)
+	End       time.Time `json:"end"`        // Duty end (Antras duty time, or the last train)
+	Trains    []string  `json:"trains"`     // Train numbers
+	Locations []string  `json:"locations"`  // Stations and depots
+	Tracks    []string  `json:"tracks"`     // Tracks as "<location> <track>"
+	UpdatedAt time.Time `json:"updated_at"` // Latest change of a schedule record of the duty
+}
+
+// extend adds a piece of a duty: a train or an Antras duty period.
+func (d *EmployeeDuty) extend(piece EmployeeDuty) {
+	if piece.Start.Before(d.Start) {
+		d.Start = piece.Start
+	}
+	if piece.End.After(d.End) {
+		d.End = piece.End
+	}
+	if piece.UpdatedAt.After(d.UpdatedAt) {
+		d.UpdatedAt = piece.UpdatedAt
+	}
+	d.Trains = appendDistinct(d.Trains, piece.Trains...)
+	d.Locations = appendDistinct(d.Locations, piece.Locations...)
+	d.Tracks = appendDistinct(d.Tracks, piece.Tracks...)
+}
+
+// GetEmployeeDuties assembles the duties of an employee within a time range.
+//
+// Schedule records list the employee by name or personnel number in their
+// departure and arrival employee fields; each side the employee works becomes
+// a train of the duty named in the matching duty field. Antras rows add the
+// duty start and end times for the personnel number. Trains and duty periods
+// with the same duty code less than dutyGap apart form one duty.
+//
+// Parameters:
+//   - db: Database connection
+//   - personnelNumber, employeeName: Employee to collect duties for
+//   - from, to: Time range
+//
+// Returns:
+//   - Duties in time order
+//   - Error if the database operation fails
+func GetEmployeeDuties(db *sql.DB, personnelNumber, employeeName string, from, to time.Time) ([]EmployeeDuty, error) {
+	var names []any
+	for _, name := range []string{personnelNumber, employeeName} {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return []EmployeeDuty{}, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+
+	query := "SELECT " + trainScheduleSelectColumns + " FROM train_schedules" +
+		" WHERE deleted_at IS NULL" +
+		" AND ((employee1_departure IN (" + placeholders + ") AND departure_date_time >= ? AND departure_date_time < ?)" +
+		" OR (employee1_arrival IN (" + placeholders + ") AND arrival_date_time >= ? AND arrival_date_time < ?))"
+	params := append(append([]any{}, names...), from, to)
+	params = append(append(params, names...), from, to)
+
+	rows, err := db.Query(query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := func(value string) bool {
+		for _, name := range names {
+			if strings.EqualFold(strings.TrimSpace(value), name.(string)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var pieces []EmployeeDuty
+	for rows.Next() {
+		s, err := scanTrainSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		if s.DepartureDateTime != nil && matches(s.Employee1Departure) {
+			pieces = append(pieces, schedulePiece(s.DutyDeparture, *s.DepartureDateTime,
+				s.TrainNumberDeparture, s.StartingLocation, s.StartingTrack, s.UpdatedAt))
+		}
+		if s.ArrivalDateTime != nil && matches(s.Employee1Arrival) {
+			pieces = append(pieces, schedulePiece(s.DutyArrival, *s.ArrivalDateTime,
+				s.TrainNumberArrival, s.EndLocation, s.TargetTrack, s.UpdatedAt))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if personnelNumber != "" {
+		assignments, err := GetAntrasCrewAssignments(db, AntrasCrewFilter{
+			PersonnelNumber: personnelNumber,
+			From:            &from,
+			To:              &to,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range assignments {
+			if a.DutyStart == nil || a.DutyEnd == nil {
+				continue
+			}
+			end := *a.DutyEnd
+			// A duty ending after midnight may be written without the (+1) marker
+			if end.Before(*a.DutyStart) {
+				end = end.AddDate(0, 0, 1)
+			}
+			pieces = append(pieces, EmployeeDuty{
+				Duty:      a.Duty,
+				Start:     *a.DutyStart,
+				End:       end,
+				Trains:    appendDistinct(nil, a.TrainNo),
+				Locations: appendDistinct(nil, a.StationCode),
+			})
+		}
+	}
+
+	return mergeDutyPieces(pieces), nil
+}
+
+// schedulePiece describes one train of a schedule record worked by the employee.
+func schedulePiece(duty string, at time.Time, train, location, track string, updatedAt time.Time) EmployeeDuty {
+	piece := EmployeeDuty{
+		Duty:      strings.TrimSpace(duty),
+		Start:     at,
+		End:       at,
+		Trains:    appendDistinct(nil, train),
+		Locations: appendDistinct(nil, location),
+		UpdatedAt: updatedAt,
+	}
+	if track != "" {
+		piece.Tracks = appendDistinct(nil, strings.TrimSpace(location+" "+track))
+	}
+	return piece
+}
+
+// mergeDutyPieces joins trains and duty periods of the same duty code that
+// are less than dutyGap apart. Pieces without a duty code stay separate.
+func mergeDutyPieces(pieces []EmployeeDuty) []EmployeeDuty {
+	sort.SliceStable(pieces, func(a, b int) bool { return pieces[a].Start.Before(pieces[b].Start) })
+
+	duties := []EmployeeDuty{}
+	open := make(map[string]int) // duty code -> index of its latest duty
+	for _, piece := range pieces {
+		if i, ok := open[piece.Duty]; ok && piece.Duty != "" && !piece.Start.After(duties[i].End.Add(dutyGap)) {
+			duties[i].extend(piece)
+			continue
+		}
+
+		duty := EmployeeDuty{
+			Duty:      piece.Duty,
+			Start:     piece.Start,
+			End:       piece.End,
+			Trains:    []string{},
+			Locations: []string{},
+			Tracks:    []string{},
+			UpdatedAt: piece.UpdatedAt,
+		}
+		duty.extend(piece)
+		open[piece.Duty] = len(duties)
+		duties = append(duties, duty)
+	}
+
+	sort.SliceStable(duties, func(a, b int) bool { return duties[a].Start.Before(duties[b].Start) })
+	return duties
+}
+
+// appendDistinct appends the non-empty values that are not in the list yet.
+func appendDistinct(list []string, values ...string) []string {
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		found := false
+		for _, v := range list {
+			if v == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
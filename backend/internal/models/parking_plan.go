@@ -0,0 +1,63 @@
+// backend/internal/models/parking_plan.go
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"yopta-template/internal/parking"
+)
+
+// ProposeParkingPlan proposes track positions for the vehicles standing at a
+// station within a time range, usually one night of the depot.
+//
+// Every arrival and departure at the station in the range is paired into the
+// time its vehicle stands there, whatever track the records name. Locked
+// track assignments stay where dispatchers put them and their records are not
+// planned again; unlocked assignments are ignored, as the proposal replaces
+// them. Nothing is saved.
+//
+// Records carry no vehicle lengths, so the lengths of the tracks are not
+// checked; the proposal lists this among its limitations. Dead-end tracks are
+// planned as filo.
+//
+// Parameters:
+//   - db: Database connection
+//   - station: Station with its tracks
+//   - from, to: Time range to plan
+//
+// Returns:
+//   - The proposal with its conflicts, explanations and score
+//   - Error if the database operation fails
+func ProposeParkingPlan(db *sql.DB, station Station, from, to time.Time) (parking.Proposal, error) {
+	assignments, err := GetTrackAssignments(db, station.ID, from, to)
+	if err != nil {
+		return parking.Proposal{}, err
+	}
+	var fixed []parking.Assignment
+	lockedRecords := make(map[string]bool)
+	for _, assignment := range assignments {
+		if assignment.Locked {
+			fixed = append(fixed, assignment.Parking())
+			lockedRecords[assignment.ScheduleID] = true
+		}
+	}
+
+	arrivals, err := stationMovements(db, station.Code, true, from, to)
+	if err != nil {
+		return parking.Proposal{}, err
+	}
+	departures, err := stationMovements(db, station.Code, false, from, to)
+	if err != nil {
+		return parking.Proposal{}, err
+	}
+
+	var stays []parking.Assignment
+	for _, stay := range parking.Stays(arrivals, departures, from, to) {
+		if !lockedRecords[stay.ArrivalID] && !lockedRecords[stay.DepartureID] {
+			stays = append(stays, stay)
+		}
+	}
+
+	return parking.Plan(station.ParkingTracks(), fixed, stays), nil
+}
@@ -3,20 +3,25 @@ package models
 
 import (
 	"database/sql"
+	"strconv"
 	"time"
 )
 
 // Station represents a train station or depot in the system.
 // It serves as a container for tracks and is used in train schedule planning.
 type Station struct {
-	ID        int       `json:"id"`         // Unique identifier
-	Name      string    `json:"name"`       // Station/depot name
-	Code      string    `json:"code"`       // Station/depot code (unique)
-	Notes     string    `json:"notes"`      // Additional notes
-	CreatedAt time.Time `json:"created_at"` // When the record was created
-	UpdatedAt time.Time `json:"updated_at"` // When the record was last updated
-	UserID    int       `json:"user_id"`    // ID of the user who created the record
-	Tracks    []Track   `json:"tracks"`     // Associated tracks
+	ID        int            `json:"id"`                   // Unique identifier
+	Name      string         `json:"name"`                 // Station/depot name
+	Code      string         `json:"code"`                 // Station/depot code (unique)
+	Notes     string         `json:"notes"`                // Additional notes
+	CreatedAt time.Time      `json:"created_at"`           // When the record was created
+	UpdatedAt time.Time      `json:"updated_at"`           // When the record was last updated
+	Version   int64          `json:"version"`              // Incremented on every change of the station, its tracks or shifts
+	UserID    int            `json:"user_id"`              // ID of the user who created the record
+	DeletedAt *time.Time     `json:"deleted_at,omitempty"` // When the station was moved to the trash
+	DeletedBy *int           `json:"deleted_by,omitempty"` // ID of the user who deleted the station
+	Tracks    []Track        `json:"tracks"`               // Associated tracks
+	Shifts    []StationShift `json:"shifts"`               // Shift windows of the station
 }
 
 // Track represents a railway track within a station/depot.
@@ -33,16 +38,57 @@ type Track struct {
 	Notes       string    `json:"notes"`        // Additional notes
 	CreatedAt   time.Time `json:"created_at"`   // When the record was created
 	UpdatedAt   time.Time `json:"updated_at"`   // When the record was last updated
+	Version     int64     `json:"version"`      // Incremented on every change
+}
+
+// stationColumns lists the columns read by scanStation, in order.
+const stationColumns = `
+	id, name, code, notes, created_at, updated_at, version, user_id, deleted_at, deleted_by
+`
+
+// scanStation reads one stations row selected with stationColumns.
+func scanStation(row interface{ Scan(...any) error }) (Station, error) {
+	var station Station
+	var deletedAt sql.NullTime
+
+	err := row.Scan(
+		&station.ID,
+		&station.Name,
+		&station.Code,
+		&station.Notes,
+		&station.CreatedAt,
+		&station.UpdatedAt,
+		&station.Version,
+		&station.UserID,
+		&deletedAt,
+		&station.DeletedBy,
+	)
+	if err != nil {
+		return Station{}, err
+	}
+
+	if deletedAt.Valid {
+		station.DeletedAt = &deletedAt.Time
+	}
+	return station, nil
 }
 
 // GetAllStations retrieves all stations with their associated tracks.
+// Stations in the trash are left out.
 func GetAllStations(db *sql.DB) ([]Station, error) {
-	// Query to get all stations
-	rows, err := db.Query(`
-		SELECT id, name, code, notes, created_at, updated_at, user_id
-		FROM stations
-		ORDER BY name ASC
-	`)
+	return queryStations(db, "WHERE deleted_at IS NULL ORDER BY name ASC")
+}
+
+// GetDeletedStations retrieves the stations in the trash with their tracks,
+// most recently deleted first.
+func GetDeletedStations(db *sql.DB) ([]Station, error) {
+	return queryStations(db, "WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, name ASC")
+}
+
+// queryStations reads the stations selected by the given condition and order,
+// with their tracks and shifts.
+func queryStations(db *sql.DB, where string) ([]Station, error) {
+	rows, err := db.Query("SELECT " + stationColumns + " FROM stations " + where)
 	if err != nil {
 		return nil, err
 	}
@@ -50,50 +96,40 @@ func GetAllStations(db *sql.DB) ([]Station, error) {
 
 	var stations []Station
 	for rows.Next() {
-		var station Station
-		err := rows.Scan(
-			&station.ID,
-			&station.Name,
-			&station.Code,
-			&station.Notes,
-			&station.CreatedAt,
-			&station.UpdatedAt,
-			&station.UserID,
-		)
+		station, err := scanStation(rows)
 		if err != nil {
 			return nil, err
 		}
 		stations = append(stations, station)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	// For each station, get its tracks
+	// For each station, get its tracks and shifts
 	for i := range stations {
 		tracks, err := GetTracksByStationID(db, stations[i].ID)
 		if err != nil {
 			return nil, err
 		}
 		stations[i].Tracks = tracks
+
+		shifts, err := GetShiftsByStationID(db, stations[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		stations[i].Shifts = shifts
 	}
 
 	return stations, nil
 }
 
 // GetStationByID retrieves a single station by its ID, including its tracks.
+// Returns sql.ErrNoRows if the station does not exist or is in the trash.
 func GetStationByID(db *sql.DB, id int) (Station, error) {
-	var station Station
-	err := db.QueryRow(`
-		SELECT id, name, code, notes, created_at, updated_at, user_id
-		FROM stations
-		WHERE id = ?
-	`, id).Scan(
-		&station.ID,
-		&station.Name,
-		&station.Code,
-		&station.Notes,
-		&station.CreatedAt,
-		&station.UpdatedAt,
-		&station.UserID,
-	)
+	station, err := scanStation(db.QueryRow(
+		"SELECT "+stationColumns+" FROM stations WHERE id = ? AND deleted_at IS NULL", id,
+	))
 	if err != nil {
 		return Station{}, err
 	}
@@ -105,6 +141,13 @@ func GetStationByID(db *sql.DB, id int) (Station, error) {
 	}
 	station.Tracks = tracks
 
+	// Get shifts for this station
+	shifts, err := GetShiftsByStationID(db, station.ID)
+	if err != nil {
+		return Station{}, err
+	}
+	station.Shifts = shifts
+
 	return station, nil
 }
 
@@ -143,6 +186,14 @@ func CreateStation(db *sql.DB, station Station) (int, error) {
 		}
 	}
 
+	// Every station starts with the default shift windows
+	for _, shift := range DefaultStationShifts {
+		shift.StationID = int(stationID)
+		if _, err := insertShift(tx, shift); err != nil {
+			return 0, err
+		}
+	}
+
 	// Commit the transaction
 	if err = tx.Commit(); err != nil {
 		return 0, err
@@ -152,6 +203,13 @@ func CreateStation(db *sql.DB, station Station) (int, error) {
 }
 
 // UpdateStation updates an existing station and its tracks.
+// If station.Version is set, the update is rejected with *VersionConflictError
+// when the station has been changed since the client read it.
+//
+// Tracks are matched by ID, or by track number for tracks sent without one,
+// and updated in place so their track assignments are kept; tracks missing
+// from the request are removed. A station sent without tracks (nil) keeps
+// the ones it has.
 func UpdateStation(db *sql.DB, station Station) error {
 	// Start a transaction
 	tx, err := db.Begin()
@@ -160,53 +218,165 @@ func UpdateStation(db *sql.DB, station Station) error {
 	}
 	defer tx.Rollback()
 
+	// Lock the station and compare versions
+	if err := lockStationVersion(tx, station.ID, station.Version); err != nil {
+		return err
+	}
+
 	// Update station
 	_, err = tx.Exec(`
 		UPDATE stations 
-		SET name = ?, code = ?, notes = ?
+		SET name = ?, code = ?, notes = ?, version = version + 1
 		WHERE id = ?
 	`, station.Name, station.Code, station.Notes, station.ID)
 	if err != nil {
 		return err
 	}
 
-	// For simplicity, we'll delete all tracks and re-insert them
-	// In a real application, you might want to update existing tracks instead
-	_, err = tx.Exec(`DELETE FROM tracks WHERE station_id = ?`, station.ID)
+	if station.Tracks != nil {
+		if err := syncStationTracks(tx, station.ID, station.Tracks); err != nil {
+			return err
+		}
+	}
+
+	// Commit the transaction
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// syncStationTracks makes the tracks of a station match the given list.
+func syncStationTracks(tx *sql.Tx, stationID int, tracks []Track) error {
+	rows, err := tx.Query(`SELECT id, track_number FROM tracks WHERE station_id = ?`, stationID)
 	if err != nil {
 		return err
 	}
+	byNumber := make(map[string]int)
+	existing := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		var number string
+		if err := rows.Scan(&id, &number); err != nil {
+			rows.Close()
+			return err
+		}
+		byNumber[number] = id
+		existing[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
-	// Insert updated tracks
-	for _, track := range station.Tracks {
-		_, err := tx.Exec(`
-			INSERT INTO tracks (station_id, track_number, positions, length, notes)
-			VALUES (?, ?, ?, ?, ?)
-		`, station.ID, track.TrackNumber, track.Positions, track.Length, track.Notes)
+	// Match the tracks first, so a renumbered track does not take another's place
+	ids := make([]int, len(tracks))
+	kept := make(map[int]bool)
+	for i, track := range tracks {
+		id := track.ID
+		if !existing[id] {
+			id = byNumber[track.TrackNumber]
+		}
+		if id != 0 && !kept[id] {
+			ids[i] = id
+			kept[id] = true
+		}
+	}
+
+	for id := range existing {
+		if !kept[id] {
+			if _, err := tx.Exec(`DELETE FROM tracks WHERE id = ?`, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, track := range tracks {
+		applyTrackRules(&track)
+		if ids[i] == 0 {
+			_, err = tx.Exec(`
+				INSERT INTO tracks (station_id, track_number, positions, length, type, rule, exceptions, notes)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			`, stationID, track.TrackNumber, track.Positions, track.Length, track.Type, track.Rule, track.Exceptions, track.Notes)
+		} else {
+			_, err = tx.Exec(`
+				UPDATE tracks
+				SET track_number = ?, positions = ?, length = ?, type = ?, rule = ?, exceptions = ?, notes = ?,
+					version = version + 1
+				WHERE id = ?
+			`, track.TrackNumber, track.Positions, track.Length, track.Type, track.Rule, track.Exceptions, track.Notes, ids[i])
+		}
 		if err != nil {
 			return err
 		}
 	}
 
-	// Commit the transaction
-	if err = tx.Commit(); err != nil {
+	return nil
+}
+
+// applyTrackRules fills in the default type and rule of a track.
+// Dead-end tracks can only be FILO.
+func applyTrackRules(track *Track) {
+	if track.Type == "" {
+		track.Type = "through"
+	}
+	if track.Rule == "" {
+		track.Rule = "fifo"
+	}
+	if track.Type == "dead_end" {
+		track.Rule = "filo" // Force FILO for dead-end tracks
+	}
+}
+
+// DeleteStation moves a station to the trash. Its tracks, shifts and depot
+// team stay with it, so restoring the station brings the whole depot
+// configuration back. A non-zero expectedVersion must match the stored version.
+func DeleteStation(db *sql.DB, id int, expectedVersion int64, userID int) error {
+	tx, err := db.Begin()
+	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	return nil
+	if err := lockStationVersion(tx, id, expectedVersion); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE stations SET deleted_at = NOW(), deleted_by = ?, version = version + 1 WHERE id = ?`,
+		userID, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// DeleteStation removes a station and all its tracks from the database.
-func DeleteStation(db *sql.DB, id int) error {
-	// Note: With CASCADE delete on foreign key, this will also delete associated tracks
-	_, err := db.Exec(`DELETE FROM stations WHERE id = ?`, id)
+// lockStationVersion locks a station row until the transaction ends
+// and checks the client's version against it.
+// Returns sql.ErrNoRows if the station does not exist or is in the trash.
+func lockStationVersion(tx *sql.Tx, id int, expectedVersion int64) error {
+	var current int64
+	err := tx.QueryRow(`SELECT version FROM stations WHERE id = ? AND deleted_at IS NULL FOR UPDATE`, id).Scan(&current)
+	if err != nil {
+		return err
+	}
+	return checkVersion("station", strconv.Itoa(id), expectedVersion, current)
+}
+
+// touchStation increments a station's version after one of its tracks changed,
+// so the station's ETag covers its tracks too.
+func touchStation(tx *sql.Tx, stationID int) error {
+	_, err := tx.Exec(`UPDATE stations SET version = version + 1 WHERE id = ?`, stationID)
 	return err
 }
 
 // GetTracksByStationID retrieves all tracks for a given station.
 func GetTracksByStationID(db *sql.DB, stationID int) ([]Track, error) {
 	rows, err := db.Query(`
-        SELECT id, station_id, track_number, positions, length, type, rule, exceptions, notes, created_at, updated_at
+        SELECT id, station_id, track_number, positions, length, type, rule, exceptions, notes, created_at, updated_at, version
         FROM tracks
         WHERE station_id = ?
         ORDER BY track_number ASC
@@ -233,6 +403,7 @@ func GetTracksByStationID(db *sql.DB, stationID int) ([]Track, error) {
 			&track.Notes,
 			&track.CreatedAt,
 			&track.UpdatedAt,
+			&track.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -256,7 +427,13 @@ func AddTrack(db *sql.DB, track Track) (int, error) {
 		track.Rule = "filo" // Force FILO for dead-end tracks
 	}
 
-	result, err := db.Exec(`
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
         INSERT INTO tracks (station_id, track_number, positions, length, type, rule, exceptions, notes)
         VALUES (?, ?, ?, ?, ?, ?, ?, ?)
     `, track.StationID, track.TrackNumber, track.Positions, track.Length, track.Type, track.Rule, track.Exceptions, track.Notes)
@@ -269,27 +446,90 @@ func AddTrack(db *sql.DB, track Track) (int, error) {
 		return 0, err
 	}
 
+	if err := touchStation(tx, track.StationID); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
 	return int(trackID), nil
 }
 
 // UpdateTrack updates an existing track.
+// If track.Version is set, the update is rejected with *VersionConflictError
+// when the track has been changed since the client read it.
 func UpdateTrack(db *sql.DB, track Track) error {
 	// Validate rule based on type - dead-end tracks can only be FILO
 	if track.Type == "dead_end" {
 		track.Rule = "filo" // Force FILO for dead-end tracks
 	}
 
-	_, err := db.Exec(`
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockTrackVersion(tx, track.ID, track.Version); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
         UPDATE tracks 
-        SET track_number = ?, positions = ?, length = ?, type = ?, rule = ?, exceptions = ?, notes = ?
+        SET track_number = ?, positions = ?, length = ?, type = ?, rule = ?, exceptions = ?, notes = ?,
+            version = version + 1
         WHERE id = ?
     `, track.TrackNumber, track.Positions, track.Length, track.Type, track.Rule, track.Exceptions, track.Notes, track.ID)
+	if err != nil {
+		return err
+	}
 
-	return err
+	if err := touchStation(tx, track.StationID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // DeleteTrack removes a track from the database.
-func DeleteTrack(db *sql.DB, id int) error {
-	_, err := db.Exec(`DELETE FROM tracks WHERE id = ?`, id)
-	return err
+// A non-zero expectedVersion must match the stored version.
+func DeleteTrack(db *sql.DB, id int, expectedVersion int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockTrackVersion(tx, id, expectedVersion); err != nil {
+		return err
+	}
+
+	var stationID int
+	if err := tx.QueryRow(`SELECT station_id FROM tracks WHERE id = ?`, id).Scan(&stationID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM tracks WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	if err := touchStation(tx, stationID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockTrackVersion locks a track row until the transaction ends
+// and checks the client's version against it.
+// Returns sql.ErrNoRows if the track does not exist.
+func lockTrackVersion(tx *sql.Tx, id int, expectedVersion int64) error {
+	var current int64
+	err := tx.QueryRow(`SELECT version FROM tracks WHERE id = ? FOR UPDATE`, id).Scan(&current)
+	if err != nil {
+		return err
+	}
+	return checkVersion("track", strconv.Itoa(id), expectedVersion, current)
 }
@@ -0,0 +1,268 @@
+// backend/internal/models/station_shift.go
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"yopta-template/internal/timeparse"
+)
+
+// StationShift defines a shift window of a station/depot.
+// Times are local (Vilnius) wall-clock times; a shift that crosses midnight
+// ends on the day after it starts.
+type StationShift struct {
+	ID              int       `json:"id"`               // Unique identifier
+	StationID       int       `json:"station_id"`       // Foreign key to station
+	Name            string    `json:"name"`             // Shift name used in queries (unique per station)
+	StartTime       string    `json:"start_time"`       // Shift start, HH:MM
+	EndTime         string    `json:"end_time"`         // Shift end, HH:MM
+	CrossesMidnight bool      `json:"crosses_midnight"` // Whether the shift ends on the next day
+	EarlyMinutes    int       `json:"early_minutes"`    // Spill-over window before the start
+	LateMinutes     int       `json:"late_minutes"`     // Spill-over window after the end
+	SortOrder       int       `json:"sort_order"`       // Display order
+	CreatedAt       time.Time `json:"created_at"`       // When the record was created
+	UpdatedAt       time.Time `json:"updated_at"`       // When the record was last updated
+}
+
+// maxSpillMinutes limits the early and late spill-over windows (12 hours).
+const maxSpillMinutes = 12 * 60
+
+// DefaultStationShifts are created for every new station.
+// They match the shifts the Klasika page used before shifts were configurable.
+var DefaultStationShifts = []StationShift{
+	{Name: "day", StartTime: "06:00", EndTime: "20:00", EarlyMinutes: 120, LateMinutes: 120, SortOrder: 1},
+	{Name: "night", StartTime: "18:00", EndTime: "08:00", CrossesMidnight: true, EarlyMinutes: 120, LateMinutes: 120, SortOrder: 2},
+	{Name: "all", StartTime: "00:00", EndTime: "00:00", CrossesMidnight: true, EarlyMinutes: 120, LateMinutes: 120, SortOrder: 3},
+}
+
+// stationShiftColumns lists the columns read by scanStationShift, in order.
+const stationShiftColumns = `
+	id, station_id, name, TIME_FORMAT(start_time, '%H:%i'), TIME_FORMAT(end_time, '%H:%i'),
+	crosses_midnight, early_minutes, late_minutes, sort_order, created_at, updated_at
+`
+
+// scanStationShift reads one station_shifts row selected with stationShiftColumns.
+func scanStationShift(row interface{ Scan(...any) error }) (StationShift, error) {
+	var shift StationShift
+	err := row.Scan(
+		&shift.ID, &shift.StationID, &shift.Name, &shift.StartTime, &shift.EndTime,
+		&shift.CrossesMidnight, &shift.EarlyMinutes, &shift.LateMinutes, &shift.SortOrder,
+		&shift.CreatedAt, &shift.UpdatedAt,
+	)
+	return shift, err
+}
+
+// ValidateStationShift checks a shift definition and normalizes its times to HH:MM.
+// The crossing flag must agree with the times: a shift crosses midnight
+// exactly when it ends at or before its start time.
+func ValidateStationShift(shift *StationShift) error {
+	shift.Name = strings.TrimSpace(shift.Name)
+	if shift.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(shift.Name) > 50 {
+		return fmt.Errorf("name is longer than 50 characters")
+	}
+
+	start, err := parseShiftClock(shift.StartTime)
+	if err != nil {
+		return fmt.Errorf("start time: %w", err)
+	}
+	end, err := parseShiftClock(shift.EndTime)
+	if err != nil {
+		return fmt.Errorf("end time: %w", err)
+	}
+	shift.StartTime = fmt.Sprintf("%02d:%02d", start.Hour, start.Minute)
+	shift.EndTime = fmt.Sprintf("%02d:%02d", end.Hour, end.Minute)
+
+	endsNextDay := shift.EndTime <= shift.StartTime
+	if shift.CrossesMidnight != endsNextDay {
+		if endsNextDay {
+			return fmt.Errorf("a shift ending at or before its start time must cross midnight")
+		}
+		return fmt.Errorf("a shift ending after its start time cannot cross midnight")
+	}
+
+	if shift.EarlyMinutes < 0 || shift.EarlyMinutes > maxSpillMinutes {
+		return fmt.Errorf("early minutes must be between 0 and %d", maxSpillMinutes)
+	}
+	if shift.LateMinutes < 0 || shift.LateMinutes > maxSpillMinutes {
+		return fmt.Errorf("late minutes must be between 0 and %d", maxSpillMinutes)
+	}
+
+	return nil
+}
+
+// parseShiftClock parses a shift time; day offsets are not allowed.
+func parseShiftClock(value string) (timeparse.Clock, error) {
+	clock, err := timeparse.ParseClock(value)
+	if err != nil {
+		return clock, err
+	}
+	if clock.DayOffset != 0 {
+		return clock, fmt.Errorf("day offsets are not allowed, use crosses_midnight")
+	}
+	return clock, nil
+}
+
+// Window returns the start and end of the shift that starts on the given day.
+// The day's date is read in Vilnius time.
+func (s StationShift) Window(day time.Time) (time.Time, time.Time, error) {
+	start, err := parseShiftClock(s.StartTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := parseShiftClock(s.EndTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if s.CrossesMidnight {
+		end.DayOffset = 1
+	}
+	return timeparse.At(day, start), timeparse.At(day, end), nil
+}
+
+// GetShiftsByStationID retrieves all shifts of a station in display order.
+func GetShiftsByStationID(db *sql.DB, stationID int) ([]StationShift, error) {
+	rows, err := db.Query(
+		"SELECT "+stationShiftColumns+" FROM station_shifts WHERE station_id = ? ORDER BY sort_order, name",
+		stationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shifts := []StationShift{}
+	for rows.Next() {
+		shift, err := scanStationShift(rows)
+		if err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, shift)
+	}
+
+	return shifts, rows.Err()
+}
+
+// GetShiftByID retrieves a single shift.
+// Returns sql.ErrNoRows if the shift does not exist.
+func GetShiftByID(db *sql.DB, id int) (StationShift, error) {
+	return scanStationShift(db.QueryRow(
+		"SELECT "+stationShiftColumns+" FROM station_shifts WHERE id = ?", id,
+	))
+}
+
+// GetShiftByName retrieves a shift by station code and shift name.
+// Returns sql.ErrNoRows if the station has no such shift.
+func GetShiftByName(db *sql.DB, stationCode, name string) (StationShift, error) {
+	return scanStationShift(db.QueryRow(
+		"SELECT "+stationShiftColumns+" FROM station_shifts"+
+			" WHERE station_id = (SELECT id FROM stations WHERE code = ? AND deleted_at IS NULL) AND name = ?",
+		stationCode, name,
+	))
+}
+
+// CreateShift adds a shift to a station and returns its ID.
+// The station's version is incremented, so its ETag covers its shifts.
+func CreateShift(db *sql.DB, shift StationShift) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	id, err := insertShift(tx, shift)
+	if err != nil {
+		return 0, err
+	}
+	if err := touchStation(tx, shift.StationID); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// insertShift stores a new shift within a transaction.
+func insertShift(tx *sql.Tx, shift StationShift) (int, error) {
+	result, err := tx.Exec(`
+		INSERT INTO station_shifts (
+			station_id, name, start_time, end_time, crosses_midnight,
+			early_minutes, late_minutes, sort_order
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		shift.StationID, shift.Name, shift.StartTime, shift.EndTime, shift.CrossesMidnight,
+		shift.EarlyMinutes, shift.LateMinutes, shift.SortOrder,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// UpdateShift changes an existing shift.
+// Returns sql.ErrNoRows if the shift does not exist.
+func UpdateShift(db *sql.DB, shift StationShift) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var stationID int
+	err = tx.QueryRow(`SELECT station_id FROM station_shifts WHERE id = ? FOR UPDATE`, shift.ID).Scan(&stationID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE station_shifts
+		SET name = ?, start_time = ?, end_time = ?, crosses_midnight = ?,
+			early_minutes = ?, late_minutes = ?, sort_order = ?
+		WHERE id = ?
+	`,
+		shift.Name, shift.StartTime, shift.EndTime, shift.CrossesMidnight,
+		shift.EarlyMinutes, shift.LateMinutes, shift.SortOrder, shift.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if err := touchStation(tx, stationID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteShift removes a shift.
+// Returns sql.ErrNoRows if the shift does not exist.
+func DeleteShift(db *sql.DB, id int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var stationID int
+	err = tx.QueryRow(`SELECT station_id FROM station_shifts WHERE id = ? FOR UPDATE`, id).Scan(&stationID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM station_shifts WHERE id = ?`, id); err != nil {
+		return err
+	}
+	if err := touchStation(tx, stationID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
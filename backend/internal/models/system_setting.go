@@ -0,0 +1,36 @@
+// backend/internal/models/system_setting.go
+package models
+
+import (
+	"database/sql"
+	"strconv"
+)
+
+// GetSystemSetting returns the value of a system setting.
+// Returns sql.ErrNoRows if the setting does not exist.
+func GetSystemSetting(db *sql.DB, key string) (string, error) {
+	var value sql.NullString
+	err := db.QueryRow("SELECT setting_value FROM system_settings WHERE setting_key = ?", key).Scan(&value)
+	if err != nil {
+		return "", err
+	}
+	return value.String, nil
+}
+
+// GetSystemSettingInt returns a numeric system setting, or def if the
+// setting does not exist or is not a number.
+func GetSystemSettingInt(db *sql.DB, key string, def int) (int, error) {
+	value, err := GetSystemSetting(db, key)
+	if err == sql.ErrNoRows {
+		return def, nil
+	}
+	if err != nil {
+		return def, err
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return def, nil
+	}
+	return n, nil
+}
@@ -0,0 +1,233 @@
+// backend/internal/models/timetable_generate.go
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"yopta-template/internal/timeparse"
+)
+
+// Reasons why the generator leaves a record alone
+const (
+	GenerateSkipEdited       = "edited"        // The record was changed after it was generated
+	GenerateSkipDeleted      = "deleted"       // The record is in the trash
+	GenerateSkipNotGenerated = "not_generated" // The record was imported or generated from another template
+	GenerateSkipDuplicate    = "duplicate"     // Another template generates the same record
+)
+
+// generatedSource marks raw data written by the generator.
+const generatedSource = "timetable_template"
+
+// generatedRawData is the raw data of a generated record. Besides the record
+// as generated it holds the import fields TrainScheduleKey is derived from,
+// so duplicate detection treats generated and imported rows alike.
+type generatedRawData struct {
+	Source      string        `json:"source"`
+	TemplateID  int           `json:"templateId"`
+	Working     string        `json:"vehicleWorkingDesignation"`
+	Date        string        `json:"date"`
+	TrainNumber string        `json:"departureTrainNumber"`
+	Record      TrainSchedule `json:"record"` // Record as generated, to tell later edits apart
+}
+
+// TimetableGenerationSkip is a record the generator left untouched.
+type TimetableGenerationSkip struct {
+	ID         string `json:"id"`          // Record ID
+	Date       string `json:"date"`        // Service day, YYYY-MM-DD
+	TemplateID int    `json:"template_id"` // Template that would have generated it
+	Reason     string `json:"reason"`      // One of the GenerateSkip* reasons
+}
+
+// TimetableGeneration summarizes a generation run.
+type TimetableGeneration struct {
+	From      string                    `json:"from"`      // First service day, YYYY-MM-DD
+	To        string                    `json:"to"`        // Last service day, YYYY-MM-DD
+	DryRun    bool                      `json:"dry_run"`   // Whether the records were only planned, not saved
+	Created   int                       `json:"created"`   // New records
+	Updated   int                       `json:"updated"`   // Generated records brought up to date with their template
+	Unchanged int                       `json:"unchanged"` // Generated records already up to date
+	Skipped   []TimetableGenerationSkip `json:"skipped"`   // Records left untouched
+	Failed    []TrainScheduleRowError   `json:"failed"`    // Records the save rejected
+}
+
+// GenerateTrainSchedules materializes the records of timetable templates
+// for every service day from first to last (inclusive, Vilnius dates).
+//
+// Missing records are created. Records generated earlier are updated when
+// their template changed, but only while nobody edited them: a record whose
+// tracked fields differ from what was generated, a record in the trash and
+// a record that was imported are left untouched and listed in Skipped.
+// Records are saved through SaveTrainSchedules, so ownership rules apply
+// and every change is written to the revision history.
+//
+// Parameters:
+//   - db: Database connection
+//   - templates: Templates to generate (inactive ones are ignored)
+//   - first, last: First and last service day
+//   - editor: User the records are generated for
+//   - dryRun: Only report what would be written
+//
+// Returns:
+//   - Summary of created, updated, unchanged, skipped and failed records
+//   - Error if the database operation fails
+func GenerateTrainSchedules(
+	db *sql.DB,
+	templates []TimetableTemplate,
+	first, last time.Time,
+	editor Editor,
+	dryRun bool,
+) (*TimetableGeneration, error) {
+	first, last = first.In(timeparse.Vilnius), last.In(timeparse.Vilnius)
+	result := &TimetableGeneration{
+		From:    first.Format("2006-01-02"),
+		To:      last.Format("2006-01-02"),
+		DryRun:  dryRun,
+		Skipped: []TimetableGenerationSkip{},
+		Failed:  []TrainScheduleRowError{},
+	}
+
+	type planned struct {
+		templateID int
+		date       string
+		record     TrainSchedule
+	}
+	var plan []planned
+	generatedBy := make(map[string]int)
+	for day := first; !day.After(last); day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, timeparse.Vilnius) {
+		date := day.Format("2006-01-02")
+		for _, t := range templates {
+			if !t.IsActive || !t.RunsOn(day) {
+				continue
+			}
+			record, err := t.Record(day)
+			if err != nil {
+				return nil, err
+			}
+			if _, taken := generatedBy[record.ID]; taken {
+				result.Skipped = append(result.Skipped, TimetableGenerationSkip{
+					ID: record.ID, Date: date, TemplateID: t.ID, Reason: GenerateSkipDuplicate,
+				})
+				continue
+			}
+			generatedBy[record.ID] = t.ID
+
+			raw, err := json.Marshal(generatedRawData{
+				Source:      generatedSource,
+				TemplateID:  t.ID,
+				Working:     t.Working,
+				Date:        date,
+				TrainNumber: t.TrainNumberDeparture,
+				Record:      record,
+			})
+			if err != nil {
+				return nil, err
+			}
+			record.RawData = string(raw)
+			plan = append(plan, planned{templateID: t.ID, date: date, record: record})
+		}
+	}
+
+	ids := make([]string, len(plan))
+	for i, p := range plan {
+		ids[i] = p.record.ID
+	}
+	stored, err := getTrainSchedulesWithTrash(db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var toSave []TrainSchedule
+	for _, p := range plan {
+		record := p.record
+		existing := stored[record.ID]
+		if existing == nil {
+			toSave = append(toSave, record)
+			result.Created++
+			continue
+		}
+
+		skip := func(reason string) {
+			result.Skipped = append(result.Skipped, TimetableGenerationSkip{
+				ID: record.ID, Date: p.date, TemplateID: p.templateID, Reason: reason,
+			})
+		}
+		if existing.DeletedAt != nil {
+			skip(GenerateSkipDeleted)
+			continue
+		}
+		var raw generatedRawData
+		if json.Unmarshal([]byte(existing.RawData), &raw) != nil ||
+			raw.Source != generatedSource || raw.TemplateID != p.templateID {
+			skip(GenerateSkipNotGenerated)
+			continue
+		}
+		if len(trainScheduleChanges(&raw.Record, existing)) > 0 {
+			skip(GenerateSkipEdited)
+			continue
+		}
+
+		if len(trainScheduleChanges(existing, &record)) == 0 && record.RawData == existing.RawData {
+			result.Unchanged++
+			continue
+		}
+		record.Version = existing.Version
+		toSave = append(toSave, record)
+		result.Updated++
+	}
+
+	if dryRun || len(toSave) == 0 {
+		return result, nil
+	}
+
+	saved, err := SaveTrainSchedules(db, toSave, editor, TrainScheduleSaveOptions{Imported: true})
+	if err != nil {
+		return nil, err
+	}
+	result.Created = saved.Created
+	result.Updated = saved.Updated
+	result.Unchanged += saved.Unchanged
+	result.Failed = saved.Failed
+
+	return result, nil
+}
+
+// getTrainSchedulesWithTrash reads the records with the given IDs, including
+// those in the trash. Missing IDs are absent from the map.
+func getTrainSchedulesWithTrash(db *sql.DB, ids []string) (map[string]*TrainSchedule, error) {
+	found := make(map[string]*TrainSchedule, len(ids))
+
+	for start := 0; start < len(ids); start += defaultSaveChunkSize {
+		chunk := ids[start:min(start+defaultSaveChunkSize, len(ids))]
+		args := make([]any, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+
+		rows, err := db.Query(
+			"SELECT "+trainScheduleSelectColumns+" FROM train_schedules WHERE id IN ("+
+				strings.TrimSuffix(strings.Repeat("?, ", len(chunk)), ", ")+")",
+			args...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			schedule, err := scanTrainSchedule(rows)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			found[schedule.ID] = &schedule
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return found, nil
+}
@@ -0,0 +1,331 @@
+// backend/internal/models/timetable_template.go
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"yopta-template/internal/timeparse"
+)
+
+// Weekday bits of TimetableTemplate.DaysOfWeek
+const (
+	DayMonday    = 1 << iota // 1
+	DayTuesday               // 2
+	DayWednesday             // 4
+	DayThursday              // 8
+	DayFriday                // 16
+	DaySaturday              // 32
+	DaySunday                // 64
+
+	Weekdays = DayMonday | DayTuesday | DayWednesday | DayThursday | DayFriday // 31
+	AllDays  = Weekdays | DaySaturday | DaySunday                              // 127
+)
+
+// TimetableTemplate describes a train that repeats on the same weekdays
+// within a timetable period. Times are local (Vilnius) wall-clock times of
+// the service day; an arrival after midnight has a day offset.
+type TimetableTemplate struct {
+	ID                   int       `json:"id"`
+	Name                 string    `json:"name"`                   // Label shown to planners
+	Working              string    `json:"working"`                // Vehicle working designation, part of the record IDs
+	TrainNumberDeparture string    `json:"train_number_departure"` // Departure train number
+	TrainNumberArrival   string    `json:"train_number_arrival"`   // Arrival train number
+	VehicleName          string    `json:"vehicle_name"`           // Vehicle, if the same one runs every day
+	StartingLocation     string    `json:"starting_location"`      // Departure depot code
+	EndLocation          string    `json:"end_location"`           // Arrival depot code
+	DepartureTime        string    `json:"departure_time"`         // Departure, HH:MM (empty if the train only arrives)
+	ArrivalTime          string    `json:"arrival_time"`           // Arrival, HH:MM (empty if the train only departs)
+	ArrivalDayOffset     int       `json:"arrival_day_offset"`     // Days between the service day and the arrival
+	DaysOfWeek           int       `json:"days_of_week"`           // Weekday mask, see DayMonday ... DaySunday
+	ValidFrom            string    `json:"valid_from"`             // First service day, YYYY-MM-DD
+	ValidTo              string    `json:"valid_to"`               // Last service day, YYYY-MM-DD
+	StartingTrack        string    `json:"starting_track"`         // Default departure track
+	TargetTrack          string    `json:"target_track"`           // Default arrival track
+	Notes                string    `json:"notes"`                  // Copied to the generated records
+	IsActive             bool      `json:"is_active"`              // Inactive templates are not generated
+	UserID               *int      `json:"user_id"`                // User who created the template
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// ValidateTimetableTemplate checks a template and normalizes its times to
+// HH:MM and its dates to YYYY-MM-DD.
+func ValidateTimetableTemplate(t *TimetableTemplate) error {
+	t.Name = strings.TrimSpace(t.Name)
+	t.Working = strings.TrimSpace(t.Working)
+	if NormalizeKeyPart(t.Working) == "" {
+		return fmt.Errorf("working is required")
+	}
+	for _, field := range []struct {
+		name  string
+		value *string
+		size  int
+	}{
+		{"name", &t.Name, 100},
+		{"working", &t.Working, 50},
+		{"train_number_departure", &t.TrainNumberDeparture, 50},
+		{"train_number_arrival", &t.TrainNumberArrival, 50},
+		{"vehicle_name", &t.VehicleName, 100},
+		{"starting_location", &t.StartingLocation, 50},
+		{"end_location", &t.EndLocation, 50},
+		{"starting_track", &t.StartingTrack, 50},
+		{"target_track", &t.TargetTrack, 50},
+	} {
+		*field.value = strings.TrimSpace(*field.value)
+		if len([]rune(*field.value)) > field.size {
+			return fmt.Errorf("%s is longer than %d characters", field.name, field.size)
+		}
+	}
+
+	if t.DepartureTime == "" && t.ArrivalTime == "" {
+		return fmt.Errorf("a departure or an arrival time is required")
+	}
+	if t.DepartureTime != "" {
+		if t.StartingLocation == "" {
+			return fmt.Errorf("a departure needs a starting location")
+		}
+		clock, err := parseShiftClock(t.DepartureTime)
+		if err != nil {
+			return fmt.Errorf("departure time: %w", err)
+		}
+		t.DepartureTime = fmt.Sprintf("%02d:%02d", clock.Hour, clock.Minute)
+	}
+	if t.ArrivalTime != "" {
+		if t.EndLocation == "" {
+			return fmt.Errorf("an arrival needs an end location")
+		}
+		clock, err := parseShiftClock(t.ArrivalTime)
+		if err != nil {
+			return fmt.Errorf("arrival time: %w", err)
+		}
+		t.ArrivalTime = fmt.Sprintf("%02d:%02d", clock.Hour, clock.Minute)
+	}
+	if t.ArrivalDayOffset < 0 || t.ArrivalDayOffset > 2 {
+		return fmt.Errorf("arrival day offset must be between 0 and 2")
+	}
+	if t.ArrivalTime == "" && t.ArrivalDayOffset != 0 {
+		return fmt.Errorf("arrival day offset needs an arrival time")
+	}
+	if t.DepartureTime != "" && t.ArrivalTime != "" && t.ArrivalDayOffset == 0 && t.ArrivalTime <= t.DepartureTime {
+		return fmt.Errorf("the arrival must be after the departure, use arrival_day_offset for arrivals after midnight")
+	}
+
+	if t.DaysOfWeek <= 0 || t.DaysOfWeek > AllDays {
+		return fmt.Errorf("days of week must be a mask between 1 and %d", AllDays)
+	}
+
+	from, err := timeparse.ParseDate(t.ValidFrom)
+	if err != nil {
+		return fmt.Errorf("valid from: %w", err)
+	}
+	to, err := timeparse.ParseDate(t.ValidTo)
+	if err != nil {
+		return fmt.Errorf("valid to: %w", err)
+	}
+	if to.Before(from) {
+		return fmt.Errorf("the validity must end on or after its first day")
+	}
+	t.ValidFrom = from.Format("2006-01-02")
+	t.ValidTo = to.Format("2006-01-02")
+
+	return nil
+}
+
+// RunsOn reports whether the template's train runs on the given service day.
+// The day's date is read in Vilnius time.
+func (t TimetableTemplate) RunsOn(day time.Time) bool {
+	date := day.In(timeparse.Vilnius).Format("2006-01-02")
+	if date < t.ValidFrom || date > t.ValidTo {
+		return false
+	}
+	// time.Weekday counts from Sunday, the mask from Monday
+	bit := (int(day.In(timeparse.Vilnius).Weekday()) + 6) % 7
+	return t.DaysOfWeek&(1<<bit) != 0
+}
+
+// Record builds the train schedule record of the template's train on the
+// given service day. The ID is derived like the IDs of imported rows (see
+// TrainScheduleKey), so an import of the same train overwrites the record
+// instead of duplicating it.
+func (t TimetableTemplate) Record(day time.Time) (TrainSchedule, error) {
+	date := day.In(timeparse.Vilnius).Format("2006-01-02")
+	id, err := TrainScheduleKey(t.Working, date, t.TrainNumberDeparture)
+	if err != nil {
+		return TrainSchedule{}, err
+	}
+
+	record := TrainSchedule{
+		ID:                   id,
+		TrainNumberDeparture: t.TrainNumberDeparture,
+		TrainNumberArrival:   t.TrainNumberArrival,
+		VehicleName:          t.VehicleName,
+		StartingLocation:     t.StartingLocation,
+		EndLocation:          t.EndLocation,
+		StartingTrack:        t.StartingTrack,
+		TargetTrack:          t.TargetTrack,
+		Notes:                t.Notes,
+	}
+	if t.DepartureTime != "" {
+		record.DepartureDateTime, err = timeparse.ParseDateTime(date, t.DepartureTime)
+		if err != nil {
+			return TrainSchedule{}, err
+		}
+	}
+	if t.ArrivalTime != "" {
+		record.ArrivalDateTime, err = timeparse.ParseDateTime(date, fmt.Sprintf("%s (+%d)", t.ArrivalTime, t.ArrivalDayOffset))
+		if err != nil {
+			return TrainSchedule{}, err
+		}
+	}
+
+	return record, nil
+}
+
+// timetableTemplateColumns lists the columns read by scanTimetableTemplate, in order.
+const timetableTemplateColumns = `
+	id, name, working, train_number_departure, train_number_arrival, vehicle_name,
+	starting_location, end_location,
+	COALESCE(TIME_FORMAT(departure_time, '%H:%i'), ''), COALESCE(TIME_FORMAT(arrival_time, '%H:%i'), ''),
+	arrival_day_offset, days_of_week,
+	DATE_FORMAT(valid_from, '%Y-%m-%d'), DATE_FORMAT(valid_to, '%Y-%m-%d'),
+	starting_track, target_track, COALESCE(notes, ''), is_active, user_id, created_at, updated_at
+`
+
+// scanTimetableTemplate reads one timetable_templates row selected with timetableTemplateColumns.
+func scanTimetableTemplate(row interface{ Scan(...any) error }) (TimetableTemplate, error) {
+	var t TimetableTemplate
+	err := row.Scan(
+		&t.ID, &t.Name, &t.Working, &t.TrainNumberDeparture, &t.TrainNumberArrival, &t.VehicleName,
+		&t.StartingLocation, &t.EndLocation,
+		&t.DepartureTime, &t.ArrivalTime,
+		&t.ArrivalDayOffset, &t.DaysOfWeek,
+		&t.ValidFrom, &t.ValidTo,
+		&t.StartingTrack, &t.TargetTrack, &t.Notes, &t.IsActive, &t.UserID, &t.CreatedAt, &t.UpdatedAt,
+	)
+	return t, err
+}
+
+// GetTimetableTemplates retrieves all templates ordered by validity and departure.
+func GetTimetableTemplates(db *sql.DB) ([]TimetableTemplate, error) {
+	return queryTimetableTemplates(db, "")
+}
+
+// GetTimetableTemplatesFor retrieves the active templates valid on any day
+// between from and to (YYYY-MM-DD, inclusive).
+func GetTimetableTemplatesFor(db *sql.DB, from, to string) ([]TimetableTemplate, error) {
+	return queryTimetableTemplates(db, "WHERE is_active = TRUE AND valid_from <= ? AND valid_to >= ?", to, from)
+}
+
+// queryTimetableTemplates runs the template listing query with an optional WHERE clause.
+func queryTimetableTemplates(db *sql.DB, where string, args ...any) ([]TimetableTemplate, error) {
+	rows, err := db.Query(
+		"SELECT "+timetableTemplateColumns+" FROM timetable_templates "+where+
+			" ORDER BY valid_from, departure_time, arrival_time, id",
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query timetable templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []TimetableTemplate{}
+	for rows.Next() {
+		t, err := scanTimetableTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan timetable template: %w", err)
+		}
+		templates = append(templates, t)
+	}
+
+	return templates, rows.Err()
+}
+
+// GetTimetableTemplateByID retrieves a single template.
+// Returns sql.ErrNoRows if it does not exist.
+func GetTimetableTemplateByID(db *sql.DB, id int) (*TimetableTemplate, error) {
+	t, err := scanTimetableTemplate(db.QueryRow(
+		"SELECT "+timetableTemplateColumns+" FROM timetable_templates WHERE id = ?", id,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// nullableClock stores an empty HH:MM time as NULL.
+func nullableClock(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// CreateTimetableTemplate stores a new template.
+// The template must pass ValidateTimetableTemplate first.
+func CreateTimetableTemplate(db *sql.DB, t *TimetableTemplate) error {
+	result, err := db.Exec(`
+		INSERT INTO timetable_templates (
+			name, working, train_number_departure, train_number_arrival, vehicle_name,
+			starting_location, end_location, departure_time, arrival_time, arrival_day_offset,
+			days_of_week, valid_from, valid_to, starting_track, target_track, notes, is_active, user_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		t.Name, t.Working, t.TrainNumberDeparture, t.TrainNumberArrival, t.VehicleName,
+		t.StartingLocation, t.EndLocation, nullableClock(t.DepartureTime), nullableClock(t.ArrivalTime), t.ArrivalDayOffset,
+		t.DaysOfWeek, t.ValidFrom, t.ValidTo, t.StartingTrack, t.TargetTrack, t.Notes, t.IsActive, t.UserID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create timetable template: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	t.ID = int(id)
+	return nil
+}
+
+// UpdateTimetableTemplate changes a template. Records generated earlier are
+// brought up to date by the next generation run.
+// The template must pass ValidateTimetableTemplate first. Returns sql.ErrNoRows if it does not exist.
+func UpdateTimetableTemplate(db *sql.DB, id int, t *TimetableTemplate) error {
+	if _, err := GetTimetableTemplateByID(db, id); err != nil {
+		return err
+	}
+
+	_, err := db.Exec(`
+		UPDATE timetable_templates
+		SET
+			name = ?, working = ?, train_number_departure = ?, train_number_arrival = ?, vehicle_name = ?,
+			starting_location = ?, end_location = ?, departure_time = ?, arrival_time = ?, arrival_day_offset = ?,
+			days_of_week = ?, valid_from = ?, valid_to = ?, starting_track = ?, target_track = ?, notes = ?,
+			is_active = ?
+		WHERE id = ?
+	`,
+		t.Name, t.Working, t.TrainNumberDeparture, t.TrainNumberArrival, t.VehicleName,
+		t.StartingLocation, t.EndLocation, nullableClock(t.DepartureTime), nullableClock(t.ArrivalTime), t.ArrivalDayOffset,
+		t.DaysOfWeek, t.ValidFrom, t.ValidTo, t.StartingTrack, t.TargetTrack, t.Notes,
+		t.IsActive, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update timetable template: %w", err)
+	}
+
+	t.ID = id
+	return nil
+}
+
+// DeleteTimetableTemplate removes a template. Records it generated stay.
+// Returns sql.ErrNoRows if it does not exist.
+func DeleteTimetableTemplate(db *sql.DB, id int) error {
+	result, err := db.Exec("DELETE FROM timetable_templates WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete timetable template: %w", err)
+	}
+	return requireAffected(result)
+}
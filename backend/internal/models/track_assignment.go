@@ -0,0 +1,457 @@
+// backend/internal/models/track_assignment.go
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"yopta-template/internal/parking"
+	"yopta-template/internal/timeparse"
+)
+
+// Track assignment errors
+var (
+	ErrAssignmentTrack    = errors.New("track not found")
+	ErrAssignmentPosition = errors.New("the track has no such position")
+	ErrAssignmentSchedule = errors.New("the record neither arrives at nor departs from the track's station")
+)
+
+// TrackAssignment parks the vehicle of a train schedule record on a position
+// of a depot track for a time.
+type TrackAssignment struct {
+	ID          int       `json:"id"`
+	TrackID     int       `json:"track_id"`     // Track the vehicle stands on
+	StationID   int       `json:"station_id"`   // Station of the track (read-only)
+	StationCode string    `json:"station_code"` // Depot code of the station (read-only)
+	TrackNumber string    `json:"track_number"` // Number of the track (read-only)
+	Position    int       `json:"position"`     // Position on the track, from 1
+	ScheduleID  string    `json:"schedule_id"`  // Record whose vehicle takes the position
+	VehicleName string    `json:"vehicle_name"` // Vehicle of the record (read-only)
+	StartsAt    time.Time `json:"starts_at"`    // When the vehicle takes the position
+	EndsAt      time.Time `json:"ends_at"`      // When the vehicle leaves the position (exclusive)
+	Notes       string    `json:"notes"`        // Note for the dispatchers
+	Locked      bool      `json:"locked"`       // Placed by hand; the parking planner keeps it
+	Version     int64     `json:"version"`      // Incremented on every change
+	UserID      *int      `json:"user_id"`      // User who last changed the assignment
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Parking returns the assignment as the parking checks see it.
+// Conflicts refer to it by its ID.
+func (a TrackAssignment) Parking() parking.Assignment {
+	return parking.Assignment{
+		ID:       strconv.Itoa(a.ID),
+		Vehicle:  a.VehicleName,
+		Track:    a.TrackNumber,
+		Position: a.Position,
+		Start:    a.StartsAt,
+		End:      a.EndsAt,
+	}
+}
+
+// ValidateTrackAssignment checks an assignment before it is saved.
+// The track, its positions and the record are checked when saving.
+func ValidateTrackAssignment(a *TrackAssignment) error {
+	a.ScheduleID = strings.TrimSpace(a.ScheduleID)
+	a.Notes = strings.TrimSpace(a.Notes)
+
+	if a.TrackID <= 0 {
+		return fmt.Errorf("track_id is required")
+	}
+	if a.Position < 1 {
+		return fmt.Errorf("position must be 1 or more")
+	}
+	if a.ScheduleID == "" {
+		return fmt.Errorf("schedule_id is required")
+	}
+	if a.StartsAt.IsZero() || !a.EndsAt.After(a.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	if len(a.Notes) > 255 {
+		return fmt.Errorf("notes must be at most 255 characters")
+	}
+	return nil
+}
+
+// trackAssignmentColumns lists the columns read by scanTrackAssignment, in order.
+const trackAssignmentColumns = `
+	a.id, a.track_id, t.station_id, s.code, t.track_number, a.position, a.schedule_id, ts.vehicle_name,
+	a.starts_at, a.ends_at, a.notes, a.locked, a.version, a.user_id, a.created_at, a.updated_at
+`
+
+// trackAssignmentTables joins an assignment with its track, station and record.
+// Assignments of records in the trash are left out: they hold no position
+// until the record is restored.
+const trackAssignmentTables = `
+	track_assignments a
+	JOIN tracks t ON t.id = a.track_id
+	JOIN stations s ON s.id = t.station_id
+	JOIN train_schedules ts ON ts.id = a.schedule_id AND ts.deleted_at IS NULL
+`
+
+// scanTrackAssignment reads one row selected with trackAssignmentColumns.
+func scanTrackAssignment(row interface{ Scan(...any) error }) (TrackAssignment, error) {
+	var a TrackAssignment
+	err := row.Scan(
+		&a.ID, &a.TrackID, &a.StationID, &a.StationCode, &a.TrackNumber, &a.Position, &a.ScheduleID, &a.VehicleName,
+		&a.StartsAt, &a.EndsAt, &a.Notes, &a.Locked, &a.Version, &a.UserID, &a.CreatedAt, &a.UpdatedAt,
+	)
+	return a, err
+}
+
+// GetTrackAssignments retrieves the assignments of a station's tracks that
+// overlap a time range, e.g. one day of the parking plan.
+//
+// Parameters:
+//   - db: Database connection
+//   - stationID: Station ID
+//   - from, to: Time range; assignments standing at any time within it are returned
+//
+// Returns:
+//   - Assignments ordered by track, position and start
+//   - Error if the database operation fails
+func GetTrackAssignments(db *sql.DB, stationID int, from, to time.Time) ([]TrackAssignment, error) {
+	rows, err := db.Query(
+		"SELECT "+trackAssignmentColumns+" FROM "+trackAssignmentTables+
+			" WHERE t.station_id = ? AND a.ends_at > ? AND a.starts_at < ?"+
+			" ORDER BY t.track_number, a.position, a.starts_at, a.id",
+		stationID, from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := []TrackAssignment{}
+	for rows.Next() {
+		a, err := scanTrackAssignment(rows)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, rows.Err()
+}
+
+// GetTrackAssignmentByID retrieves a single track assignment.
+// Returns sql.ErrNoRows if it does not exist or its record is in the trash.
+func GetTrackAssignmentByID(db *sql.DB, id int) (*TrackAssignment, error) {
+	a, err := scanTrackAssignment(db.QueryRow(
+		"SELECT "+trackAssignmentColumns+" FROM "+trackAssignmentTables+" WHERE a.id = ?", id,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// AssignmentInPublishedPlan reports whether an assignment falls on a depot
+// day whose plan is published, i.e. whether viewers may see it.
+func AssignmentInPublishedPlan(db *sql.DB, a *TrackAssignment) (bool, error) {
+	published, err := planDaysInState(db, assignmentPlanDays(a.StationCode, a), PlanPublished)
+	return len(published) > 0, err
+}
+
+// assignmentPlanDays returns the depot days an assignment falls on.
+func assignmentPlanDays(code string, a *TrackAssignment) []planDay {
+	first := a.StartsAt.In(timeparse.Vilnius).Format("2006-01-02")
+	last := a.EndsAt.Add(-time.Second).In(timeparse.Vilnius).Format("2006-01-02")
+	days := []planDay{{code, first}}
+	if last != first {
+		days = append(days, planDay{code, last})
+	}
+	return days
+}
+
+// assignmentTrack is the track of an assignment, locked while it is written.
+type assignmentTrack struct {
+	stationCode string
+	track       parking.Track
+}
+
+// lockAssignmentTrack reads a track of a station that is not in the trash and
+// locks it until the transaction ends, so writes to one track take turns.
+func lockAssignmentTrack(tx *sql.Tx, trackID int) (assignmentTrack, error) {
+	var t assignmentTrack
+	err := tx.QueryRow(`
+		SELECT s.code, t.track_number, t.positions, COALESCE(t.length, 0), t.type, t.rule, t.exceptions
+		FROM tracks t
+		JOIN stations s ON s.id = t.station_id
+		WHERE t.id = ? AND s.deleted_at IS NULL
+		FOR UPDATE
+	`, trackID).Scan(
+		&t.stationCode, &t.track.Number, &t.track.Positions, &t.track.Length, &t.track.Type, &t.track.Rule, &t.track.Exceptions,
+	)
+	if err == sql.ErrNoRows {
+		return t, ErrAssignmentTrack
+	}
+	return t, err
+}
+
+// assignmentConflicts returns the parking conflicts an assignment has with
+// the other assignments on its track.
+func assignmentConflicts(tx *sql.Tx, t assignmentTrack, a *TrackAssignment) ([]parking.Conflict, error) {
+	rows, err := tx.Query(
+		"SELECT "+trackAssignmentColumns+" FROM "+trackAssignmentTables+
+			" WHERE a.track_id = ? AND a.id <> ? AND a.ends_at > ? AND a.starts_at < ?",
+		a.TrackID, a.ID, a.StartsAt, a.EndsAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checked := *a
+	checked.TrackNumber = t.track.Number
+	assignments := []parking.Assignment{checked.Parking()}
+	for rows.Next() {
+		other, err := scanTrackAssignment(rows)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, other.Parking())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	id := strconv.Itoa(a.ID)
+	conflicts := []parking.Conflict{}
+	for _, conflict := range parking.Detect([]parking.Track{t.track}, assignments) {
+		if conflict.Assignment == id || conflict.With == id {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return conflicts, nil
+}
+
+// checkAssignmentPlan checks that the editor plans the depot of an assignment
+// and that the depot days it falls on are not published.
+func checkAssignmentPlan(tx *sql.Tx, code string, a *TrackAssignment, editor Editor) error {
+	if !editor.canChangePlan(code) {
+		return ErrPlanForbidden
+	}
+	closed, err := planDaysInState(tx, assignmentPlanDays(code, a), PlanPublished, PlanArchived)
+	if err != nil {
+		return err
+	}
+	if len(closed) > 0 {
+		return ErrPlanPublished
+	}
+	return nil
+}
+
+// checkAssignmentWrite checks that the editor may place an assignment on its
+// track: the track has the position, the record stops at the station, and
+// checkAssignmentPlan allows it.
+func checkAssignmentWrite(tx *sql.Tx, t assignmentTrack, a *TrackAssignment, editor Editor) error {
+	if t.track.Positions > 0 && a.Position > t.track.Positions {
+		return ErrAssignmentPosition
+	}
+
+	var starting, end string
+	err := tx.QueryRow(
+		"SELECT starting_location, end_location FROM train_schedules WHERE id = ? AND deleted_at IS NULL",
+		a.ScheduleID,
+	).Scan(&starting, &end)
+	if err == sql.ErrNoRows || (err == nil && starting != t.stationCode && end != t.stationCode) {
+		return ErrAssignmentSchedule
+	}
+	if err != nil {
+		return err
+	}
+
+	return checkAssignmentPlan(tx, t.stationCode, a, editor)
+}
+
+// CreateTrackAssignment parks the vehicle of a record on a track position.
+// Placements that break the rules of a track without exceptions are rejected.
+//
+// Parameters:
+//   - db: Database connection
+//   - a: Assignment to create; its ID is set on success
+//   - editor: User creating the assignment (admin or editor of the depot)
+//
+// Returns:
+//   - Parking conflicts of the new assignment, allowed as exceptions of its track
+//   - ErrAssignmentTrack, ErrAssignmentPosition or ErrAssignmentSchedule for invalid placements
+//   - ErrPlanForbidden if the user may not plan the depot
+//   - ErrPlanPublished if the depot day's plan is published or archived
+//   - *TrackConflictError if the assignment breaks the rules of its track
+//   - Error if the database operation fails
+func CreateTrackAssignment(db *sql.DB, a *TrackAssignment, editor Editor) ([]parking.Conflict, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	t, err := lockAssignmentTrack(tx, a.TrackID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAssignmentWrite(tx, t, a, editor); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO track_assignments (track_id, position, schedule_id, starts_at, ends_at, notes, locked, user_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, a.TrackID, a.Position, a.ScheduleID, a.StartsAt.UTC(), a.EndsAt.UTC(), a.Notes, a.Locked, editor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	a.ID = int(id)
+
+	conflicts, err := assignmentConflicts(tx, t, a)
+	if err != nil {
+		return nil, err
+	}
+	if blocking := blockingConflicts(nil, conflicts); len(blocking) > 0 {
+		return nil, &TrackConflictError{Conflicts: blocking}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
+
+// UpdateTrackAssignment moves an assignment to another track, position or
+// time. Conflicts the assignment already had on its track do not block the
+// change; new ones on a track without exceptions do.
+//
+// Parameters:
+//   - db: Database connection
+//   - id: Assignment ID
+//   - a: New track, position, record, interval, notes and lock
+//   - expectedVersion: Version the client edited (0 skips the check)
+//   - editor: User changing the assignment (admin or editor of the depots)
+//
+// Returns:
+//   - Parking conflicts of the assignment after the change
+//   - sql.ErrNoRows if the assignment does not exist
+//   - The errors of CreateTrackAssignment for the old and the new placement
+//   - Error if the database operation fails (*VersionConflictError if the assignment was changed meanwhile)
+func UpdateTrackAssignment(
+	db *sql.DB,
+	id int,
+	a *TrackAssignment,
+	expectedVersion int64,
+	editor Editor,
+) ([]parking.Conflict, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := lockTrackAssignment(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion("track_assignment", strconv.Itoa(id), expectedVersion, current.Version); err != nil {
+		return nil, err
+	}
+
+	// The assignment must be movable from where it is and to where it goes
+	oldTrack, err := lockAssignmentTrack(tx, current.TrackID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAssignmentPlan(tx, oldTrack.stationCode, current, editor); err != nil {
+		return nil, err
+	}
+	a.ID = id
+	newTrack := oldTrack
+	if a.TrackID != current.TrackID {
+		if newTrack, err = lockAssignmentTrack(tx, a.TrackID); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkAssignmentWrite(tx, newTrack, a, editor); err != nil {
+		return nil, err
+	}
+
+	var before []parking.Conflict
+	if a.TrackID == current.TrackID {
+		if before, err = assignmentConflicts(tx, oldTrack, current); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE track_assignments
+		SET track_id = ?, position = ?, schedule_id = ?, starts_at = ?, ends_at = ?, notes = ?, locked = ?,
+			user_id = ?, version = version + 1
+		WHERE id = ?
+	`, a.TrackID, a.Position, a.ScheduleID, a.StartsAt.UTC(), a.EndsAt.UTC(), a.Notes, a.Locked, editor.UserID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts, err := assignmentConflicts(tx, newTrack, a)
+	if err != nil {
+		return nil, err
+	}
+	if blocking := blockingConflicts(before, conflicts); len(blocking) > 0 {
+		return nil, &TrackConflictError{Conflicts: blocking}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
+
+// DeleteTrackAssignment removes an assignment from the parking plan.
+//
+// Returns:
+//   - sql.ErrNoRows if the assignment does not exist
+//   - ErrPlanForbidden if the user may not plan the depot
+//   - ErrPlanPublished if the depot day's plan is published or archived
+//   - Error if the database operation fails (*VersionConflictError if the assignment was changed meanwhile)
+func DeleteTrackAssignment(db *sql.DB, id int, expectedVersion int64, editor Editor) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := lockTrackAssignment(tx, id)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion("track_assignment", strconv.Itoa(id), expectedVersion, current.Version); err != nil {
+		return err
+	}
+
+	if err := checkAssignmentPlan(tx, current.StationCode, current, editor); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM track_assignments WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockTrackAssignment reads an assignment and locks it until the transaction ends.
+func lockTrackAssignment(tx *sql.Tx, id int) (*TrackAssignment, error) {
+	a, err := scanTrackAssignment(tx.QueryRow(
+		"SELECT "+trackAssignmentColumns+" FROM "+trackAssignmentTables+" WHERE a.id = ? FOR UPDATE", id,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
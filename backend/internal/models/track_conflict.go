@@ -0,0 +1,213 @@
+// backend/internal/models/track_conflict.go
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"yopta-template/internal/parking"
+)
+
+// trackConflictLookaround is how far before and after a record the movements
+// of its station are read, so the vehicles parked around it are found.
+const trackConflictLookaround = 24 * time.Hour
+
+// parkingFields are the record fields that decide which track position a
+// vehicle takes and for how long.
+var parkingFields = map[string]bool{
+	"vehicleName":       true,
+	"startingLocation":  true,
+	"endLocation":       true,
+	"departureDateTime": true,
+	"arrivalDateTime":   true,
+	"startingTrack":     true,
+	"targetTrack":       true,
+}
+
+// TrackConflictError is returned when a change parks a vehicle against the
+// rules of a track that allows no exceptions.
+type TrackConflictError struct {
+	Conflicts []parking.Conflict // New conflicts caused by the change
+}
+
+func (e *TrackConflictError) Error() string {
+	return fmt.Sprintf("the change causes %d track conflicts", len(e.Conflicts))
+}
+
+// ParkingTracks returns the station's tracks as the parking checks see them.
+func (s Station) ParkingTracks() []parking.Track {
+	tracks := make([]parking.Track, len(s.Tracks))
+	for i, track := range s.Tracks {
+		tracks[i] = parking.Track{
+			Number:     track.TrackNumber,
+			Positions:  track.Positions,
+			Length:     track.Length,
+			Type:       track.Type,
+			Rule:       track.Rule,
+			Exceptions: track.Exceptions,
+		}
+	}
+	return tracks
+}
+
+// stationTracksByCode reads the tracks of a station that is not in the trash.
+func stationTracksByCode(q queryer, code string) ([]parking.Track, error) {
+	rows, err := q.Query(`
+		SELECT t.track_number, t.positions, COALESCE(t.length, 0), t.type, t.rule, t.exceptions
+		FROM tracks t
+		JOIN stations s ON s.id = t.station_id
+		WHERE s.code = ? AND s.deleted_at IS NULL
+	`, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tracks []parking.Track
+	for rows.Next() {
+		var track parking.Track
+		if err := rows.Scan(&track.Number, &track.Positions, &track.Length, &track.Type, &track.Rule, &track.Exceptions); err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, track)
+	}
+	return tracks, rows.Err()
+}
+
+// stationMovements reads the arrivals (arrival true) or departures of the
+// records at a station within the time range.
+func stationMovements(q queryer, code string, arrival bool, from, to time.Time) ([]parking.Movement, error) {
+	location, at, track := "starting_location", "departure_date_time", "starting_track"
+	if arrival {
+		location, at, track = "end_location", "arrival_date_time", "target_track"
+	}
+
+	rows, err := q.Query(
+		"SELECT id, vehicle_name, "+at+", "+track+" FROM train_schedules"+
+			" WHERE deleted_at IS NULL AND "+location+" = ? AND "+at+" >= ? AND "+at+" < ?",
+		code, from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movements []parking.Movement
+	for rows.Next() {
+		var movement parking.Movement
+		if err := rows.Scan(&movement.ID, &movement.Vehicle, &movement.Time, &movement.Track); err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	}
+	return movements, rows.Err()
+}
+
+// stationAssignments pairs the arrivals and departures with a track at a
+// station within the time range into track assignments, the way the parking
+// timeline shows them.
+func stationAssignments(q queryer, code string, from, to time.Time) ([]parking.Assignment, error) {
+	arrivals, err := stationMovements(q, code, true, from, to)
+	if err != nil {
+		return nil, err
+	}
+	departures, err := stationMovements(q, code, false, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return parking.Pair(arrivals, departures, from, to), nil
+}
+
+// trackConflicts returns the parking conflicts the records take part in at
+// the stations they arrive at and depart from. Stations without tracks are
+// not checked.
+func trackConflicts(q queryer, records ...*TrainSchedule) ([]parking.Conflict, error) {
+	type timeRange struct{ from, to time.Time }
+	ranges := make(map[string]*timeRange)
+	involved := make(map[string]bool, len(records))
+
+	extend := func(code string, at *time.Time) {
+		if code == "" || at == nil {
+			return
+		}
+		r := ranges[code]
+		if r == nil {
+			ranges[code] = &timeRange{*at, *at}
+			return
+		}
+		if at.Before(r.from) {
+			r.from = *at
+		}
+		if at.After(r.to) {
+			r.to = *at
+		}
+	}
+	for _, record := range records {
+		involved[record.ID] = true
+		extend(record.StartingLocation, record.DepartureDateTime)
+		extend(record.EndLocation, record.ArrivalDateTime)
+	}
+
+	codes := make([]string, 0, len(ranges))
+	for code := range ranges {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	conflicts := []parking.Conflict{}
+	for _, code := range codes {
+		tracks, err := stationTracksByCode(q, code)
+		if err != nil {
+			return nil, err
+		}
+		if len(tracks) == 0 {
+			continue
+		}
+
+		r := ranges[code]
+		assignments, err := stationAssignments(q, code,
+			r.from.Add(-trackConflictLookaround), r.to.Add(trackConflictLookaround))
+		if err != nil {
+			return nil, err
+		}
+
+		// Keep the conflicts where a vehicle of the records is one of the sides
+		ofRecords := make(map[string]bool)
+		for _, assignment := range assignments {
+			if involved[assignment.ArrivalID] || involved[assignment.DepartureID] {
+				ofRecords[assignment.ID] = true
+			}
+		}
+		for _, conflict := range parking.Detect(tracks, assignments) {
+			if ofRecords[conflict.Assignment] || ofRecords[conflict.With] {
+				conflicts = append(conflicts, conflict)
+			}
+		}
+	}
+
+	return conflicts, nil
+}
+
+// trackConflictKey identifies a conflict independently of when it happens,
+// so a conflict that only moved in time is recognized.
+func trackConflictKey(c parking.Conflict) string {
+	return c.Type + "\x00" + c.Assignment + "\x00" + c.With
+}
+
+// blockingConflicts returns the conflicts of after that were not in before
+// and happen on tracks that allow no exceptions.
+func blockingConflicts(before, after []parking.Conflict) []parking.Conflict {
+	existing := make(map[string]bool, len(before))
+	for _, conflict := range before {
+		existing[trackConflictKey(conflict)] = true
+	}
+
+	var blocking []parking.Conflict
+	for _, conflict := range after {
+		if !conflict.Exception && !existing[trackConflictKey(conflict)] {
+			blocking = append(blocking, conflict)
+		}
+	}
+	return blocking
+}
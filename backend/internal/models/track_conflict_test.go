@@ -0,0 +1,38 @@
+// backend/internal/models/track_conflict_test.go
+package models
+
+import (
+	"testing"
+	"time"
+
+	"yopta-template/internal/parking"
+)
+
+func TestBlockingConflicts(t *testing.T) {
+	at := time.Date(2025, 7, 1, 20, 0, 0, 0, time.UTC)
+	existing := parking.Conflict{Type: parking.ConflictFIFO, Assignment: "b", With: "a", Time: at}
+	moved := existing
+	moved.Time = at.Add(time.Hour)
+	fresh := parking.Conflict{Type: parking.ConflictPosition, Assignment: "c", With: "a", Time: at}
+	warning := parking.Conflict{Type: parking.ConflictFILO, Assignment: "d", With: "a", Time: at, Exception: true}
+
+	tests := []struct {
+		name          string
+		before, after []parking.Conflict
+		want          int
+	}{
+		{"nothing new", []parking.Conflict{existing}, []parking.Conflict{existing}, 0},
+		{"existing conflict moved in time", []parking.Conflict{existing}, []parking.Conflict{moved}, 0},
+		{"new conflict", []parking.Conflict{existing}, []parking.Conflict{existing, fresh}, 1},
+		{"new conflict without a before", nil, []parking.Conflict{fresh}, 1},
+		{"new conflict allowed as exception", nil, []parking.Conflict{warning}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := blockingConflicts(tt.before, tt.after); len(got) != tt.want {
+				t.Errorf("blockingConflicts() = %+v, want %d conflicts", got, tt.want)
+			}
+		})
+	}
+}
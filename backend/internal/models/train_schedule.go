@@ -4,7 +4,13 @@ package models
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
+
+	"yopta-template/internal/parking"
 )
 
 // TrainSchedule represents a train schedule record in the system.
@@ -15,301 +21,854 @@ import (
 // with fields for both arrival and departure data, enabling tracking of locomotive
 // movements between depots.
 type TrainSchedule struct {
-	ID                   string        `json:"id"`                   // Unique identifier for the record
-	TrainNumberDeparture string        `json:"trainNumberDeparture"` // The train number for departure
-	TrainNumberArrival   string        `json:"trainNumberArrival"`   // The train number for arrival
-	VehicleName          string        `json:"vehicleName"`          // Name/model of the locomotive
-	StartingLocation     string        `json:"startingLocation"`     // Departure location/depot
-	EndLocation          string        `json:"endLocation"`          // Arrival location/depot
-	DepartureDateTime    *time.Time    `json:"departureDateTime"`    // Scheduled departure date and time
-	ArrivalDateTime      *time.Time    `json:"arrivalDateTime"`      // Scheduled arrival date and time
-	StartingTrack        string        `json:"startingTrack"`        // Track number for departure
-	TargetTrack          string        `json:"targetTrack"`          // Track number for arrival
-	Employee1Departure   string        `json:"employee1Departure"`   // Primary employee for departure (usually driver)
-	Employee1Arrival     string        `json:"employee1Arrival"`     // Primary employee for arrival
-	DutyDeparture        string        `json:"dutyDeparture"`        // Duty/task description for departure
-	DutyArrival          string        `json:"dutyArrival"`          // Duty/task description for arrival
-	Notes                string        `json:"notes"`                // Additional notes about the schedule
-	RawData              string        `json:"rawData"`              // Original raw data for reference
-	CreatedAt            time.Time     `json:"createdAt"`            // When the record was created
-	UpdatedAt            time.Time     `json:"updatedAt"`            // When the record was last updated
-	UserID               sql.NullInt64 `json:"userId"`               // ID of user who created/owns this record
+	ID                      string     `json:"id"`                      // Unique identifier for the record
+	TrainNumberDeparture    string     `json:"trainNumberDeparture"`    // The train number for departure
+	TrainNumberArrival      string     `json:"trainNumberArrival"`      // The train number for arrival
+	VehicleName             string     `json:"vehicleName"`             // Name/model of the locomotive
+	StartingLocation        string     `json:"startingLocation"`        // Departure location/depot
+	EndLocation             string     `json:"endLocation"`             // Arrival location/depot
+	DepartureDateTime       *time.Time `json:"departureDateTime"`       // Scheduled departure date and time
+	ArrivalDateTime         *time.Time `json:"arrivalDateTime"`         // Scheduled arrival date and time
+	ActualDepartureDateTime *time.Time `json:"actualDepartureDateTime"` // Actual departure date and time, recorded by dispatchers
+	ActualArrivalDateTime   *time.Time `json:"actualArrivalDateTime"`   // Actual arrival date and time, recorded by dispatchers
+	DelayReason             string     `json:"delayReason"`             // Why the train was late
+	StartingTrack           string     `json:"startingTrack"`           // Track number for departure
+	TargetTrack             string     `json:"targetTrack"`             // Track number for arrival
+	Employee1Departure      string     `json:"employee1Departure"`      // Primary employee for departure (usually driver)
+	Employee1Arrival        string     `json:"employee1Arrival"`        // Primary employee for arrival
+	DutyDeparture           string     `json:"dutyDeparture"`           // Duty/task description for departure
+	DutyArrival             string     `json:"dutyArrival"`             // Duty/task description for arrival
+	Notes                   string     `json:"notes"`                   // Additional notes about the schedule
+	RawData                 string     `json:"rawData"`                 // Original raw data for reference
+	CreatedAt               time.Time  `json:"createdAt"`               // When the record was created
+	UpdatedAt               time.Time  `json:"updatedAt"`               // When the record was last updated
+	Version                 int64      `json:"version"`                 // Incremented on every change, used for conflict detection
+	UserID                  *int       `json:"userId"`                  // ID of user who created/owns this record
+	DeletedAt               *time.Time `json:"deletedAt,omitempty"`     // When the record was moved to the trash
+	DeletedBy               *int       `json:"deletedBy,omitempty"`     // ID of user who deleted the record
+}
+
+// Editor identifies who makes a change to train schedule records.
+// It is passed to every write so ownership rules and the revision history
+// see the same user and request. Use NewEditor to load the depot roles.
+type Editor struct {
+	UserID    int               // ID of the user performing the change
+	IsAdmin   bool              // Whether the user may edit records owned by others
+	RequestID string            // Request ID stored in the revision history
+	Depots    map[string]string // Depot code -> role of the user's depot teams
+}
+
+// depotRole returns the editor's best role at the depots a record touches:
+// DepotRoleEditor, DepotRoleViewer or an empty string.
+func (e Editor) depotRole(s *TrainSchedule) string {
+	role := ""
+	for _, depot := range []string{s.StartingLocation, s.EndLocation} {
+		switch e.Depots[depot] {
+		case DepotRoleEditor:
+			return DepotRoleEditor
+		case DepotRoleViewer:
+			role = DepotRoleViewer
+		}
+	}
+	return role
 }
 
-// TrainScheduleList is a collection of train schedule records.
-// Used for API responses when returning multiple records.
-type TrainScheduleList struct {
-	Records []TrainSchedule `json:"records"` // Array of train schedule records
-	Total   int             `json:"total"`   // Total count of records
+// ErrScheduleForbidden is returned when a record would be saved outside the
+// depots the user edits.
+var ErrScheduleForbidden = errors.New("the record touches no depot the user edits")
+
+// canEdit reports whether the editor may change a record: admins may change
+// every record, other users their own records and records touching a depot
+// where they are editors.
+func (e Editor) canEdit(s *TrainSchedule) bool {
+	return e.IsAdmin || (s.UserID != nil && *s.UserID == e.UserID) || e.depotRole(s) == DepotRoleEditor
+}
+
+// canSave reports whether the editor may save updated in place of existing
+// (nil for a new record). Besides canEdit for the stored record, new records
+// and records moved to other locations must touch a depot where the editor
+// is an editor, so nobody places records in depots they do not edit.
+func (e Editor) canSave(existing, updated *TrainSchedule) bool {
+	if existing != nil {
+		if !e.canEdit(existing) {
+			return false
+		}
+		if existing.StartingLocation == updated.StartingLocation && existing.EndLocation == updated.EndLocation {
+			return true
+		}
+	}
+	return e.IsAdmin || e.depotRole(updated) == DepotRoleEditor
+}
+
+// CanView reports whether the editor may read a record: besides the records
+// they may edit, users see every record touching one of their depots.
+func (e Editor) CanView(s *TrainSchedule) bool {
+	return e.canEdit(s) || e.depotRole(s) != ""
+}
+
+// trainScheduleSelectColumns lists the columns read by scanTrainSchedule, in order.
+const trainScheduleSelectColumns = `
+	id, train_number_departure, train_number_arrival, vehicle_name,
+	starting_location, end_location, departure_date_time, arrival_date_time,
+	actual_departure_date_time, actual_arrival_date_time, delay_reason,
+	starting_track, target_track, employee1_departure, employee1_arrival,
+	duty_departure, duty_arrival, notes, raw_data, created_at, updated_at, version, user_id,
+	deleted_at, deleted_by
+`
+
+// scanTrainSchedule reads one train_schedules row selected with trainScheduleSelectColumns.
+func scanTrainSchedule(row interface{ Scan(...any) error }) (TrainSchedule, error) {
+	var schedule TrainSchedule
+	var departureTime, arrivalTime, actualDeparture, actualArrival, deletedAt sql.NullTime
+
+	if err := row.Scan(
+		&schedule.ID, &schedule.TrainNumberDeparture, &schedule.TrainNumberArrival, &schedule.VehicleName,
+		&schedule.StartingLocation, &schedule.EndLocation, &departureTime, &arrivalTime,
+		&actualDeparture, &actualArrival, &schedule.DelayReason,
+		&schedule.StartingTrack, &schedule.TargetTrack, &schedule.Employee1Departure, &schedule.Employee1Arrival,
+		&schedule.DutyDeparture, &schedule.DutyArrival, &schedule.Notes, &schedule.RawData,
+		&schedule.CreatedAt, &schedule.UpdatedAt, &schedule.Version, &schedule.UserID,
+		&deletedAt, &schedule.DeletedBy,
+	); err != nil {
+		return schedule, err
+	}
+
+	// Handle nullable datetime fields
+	if departureTime.Valid {
+		schedule.DepartureDateTime = &departureTime.Time
+	}
+	if arrivalTime.Valid {
+		schedule.ArrivalDateTime = &arrivalTime.Time
+	}
+	if actualDeparture.Valid {
+		schedule.ActualDepartureDateTime = &actualDeparture.Time
+	}
+	if actualArrival.Valid {
+		schedule.ActualArrivalDateTime = &actualArrival.Time
+	}
+	if deletedAt.Valid {
+		schedule.DeletedAt = &deletedAt.Time
+	}
+
+	return schedule, nil
 }
 
-// GetTrainSchedules retrieves all train schedule records from the database.
-// Supports filtering by user ID if provided.
+// GetTrainScheduleByID retrieves a single train schedule record.
+// Returns sql.ErrNoRows if the record does not exist or is in the trash.
+func GetTrainScheduleByID(db *sql.DB, id string) (*TrainSchedule, error) {
+	schedule, err := scanTrainSchedule(db.QueryRow(
+		"SELECT "+trainScheduleSelectColumns+" FROM train_schedules WHERE id = ? AND deleted_at IS NULL", id,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// getTrainScheduleForUpdate reads a record inside a transaction and locks it
+// until the transaction ends. Returns sql.ErrNoRows if the record does not
+// exist or is in the trash.
+func getTrainScheduleForUpdate(tx *sql.Tx, id string) (*TrainSchedule, error) {
+	schedule, err := scanTrainSchedule(tx.QueryRow(
+		"SELECT "+trainScheduleSelectColumns+" FROM train_schedules WHERE id = ? AND deleted_at IS NULL FOR UPDATE", id,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// Row failure codes reported by SaveTrainSchedules
+const (
+	SaveErrorInvalid   = "invalid"   // The record itself is not valid (e.g. missing ID, or a value an editable field rejects)
+	SaveErrorForbidden = "forbidden" // The record belongs to another user and none of the editor's depots, would leave them, or a changed field is not editable
+	SaveErrorConflict  = "conflict"  // The record was changed since the client read it
+	SaveErrorDuplicate = "duplicate" // A later record in the same request has the same ID
+	SaveErrorPublished = "published" // The record belongs to a published or archived depot plan
+	SaveErrorDatabase  = "database"  // The database rejected the record
+)
+
+// defaultSaveChunkSize is the number of records written per transaction.
+const defaultSaveChunkSize = 500
+
+// TrainScheduleSaveOptions configures SaveTrainSchedules.
+type TrainScheduleSaveOptions struct {
+	ChunkSize int                        // Records per transaction (default 500)
+	Progress  func(processed, total int) // Called after every chunk; may be nil
+	Imported  bool                       // Records come from an import or the timetable generator and may change any field
+}
+
+// TrainScheduleRowError describes a record that could not be saved.
+type TrainScheduleRowError struct {
+	Index   int            `json:"index"`             // Position of the record in the request (0-based)
+	ID      string         `json:"id"`                // Record ID
+	Code    string         `json:"code"`              // One of the SaveError* codes
+	Message string         `json:"message"`           // Human-readable explanation
+	Current *TrainSchedule `json:"current,omitempty"` // Stored record, for conflicts
+}
+
+// TrainScheduleSaveResult summarizes a bulk save.
+type TrainScheduleSaveResult struct {
+	Processed int                     `json:"processed"` // Records created, updated or already up to date
+	Created   int                     `json:"created"`   // New records
+	Updated   int                     `json:"updated"`   // Existing records with changes
+	Unchanged int                     `json:"unchanged"` // Existing records identical to the request
+	Failed    []TrainScheduleRowError `json:"failed"`    // Records that were not saved
+
+	TrackConflicts []parking.Conflict `json:"trackConflicts"` // Parking conflicts of the saved records
+}
+
+// SaveTrainSchedules saves or updates a batch of train schedule records.
+// Records are written in chunks, each in its own transaction, with one
+// multi-row upsert per chunk, so large monthly imports neither take ages
+// nor hold locks for the whole import.
+//
+// Existing records keep their original owner; only the owner, the editors of
+// a depot the record touches or an admin may overwrite them, and only depot
+// editors or an admin may create records or move them to other depots.
+// Unless the records are imported, a change of a stored record may only touch
+// the fields admins made editable, with values that pass their rules, as in
+// UpdateTrainScheduleField. Records sent with a non-zero Version are only
+// updated if the stored record still has that version. Records that break
+// these rules are reported in Failed while the rest of the batch is saved.
+// If the database rejects a chunk, its records are retried one by one so
+// only the broken ones fail. Every created record and every changed field is
+// written to the revision history. Saved records that park a vehicle against
+// the rules of a station track are saved anyway and listed in TrackConflicts.
 //
 // Parameters:
 //   - db: Database connection
-//   - userID: Optional user ID to filter records (0 for all records)
+//   - schedules: Train schedule records to save
+//   - editor: User performing the operation
+//   - opts: Chunk size and progress callback
 //
 // Returns:
-//   - Train schedule records matching the filter
-//   - Error if the database operation fails
-func GetTrainSchedules(db *sql.DB, userID int) ([]TrainSchedule, error) {
-	// Base SQL query
-	query := `
-		SELECT 
-			id, train_number_departure, train_number_arrival, vehicle_name,
-			starting_location, end_location, departure_date_time, arrival_date_time,
-			starting_track, target_track, employee1_departure, employee1_arrival,
-			duty_departure, duty_arrival, notes, raw_data, created_at, updated_at, user_id
-		FROM train_schedules
-	`
+//   - Summary with processed count and per-record failures
+//   - Error only if the database cannot be reached
+func SaveTrainSchedules(
+	db *sql.DB,
+	schedules []TrainSchedule,
+	editor Editor,
+	opts TrainScheduleSaveOptions,
+) (*TrainScheduleSaveResult, error) {
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultSaveChunkSize
+	}
 
-	// Add user filter if specified
-	var params []interface{}
-	if userID > 0 {
-		query += " WHERE user_id = ?"
-		params = append(params, userID)
+	result := &TrainScheduleSaveResult{
+		Failed:         []TrainScheduleRowError{},
+		TrackConflicts: []parking.Conflict{},
 	}
+	reported := make(map[string]bool)
 
-	// Add ordering
-	query += " ORDER BY COALESCE(departure_date_time, arrival_date_time) DESC"
+	// When an ID repeats, the last record wins
+	lastIndex := make(map[string]int, len(schedules))
+	for i, schedule := range schedules {
+		if schedule.ID == "" {
+			result.Failed = append(result.Failed, TrainScheduleRowError{
+				Index: i, Code: SaveErrorInvalid, Message: "record ID is required",
+			})
+			continue
+		}
+		if previous, seen := lastIndex[schedule.ID]; seen {
+			result.Failed = append(result.Failed, TrainScheduleRowError{
+				Index: previous, ID: schedule.ID, Code: SaveErrorDuplicate,
+				Message: fmt.Sprintf("record ID repeats at position %d, the later record is used", i),
+			})
+		}
+		lastIndex[schedule.ID] = i
+	}
 
-	// Execute query
-	rows, err := db.Query(query, params...)
-	if err != nil {
-		return nil, err
+	var pending []int
+	for i, schedule := range schedules {
+		if schedule.ID != "" && lastIndex[schedule.ID] == i {
+			pending = append(pending, i)
+		}
 	}
-	defer rows.Close()
 
-	// Process results
-	var schedules []TrainSchedule
-	for rows.Next() {
-		var schedule TrainSchedule
-		var departureTime, arrivalTime sql.NullTime
-
-		if err := rows.Scan(
-			&schedule.ID, &schedule.TrainNumberDeparture, &schedule.TrainNumberArrival, &schedule.VehicleName,
-			&schedule.StartingLocation, &schedule.EndLocation, &departureTime, &arrivalTime,
-			&schedule.StartingTrack, &schedule.TargetTrack, &schedule.Employee1Departure, &schedule.Employee1Arrival,
-			&schedule.DutyDeparture, &schedule.DutyArrival, &schedule.Notes, &schedule.RawData,
-			&schedule.CreatedAt, &schedule.UpdatedAt, &schedule.UserID,
-		); err != nil {
-			return nil, err
+	// Imports may set every field; edits only the editable ones
+	var editable map[string]*EditableField
+	if !opts.Imported {
+		fields, err := GetActiveEditableFields(db)
+		if err != nil {
+			return result, err
+		}
+		editable = make(map[string]*EditableField, len(fields))
+		for i := range fields {
+			editable[fields[i].FieldName] = &fields[i]
 		}
+	}
+
+	for start := 0; start < len(pending); start += chunkSize {
+		chunk := pending[start:min(start+chunkSize, len(pending))]
 
-		// Handle nullable datetime fields
-		if departureTime.Valid {
-			schedule.DepartureDateTime = &departureTime.Time
+		outcome, err := saveTrainScheduleChunk(db, schedules, chunk, editor, editable)
+		if errors.Is(err, errBeginFailed) {
+			return result, err
 		}
-		if arrivalTime.Valid {
-			schedule.ArrivalDateTime = &arrivalTime.Time
+		if err != nil && len(chunk) > 1 {
+			// Retry record by record to find the ones the database rejects
+			outcome = trainScheduleChunkOutcome{}
+			for _, i := range chunk {
+				single, err := saveTrainScheduleChunk(db, schedules, []int{i}, editor, editable)
+				if errors.Is(err, errBeginFailed) {
+					return result, err
+				}
+				if err != nil {
+					single = trainScheduleChunkOutcome{failed: []TrainScheduleRowError{{
+						Index: i, ID: schedules[i].ID, Code: SaveErrorDatabase, Message: err.Error(),
+					}}}
+				}
+				outcome.merge(single)
+			}
+		} else if err != nil {
+			outcome = trainScheduleChunkOutcome{failed: []TrainScheduleRowError{{
+				Index: chunk[0], ID: schedules[chunk[0]].ID, Code: SaveErrorDatabase, Message: err.Error(),
+			}}}
 		}
 
-		schedules = append(schedules, schedule)
+		result.Created += outcome.created
+		result.Updated += outcome.updated
+		result.Unchanged += outcome.unchanged
+		result.Failed = append(result.Failed, outcome.failed...)
+
+		// A conflict between records of different chunks is found by both
+		for _, conflict := range outcome.conflicts {
+			if key := trackConflictKey(conflict); !reported[key] {
+				reported[key] = true
+				result.TrackConflicts = append(result.TrackConflicts, conflict)
+			}
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(start+len(chunk), len(pending))
+		}
 	}
 
-	return schedules, nil
+	result.Processed = result.Created + result.Updated + result.Unchanged
+	sort.Slice(result.Failed, func(a, b int) bool {
+		return result.Failed[a].Index < result.Failed[b].Index
+	})
+
+	return result, nil
 }
 
-// SaveTrainSchedules saves or updates a batch of train schedule records.
-// This function handles both insertion of new records and updating of existing ones
-// based on their ID field.
-//
-// Parameters:
-//   - db: Database connection
-//   - schedules: Array of train schedule records to save
-//   - userID: ID of the user performing the operation
-//
-// Returns:
-//   - Number of records processed
-//   - Error if the database operation fails
-func SaveTrainSchedules(db *sql.DB, schedules []TrainSchedule, userID int) (int, error) {
-	// Begin transaction
+// errBeginFailed signals that no transaction could be started, so retrying is pointless.
+var errBeginFailed = errors.New("failed to begin transaction")
+
+// trainScheduleChunkOutcome counts the results of one chunk.
+type trainScheduleChunkOutcome struct {
+	created   int
+	updated   int
+	unchanged int
+	failed    []TrainScheduleRowError
+	conflicts []parking.Conflict
+}
+
+// merge adds another outcome to this one.
+func (o *trainScheduleChunkOutcome) merge(other trainScheduleChunkOutcome) {
+	o.created += other.created
+	o.updated += other.updated
+	o.unchanged += other.unchanged
+	o.failed = append(o.failed, other.failed...)
+	o.conflicts = append(o.conflicts, other.conflicts...)
+}
+
+// saveTrainScheduleChunk writes the records at the given indexes in one transaction.
+// Ownership, version and field failures are returned in the outcome; a returned
+// error means the whole chunk was rolled back. Changes of stored records are
+// checked against the editable fields unless editable is nil.
+func saveTrainScheduleChunk(
+	db *sql.DB,
+	schedules []TrainSchedule,
+	indexes []int,
+	editor Editor,
+	editable map[string]*EditableField,
+) (trainScheduleChunkOutcome, error) {
+	var outcome trainScheduleChunkOutcome
+
 	tx, err := db.Begin()
 	if err != nil {
-		return 0, err
+		return outcome, fmt.Errorf("%w: %v", errBeginFailed, err)
 	}
 	defer tx.Rollback()
 
-	// Insert or update each record
-	processed := 0
-	for _, schedule := range schedules {
-		// Check if record already exists
-		var exists bool
-		err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM train_schedules WHERE id = ?)", schedule.ID).
-			Scan(&exists)
-		if err != nil {
-			return processed, err
+	ids := make([]string, len(indexes))
+	for n, i := range indexes {
+		ids[n] = schedules[i].ID
+	}
+	current, err := lockTrainSchedules(tx, ids)
+	if err != nil {
+		return outcome, err
+	}
+	trashed, err := lockTrashedTrainSchedules(tx, ids)
+	if err != nil {
+		return outcome, err
+	}
+
+	// Records may neither leave nor join a depot day whose plan is published
+	touched := make([]*TrainSchedule, 0, 2*len(indexes))
+	for _, i := range indexes {
+		touched = append(touched, &schedules[i])
+		if existing := current[schedules[i].ID]; existing != nil {
+			touched = append(touched, existing)
 		}
+	}
+	closed, err := closedPlanDays(tx, touched...)
+	if err != nil {
+		return outcome, err
+	}
+
+	var toWrite []TrainSchedule
+	var revisions []TrainScheduleRevision
+	var created []string
+	var parked []*TrainSchedule // Records whose track position or its time changes
+	for _, i := range indexes {
+		schedule := schedules[i]
+		existing := current[schedule.ID]
+
+		if inClosedPlan(closed, &schedule) || (existing != nil && inClosedPlan(closed, existing)) {
+			outcome.failed = append(outcome.failed, TrainScheduleRowError{
+				Index: i, ID: schedule.ID, Code: SaveErrorPublished,
+				Message: "the depot plan of the day is published", Current: existing,
+			})
+			continue
+		}
+
+		if existing == nil {
+			// Only who may edit a trashed record may replace it
+			if old := trashed[schedule.ID]; old != nil && !editor.canEdit(old) {
+				outcome.failed = append(outcome.failed, TrainScheduleRowError{
+					Index: i, ID: schedule.ID, Code: SaveErrorForbidden,
+					Message: "record belongs to another user or depot",
+				})
+				continue
+			}
+			if !editor.canSave(nil, &schedule) {
+				outcome.failed = append(outcome.failed, TrainScheduleRowError{
+					Index: i, ID: schedule.ID, Code: SaveErrorForbidden,
+					Message: "record touches no depot the user edits",
+				})
+				continue
+			}
 
-		// Prepare raw data as JSON if needed
-		var rawData []byte
+			// Prepare raw data as JSON if none was provided
+			if schedule.RawData == "" {
+				rawData, _ := json.Marshal(schedule)
+				schedule.RawData = string(rawData)
+			}
+			schedule.UserID = &editor.UserID
+
+			revision, err := trainScheduleSnapshot(RevisionCreate, &schedule)
+			if err != nil {
+				return outcome, err
+			}
+			revisions = append(revisions, revision)
+			toWrite = append(toWrite, schedule)
+			created = append(created, schedule.ID)
+			parked = append(parked, &schedules[i])
+			outcome.created++
+			continue
+		}
+
+		// Only the owner, the depot editors or an admin may overwrite an existing
+		// record, and only editors of a depot it touches may move it
+		if !editor.canSave(existing, &schedule) {
+			outcome.failed = append(outcome.failed, TrainScheduleRowError{
+				Index: i, ID: schedule.ID, Code: SaveErrorForbidden,
+				Message: "record belongs to another user or depot",
+			})
+			continue
+		}
+
+		// Reject records edited from an outdated copy
+		if err := checkVersion("train_schedule", schedule.ID, schedule.Version, existing.Version); err != nil {
+			outcome.failed = append(outcome.failed, TrainScheduleRowError{
+				Index: i, ID: schedule.ID, Code: SaveErrorConflict, Message: err.Error(), Current: existing,
+			})
+			continue
+		}
+
+		// Keep the original import data unless new data was sent
 		if schedule.RawData == "" {
-			// If no raw data provided, create JSON from the record itself
-			rawData, _ = json.Marshal(schedule)
-		} else {
-			rawData = []byte(schedule.RawData)
+			schedule.RawData = existing.RawData
 		}
 
-		if exists {
-			// Update existing record
-			_, err = tx.Exec(
-				`
-				UPDATE train_schedules SET
-					train_number_departure = ?,
-					train_number_arrival = ?,
-					vehicle_name = ?,
-					starting_location = ?,
-					end_location = ?,
-					departure_date_time = ?,
-					arrival_date_time = ?,
-					starting_track = ?,
-					target_track = ?,
-					employee1_departure = ?,
-					employee1_arrival = ?,
-					duty_departure = ?,
-					duty_arrival = ?,
-					notes = ?,
-					raw_data = ?,
-					updated_at = NOW(),
-					user_id = ?
-				WHERE id = ?
-			`,
-				schedule.TrainNumberDeparture,
-				schedule.TrainNumberArrival,
-				schedule.VehicleName,
-				schedule.StartingLocation,
-				schedule.EndLocation,
-				schedule.DepartureDateTime,
-				schedule.ArrivalDateTime,
-				schedule.StartingTrack,
-				schedule.TargetTrack,
-				schedule.Employee1Departure,
-				schedule.Employee1Arrival,
-				schedule.DutyDeparture,
-				schedule.DutyArrival,
-				schedule.Notes,
-				string(rawData),
-				userID,
-				schedule.ID,
-			)
-		} else {
-			// Insert new record
-			_, err = tx.Exec(`
-				INSERT INTO train_schedules (
-					id, train_number_departure, train_number_arrival, vehicle_name,
-					starting_location, end_location, departure_date_time, arrival_date_time,
-					starting_track, target_track, employee1_departure, employee1_arrival,
-					duty_departure, duty_arrival, notes, raw_data, created_at, updated_at, user_id
-				) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW(), ?)
-			`,
-				schedule.ID, schedule.TrainNumberDeparture, schedule.TrainNumberArrival, schedule.VehicleName,
-				schedule.StartingLocation, schedule.EndLocation, schedule.DepartureDateTime, schedule.ArrivalDateTime,
-				schedule.StartingTrack, schedule.TargetTrack, schedule.Employee1Departure, schedule.Employee1Arrival,
-				schedule.DutyDeparture, schedule.DutyArrival, schedule.Notes, string(rawData),
-				userID,
-			)
+		changes := trainScheduleChanges(existing, &schedule)
+		if len(changes) == 0 && schedule.RawData == existing.RawData {
+			outcome.unchanged++
+			continue
+		}
+		if editable != nil {
+			if code, message := editableChangeError(editable, changes); code != "" {
+				outcome.failed = append(outcome.failed, TrainScheduleRowError{
+					Index: i, ID: schedule.ID, Code: code, Message: message, Current: existing,
+				})
+				continue
+			}
 		}
 
-		if err != nil {
-			return processed, err
+		revisions = append(revisions, changes...)
+		toWrite = append(toWrite, schedule)
+		for _, change := range changes {
+			if parkingFields[change.Field] {
+				parked = append(parked, &schedules[i])
+				break
+			}
 		}
-		processed++
+		outcome.updated++
+	}
+
+	// A record saved again after it was deleted replaces its copy in the trash
+	if err := purgeTrashedTrainSchedules(tx, created); err != nil {
+		return outcome, err
+	}
+	if err := upsertTrainSchedules(tx, toWrite); err != nil {
+		return outcome, err
+	}
+	if err := insertTrainScheduleRevisions(tx, revisions, editor); err != nil {
+		return outcome, err
+	}
+
+	// Flag the parking conflicts the saved records run into
+	outcome.conflicts, err = trackConflicts(tx, parked...)
+	if err != nil {
+		return outcome, err
 	}
 
-	// Commit transaction
 	if err := tx.Commit(); err != nil {
-		return processed, err
+		return outcome, err
+	}
+
+	return outcome, nil
+}
+
+// editableChangeError checks the changes of a stored record against the
+// editable fields. It returns the SaveError* code and message of the first
+// change of a field that is not editable or a value the field rejects, or
+// empty strings if every change is allowed.
+func editableChangeError(editable map[string]*EditableField, changes []TrainScheduleRevision) (string, string) {
+	for _, change := range changes {
+		field := editable[change.Field]
+		if field == nil {
+			return SaveErrorForbidden, fmt.Sprintf("field %s is not editable", change.Field)
+		}
+		value := ""
+		if change.NewValue != nil {
+			value = *change.NewValue
+		}
+		if _, err := field.Normalize(value); err != nil {
+			return SaveErrorInvalid, err.Error()
+		}
+	}
+	return "", ""
+}
+
+// lockTrainSchedules reads the stored records with the given IDs and locks
+// them until the transaction ends. Missing and trashed IDs are absent from the map.
+func lockTrainSchedules(tx *sql.Tx, ids []string) (map[string]*TrainSchedule, error) {
+	return lockTrainScheduleRows(tx, ids, "deleted_at IS NULL")
+}
+
+// lockTrainScheduleRows reads and locks the records with the given IDs that
+// match the condition, keyed by ID.
+func lockTrainScheduleRows(tx *sql.Tx, ids []string, condition string) (map[string]*TrainSchedule, error) {
+	found := make(map[string]*TrainSchedule, len(ids))
+	if len(ids) == 0 {
+		return found, nil
+	}
+
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := tx.Query(
+		"SELECT "+trainScheduleSelectColumns+" FROM train_schedules WHERE id IN ("+
+			strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")+") AND "+condition+" FOR UPDATE",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		schedule, err := scanTrainSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		found[schedule.ID] = &schedule
+	}
+
+	return found, rows.Err()
+}
+
+// upsertTrainSchedules inserts new records and updates existing ones with a
+// single multi-row statement. Existing records keep their owner and creation
+// time and get their version incremented.
+func upsertTrainSchedules(tx *sql.Tx, schedules []TrainSchedule) error {
+	if len(schedules) == 0 {
+		return nil
 	}
 
-	return processed, nil
+	const columns = 20
+	query := `
+		INSERT INTO train_schedules (
+			id, train_number_departure, train_number_arrival, vehicle_name,
+			starting_location, end_location, departure_date_time, arrival_date_time,
+			actual_departure_date_time, actual_arrival_date_time, delay_reason,
+			starting_track, target_track, employee1_departure, employee1_arrival,
+			duty_departure, duty_arrival, notes, raw_data, user_id
+		) VALUES ` + placeholderRows(len(schedules), columns) + `
+		ON DUPLICATE KEY UPDATE
+			train_number_departure = VALUES(train_number_departure),
+			train_number_arrival = VALUES(train_number_arrival),
+			vehicle_name = VALUES(vehicle_name),
+			starting_location = VALUES(starting_location),
+			end_location = VALUES(end_location),
+			departure_date_time = VALUES(departure_date_time),
+			arrival_date_time = VALUES(arrival_date_time),
+			actual_departure_date_time = VALUES(actual_departure_date_time),
+			actual_arrival_date_time = VALUES(actual_arrival_date_time),
+			delay_reason = VALUES(delay_reason),
+			starting_track = VALUES(starting_track),
+			target_track = VALUES(target_track),
+			employee1_departure = VALUES(employee1_departure),
+			employee1_arrival = VALUES(employee1_arrival),
+			duty_departure = VALUES(duty_departure),
+			duty_arrival = VALUES(duty_arrival),
+			notes = VALUES(notes),
+			raw_data = VALUES(raw_data),
+			updated_at = NOW(),
+			version = version + 1
+	`
+
+	args := make([]any, 0, len(schedules)*columns)
+	for _, s := range schedules {
+		args = append(args,
+			s.ID, s.TrainNumberDeparture, s.TrainNumberArrival, s.VehicleName,
+			s.StartingLocation, s.EndLocation, s.DepartureDateTime, s.ArrivalDateTime,
+			s.ActualDepartureDateTime, s.ActualArrivalDateTime, s.DelayReason,
+			s.StartingTrack, s.TargetTrack, s.Employee1Departure, s.Employee1Arrival,
+			s.DutyDeparture, s.DutyArrival, s.Notes, s.RawData, s.UserID,
+		)
+	}
+
+	_, err := tx.Exec(query, args...)
+	return err
 }
 
 // UpdateTrainScheduleField updates a specific field of a train schedule record.
 // This allows for partial updates of individual fields without having to send
-// the entire record.
+// the entire record. Only fields made editable by admins can be changed, and
+// the value must pass the field's rules. The change is written to the
+// revision history.
+//
+// A change of the track, location, time or vehicle that parks a vehicle
+// against the rules of a station track is rejected, unless the track allows
+// exceptions; conflicts the record already had do not block the change.
 //
 // Parameters:
 //   - db: Database connection
 //   - id: ID of the record to update
-//   - field: Name of the field to update
-//   - value: New value for the field
-//   - userID: ID of the user performing the update
+//   - field: Name of the field to update (JSON field name, e.g. "vehicleName")
+//   - value: New value for the field (RFC 3339 for times, empty to clear)
+//   - expectedVersion: Version the client edited (0 skips the check)
+//   - editor: User performing the update
 //
 // Returns:
-//   - Error if the database operation fails
-func UpdateTrainScheduleField(db *sql.DB, id string, field string, value string, userID int) error {
-	// Validate field name to prevent SQL injection
-	allowedFields := map[string]string{
-		"startingTrack": "starting_track",
-		"targetTrack":   "target_track",
-		"notes":         "notes",
+//   - Version of the record after the update
+//   - Parking conflicts the record takes part in after the update
+//   - sql.ErrNoRows if the record is not found, not editable by the user, or the field is not editable
+//   - *FieldValueError if the value breaks the field's rules
+//   - ErrPlanPublished if the record belongs to a published depot plan
+//   - ErrScheduleForbidden if the change moves the record out of the depots the user edits
+//   - *TrackConflictError if the change causes conflicts on tracks without exceptions
+//   - Error if the database operation fails (*VersionConflictError if the record was changed meanwhile)
+func UpdateTrainScheduleField(
+	db *sql.DB,
+	id string,
+	field string,
+	value string,
+	expectedVersion int64,
+	editor Editor,
+) (int64, []parking.Conflict, error) {
+	editable, err := GetEditableField(db, field)
+	if err != nil {
+		return 0, nil, err // sql.ErrNoRows for fields that are not editable
+	}
+	newValue, err := editable.Normalize(value)
+	if err != nil {
+		return 0, nil, err
 	}
 
-	dbField, allowed := allowedFields[field]
-	if !allowed {
-		return sql.ErrNoRows // Use standard error to avoid exposing details
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, nil, err
 	}
+	defer tx.Rollback()
 
 	// Check if record exists and belongs to user
-	var exists bool
-	var recordUserID sql.NullInt64
-	err := db.QueryRow(`
-		SELECT EXISTS(SELECT 1 FROM train_schedules WHERE id = ?), 
-		(SELECT user_id FROM train_schedules WHERE id = ?)
-	`, id, id).Scan(&exists, &recordUserID)
+	current, err := getTrainScheduleForUpdate(tx, id)
 	if err != nil {
-		return err
+		return 0, nil, err
 	}
 
-	if !exists {
-		return sql.ErrNoRows
+	// Allow update if the user owns the record, edits its depot or is admin
+	if !editor.canEdit(current) {
+		return 0, nil, sql.ErrNoRows // Use standard error for security
 	}
 
-	// Allow update if record belongs to user or user is admin
-	// (Admin check would be done at the handler level)
-	if recordUserID.Valid && int(recordUserID.Int64) != userID {
-		return sql.ErrNoRows // Use standard error for security
+	if err := checkVersion("train_schedule", id, expectedVersion, current.Version); err != nil {
+		return 0, nil, err
 	}
 
-	// Build and execute the update query
-	query := "UPDATE train_schedules SET " + dbField + " = ?, updated_at = NOW() WHERE id = ?"
-	_, err = db.Exec(query, value, id)
-	return err
+	// Nothing to record if the value is unchanged
+	oldValue := trainScheduleFieldValue(current, field)
+	if sameRevisionValue(oldValue, newValue) {
+		return current.Version, []parking.Conflict{}, nil
+	}
+
+	// Conflicts the record already has do not block the change
+	var before []parking.Conflict
+	if parkingFields[field] {
+		if before, err = trackConflicts(tx, current); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if err := setTrainScheduleField(tx, id, field, newValue); err != nil {
+		return 0, nil, err
+	}
+
+	// Neither the record as it was nor as it is now may be part of a published plan
+	updated, err := getTrainScheduleForUpdate(tx, id)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := checkPlansOpen(tx, current, updated); err != nil {
+		return 0, nil, err
+	}
+	if !editor.canSave(current, updated) {
+		return 0, nil, ErrScheduleForbidden
+	}
+
+	conflicts := []parking.Conflict{}
+	if parkingFields[field] {
+		if conflicts, err = trackConflicts(tx, updated); err != nil {
+			return 0, nil, err
+		}
+		if blocking := blockingConflicts(before, conflicts); len(blocking) > 0 {
+			return 0, nil, &TrackConflictError{Conflicts: blocking}
+		}
+	}
+
+	err = insertTrainScheduleRevision(tx, &TrainScheduleRevision{
+		ScheduleID: id,
+		Action:     RevisionUpdate,
+		Field:      field,
+		OldValue:   oldValue,
+		NewValue:   newValue,
+	}, editor)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+
+	return current.Version + 1, conflicts, nil
 }
 
-// DeleteTrainSchedule removes a train schedule record from the database.
+// DeleteTrainSchedule moves a train schedule record to the trash, from where
+// it can be restored until it is purged. The deleted record is also kept as
+// JSON in the revision history.
 //
 // Parameters:
 //   - db: Database connection
 //   - id: ID of the record to delete
-//   - userID: ID of the user requesting the deletion
+//   - expectedVersion: Version the client saw (0 skips the check)
+//   - editor: User requesting the deletion
 //
 // Returns:
-//   - Error if the database operation fails
-func DeleteTrainSchedule(db *sql.DB, id string, userID int) error {
-	// Check if record exists and belongs to user
-	var exists bool
-	var recordUserID sql.NullInt64
-	err := db.QueryRow(`
-		SELECT EXISTS(SELECT 1 FROM train_schedules WHERE id = ?), 
-		(SELECT user_id FROM train_schedules WHERE id = ?)
-	`, id, id).Scan(&exists, &recordUserID)
+//   - ErrPlanPublished if the record belongs to a published depot plan
+//   - Error if the database operation fails (*VersionConflictError if the record was changed meanwhile)
+func DeleteTrainSchedule(db *sql.DB, id string, expectedVersion int64, editor Editor) error {
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	if !exists {
-		return sql.ErrNoRows
+	// Check if record exists and belongs to user
+	current, err := getTrainScheduleForUpdate(tx, id)
+	if err != nil {
+		return err
 	}
 
-	// Allow deletion if record belongs to user or user is admin
-	// (Admin check would be done at the handler level)
-	if recordUserID.Valid && int(recordUserID.Int64) != userID {
+	// Allow deletion if the user owns the record, edits its depot or is admin
+	if !editor.canEdit(current) {
 		return sql.ErrNoRows // Use standard error for security
 	}
 
-	// Delete the record
-	_, err = db.Exec("DELETE FROM train_schedules WHERE id = ?", id)
-	return err
+	if err := checkVersion("train_schedule", id, expectedVersion, current.Version); err != nil {
+		return err
+	}
+	if err := checkPlansOpen(tx, current); err != nil {
+		return err
+	}
+
+	// Move the record to the trash
+	if _, err := tx.Exec(
+		"UPDATE train_schedules SET deleted_at = NOW(), deleted_by = ?, version = version + 1 WHERE id = ?",
+		editor.UserID, id,
+	); err != nil {
+		return err
+	}
+
+	if err := recordTrainScheduleSnapshot(tx, RevisionDelete, current, editor); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteTrainSchedules deletes several records one by one, each only if it
+// still has the version it was read with. Records that cannot be deleted are
+// reported instead of stopping the batch; Index is the position in schedules.
+func DeleteTrainSchedules(db *sql.DB, schedules []TrainSchedule, editor Editor) (int, []TrainScheduleRowError) {
+	deleted := 0
+	failed := []TrainScheduleRowError{}
+
+	for i, schedule := range schedules {
+		err := DeleteTrainSchedule(db, schedule.ID, schedule.Version, editor)
+		switch {
+		case err == nil:
+			deleted++
+		case errors.Is(err, ErrVersionConflict):
+			current, _ := GetTrainScheduleByID(db, schedule.ID)
+			failed = append(failed, TrainScheduleRowError{
+				Index: i, ID: schedule.ID, Code: SaveErrorConflict, Message: err.Error(), Current: current,
+			})
+		case err == ErrPlanPublished:
+			failed = append(failed, TrainScheduleRowError{
+				Index: i, ID: schedule.ID, Code: SaveErrorPublished, Message: err.Error(),
+			})
+		case err == sql.ErrNoRows:
+			// Either deleted meanwhile or owned by someone else; report it either way
+			failed = append(failed, TrainScheduleRowError{
+				Index: i, ID: schedule.ID, Code: SaveErrorForbidden,
+				Message: "record not found or belongs to another user",
+			})
+		default:
+			failed = append(failed, TrainScheduleRowError{
+				Index: i, ID: schedule.ID, Code: SaveErrorDatabase, Message: err.Error(),
+			})
+		}
+	}
+
+	return deleted, failed
 }
@@ -0,0 +1,318 @@
+// backend/internal/models/train_schedule_diff.go
+package models
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"yopta-template/internal/timeparse"
+)
+
+// TrainScheduleFieldChange is one field that an import would change.
+type TrainScheduleFieldChange struct {
+	Field string  `json:"field"` // Field name in camelCase
+	Old   *string `json:"old"`   // Stored value (times in RFC 3339 UTC)
+	New   *string `json:"new"`   // Imported value
+}
+
+// TrainScheduleDiffEntry is a stored record that an import would change.
+type TrainScheduleDiffEntry struct {
+	ID      string                     `json:"id"`      // Record ID
+	Current TrainSchedule              `json:"current"` // Stored record
+	Changes []TrainScheduleFieldChange `json:"changes"` // Changed fields
+}
+
+// TrainScheduleDiff compares an import with the stored records of the same
+// dates and depots. Records are matched by ID, which is built from the vehicle
+// working designation, date and trip number and therefore stays the same
+// across re-exports of the same train.
+type TrainScheduleDiff struct {
+	Added       []TrainSchedule          `json:"added"`       // Records not stored yet
+	Changed     []TrainScheduleDiffEntry `json:"changed"`     // Stored records the import changes
+	Removed     []TrainSchedule          `json:"removed"`     // Stored records of the same dates and depots missing from the import
+	Forbidden   []string                 `json:"forbidden"`   // IDs of stored records the user may not see; these rows are not imported
+	Unchanged   int                      `json:"unchanged"`   // Stored records identical to the import
+	Fingerprint string                   `json:"fingerprint"` // Identifies this diff; changes if the import or the stored data change
+
+	// Records are the imported records merged with the stored ones,
+	// ready to be saved if the diff is confirmed
+	Records []TrainSchedule `json:"-"`
+}
+
+// keepDispatcherFields copies the stored track assignments, notes, actual
+// times and delay reason into an imported record that has none. Dispatchers
+// fill these in Klasika, and the planning export usually leaves them empty.
+func keepDispatcherFields(imported *TrainSchedule, stored *TrainSchedule) {
+	if imported.StartingTrack == "" {
+		imported.StartingTrack = stored.StartingTrack
+	}
+	if imported.TargetTrack == "" {
+		imported.TargetTrack = stored.TargetTrack
+	}
+	if imported.Notes == "" {
+		imported.Notes = stored.Notes
+	}
+	if imported.ActualDepartureDateTime == nil {
+		imported.ActualDepartureDateTime = stored.ActualDepartureDateTime
+	}
+	if imported.ActualArrivalDateTime == nil {
+		imported.ActualArrivalDateTime = stored.ActualArrivalDateTime
+	}
+	if imported.DelayReason == "" {
+		imported.DelayReason = stored.DelayReason
+	}
+}
+
+// DiffTrainScheduleImport compares imported records with the stored ones.
+//
+// The comparison covers every stored record with an imported ID and every
+// stored record whose date (departure, or arrival if there is no departure)
+// and depot (starting or end location) occur together on an imported record.
+// The latter that are not in the import are reported as removed. Track
+// assignments, notes, actual times and delay reasons left empty by the import
+// keep their stored values. When an ID repeats in the import, the last record
+// wins, as in SaveTrainSchedules.
+//
+// Record IDs can be guessed, so an imported row matching a stored record the
+// editor may not see only reports the ID as forbidden and is left out of the
+// records to save. Only records the editor may edit are reported as removed.
+//
+// Parameters:
+//   - db: Database connection
+//   - imported: Parsed import records
+//   - editor: User importing the records
+//
+// Returns:
+//   - Diff with the merged records ready to be saved
+//   - Error if the database operation fails
+func DiffTrainScheduleImport(db *sql.DB, imported []TrainSchedule, editor Editor) (*TrainScheduleDiff, error) {
+	diff := &TrainScheduleDiff{
+		Added:     []TrainSchedule{},
+		Changed:   []TrainScheduleDiffEntry{},
+		Removed:   []TrainSchedule{},
+		Forbidden: []string{},
+	}
+
+	// When an ID repeats, the last record wins
+	lastIndex := make(map[string]int, len(imported))
+	for i, schedule := range imported {
+		lastIndex[schedule.ID] = i
+	}
+	var ids []string
+	for i, schedule := range imported {
+		if lastIndex[schedule.ID] == i {
+			ids = append(ids, schedule.ID)
+		}
+	}
+
+	stored, err := getTrainSchedulesByIDs(db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range imported {
+		schedule := imported[i]
+		if lastIndex[schedule.ID] != i {
+			continue
+		}
+
+		current := stored[schedule.ID]
+		if current == nil {
+			diff.Added = append(diff.Added, schedule)
+			diff.Records = append(diff.Records, schedule)
+			continue
+		}
+		if !editor.CanView(current) {
+			diff.Forbidden = append(diff.Forbidden, schedule.ID)
+			continue
+		}
+
+		keepDispatcherFields(&schedule, current)
+		schedule.Version = current.Version
+		diff.Records = append(diff.Records, schedule)
+
+		revisions := trainScheduleChanges(current, &schedule)
+		if len(revisions) == 0 {
+			diff.Unchanged++
+			continue
+		}
+
+		entry := TrainScheduleDiffEntry{ID: schedule.ID, Current: *current}
+		for _, revision := range revisions {
+			entry.Changes = append(entry.Changes, TrainScheduleFieldChange{
+				Field: revision.Field,
+				Old:   revision.OldValue,
+				New:   revision.NewValue,
+			})
+		}
+		diff.Changed = append(diff.Changed, entry)
+	}
+
+	// Stored records of the imported days and depots that the import lacks
+	inScope, err := getTrainSchedulesInImportScope(db, imported)
+	if err != nil {
+		return nil, err
+	}
+	for _, schedule := range inScope {
+		if _, ok := lastIndex[schedule.ID]; !ok && editor.canEdit(&schedule) {
+			diff.Removed = append(diff.Removed, schedule)
+		}
+	}
+
+	diff.Fingerprint, err = diff.fingerprint()
+	if err != nil {
+		return nil, err
+	}
+
+	return diff, nil
+}
+
+// fingerprint hashes the diff, so a confirmed preview can be matched
+// against the diff computed when the import is committed.
+func (d *TrainScheduleDiff) fingerprint() (string, error) {
+	data, err := json.Marshal(struct {
+		Added     []TrainSchedule
+		Changed   []TrainScheduleDiffEntry
+		Removed   []TrainSchedule
+		Forbidden []string
+	}{d.Added, d.Changed, d.Removed, d.Forbidden})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+// scheduleDay returns the Vilnius date a record belongs to:
+// its departure date, or its arrival date if it has no departure.
+func scheduleDay(s *TrainSchedule) string {
+	t := s.DepartureDateTime
+	if t == nil {
+		t = s.ArrivalDateTime
+	}
+	if t == nil {
+		return ""
+	}
+	return t.In(timeparse.Vilnius).Format("2006-01-02")
+}
+
+// importScopeKey identifies a depot day covered by an import.
+func importScopeKey(day, depot string) string {
+	return day + "\x00" + depot
+}
+
+// getTrainSchedulesInImportScope returns the stored records that share a
+// day and a depot with one of the imported records, ordered by time and ID.
+// A day and a depot that only occur on different imported records are not
+// covered by the import.
+func getTrainSchedulesInImportScope(db *sql.DB, imported []TrainSchedule) ([]TrainSchedule, error) {
+	scope := make(map[string]bool)
+	depots := make(map[string]bool)
+	var first, last time.Time
+	for i := range imported {
+		day := scheduleDay(&imported[i])
+		if day == "" {
+			continue
+		}
+		for _, depot := range []string{imported[i].StartingLocation, imported[i].EndLocation} {
+			if depot != "" {
+				scope[importScopeKey(day, depot)] = true
+				depots[depot] = true
+			}
+		}
+
+		start, err := timeparse.ParseDate(day)
+		if err != nil {
+			return nil, err
+		}
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if last.IsZero() || start.After(last) {
+			last = start
+		}
+	}
+	if len(scope) == 0 {
+		return nil, nil
+	}
+
+	var depotList []any
+	for depot := range depots {
+		depotList = append(depotList, depot)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(depotList)), ", ")
+
+	query := "SELECT " + trainScheduleSelectColumns + " FROM train_schedules" +
+		" WHERE deleted_at IS NULL" +
+		" AND COALESCE(departure_date_time, arrival_date_time) >= ?" +
+		" AND COALESCE(departure_date_time, arrival_date_time) < ?" +
+		" AND (starting_location IN (" + placeholders + ") OR end_location IN (" + placeholders + "))"
+	params := []any{first, last.AddDate(0, 0, 1)}
+	params = append(params, depotList...)
+	params = append(params, depotList...)
+	query += " ORDER BY COALESCE(departure_date_time, arrival_date_time), id"
+
+	rows, err := db.Query(query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []TrainSchedule
+	for rows.Next() {
+		schedule, err := scanTrainSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		// The range may span days and depots the import does not cover together
+		day := scheduleDay(&schedule)
+		if scope[importScopeKey(day, schedule.StartingLocation)] || scope[importScopeKey(day, schedule.EndLocation)] {
+			schedules = append(schedules, schedule)
+		}
+	}
+
+	return schedules, rows.Err()
+}
+
+// getTrainSchedulesByIDs reads the stored records with the given IDs.
+// Missing and trashed IDs are absent from the map.
+func getTrainSchedulesByIDs(db *sql.DB, ids []string) (map[string]*TrainSchedule, error) {
+	found := make(map[string]*TrainSchedule, len(ids))
+
+	for start := 0; start < len(ids); start += defaultSaveChunkSize {
+		chunk := ids[start:min(start+defaultSaveChunkSize, len(ids))]
+		args := make([]any, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+
+		rows, err := db.Query(
+			"SELECT "+trainScheduleSelectColumns+" FROM train_schedules WHERE id IN ("+
+				strings.TrimSuffix(strings.Repeat("?, ", len(chunk)), ", ")+") AND deleted_at IS NULL",
+			args...,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			schedule, err := scanTrainSchedule(rows)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			found[schedule.ID] = &schedule
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return found, nil
+}
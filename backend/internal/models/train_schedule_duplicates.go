@@ -0,0 +1,458 @@
+// backend/internal/models/train_schedule_duplicates.go
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Reasons why records are considered duplicates
+const (
+	DuplicateByKey       = "key"       // Same derived ID (see TrainScheduleKey) from the imported row
+	DuplicateByDeparture = "departure" // Same departure train, depot and time
+	DuplicateByArrival   = "arrival"   // Same arrival train, depot and time
+)
+
+// TrainScheduleDuplicateGroup is a set of records that probably describe the same train.
+type TrainScheduleDuplicateGroup struct {
+	Records []TrainSchedule `json:"records"` // Records of the group, the surviving one first
+	Reasons []string        `json:"reasons"` // Why the records were grouped
+	Merged  TrainSchedule   `json:"merged"`  // Preview of the record a merge would leave
+}
+
+// duplicateKeys returns the keys under which a record is compared with others.
+// Two records sharing any key belong to the same duplicate group.
+func duplicateKeys(s *TrainSchedule) map[string]string {
+	keys := make(map[string]string)
+
+	if key := derivedTrainScheduleKey(s); key != "" {
+		keys["k|"+key] = DuplicateByKey
+	}
+	if s.TrainNumberDeparture != "" && s.StartingLocation != "" && s.DepartureDateTime != nil {
+		keys["d|"+NormalizeKeyPart(s.TrainNumberDeparture)+"|"+s.StartingLocation+"|"+sortTime(s.DepartureDateTime)] = DuplicateByDeparture
+	}
+	if s.TrainNumberArrival != "" && s.EndLocation != "" && s.ArrivalDateTime != nil {
+		keys["a|"+NormalizeKeyPart(s.TrainNumberArrival)+"|"+s.EndLocation+"|"+sortTime(s.ArrivalDateTime)] = DuplicateByArrival
+	}
+
+	return keys
+}
+
+// derivedTrainScheduleKey derives the ID a record would get from its imported row.
+// Returns an empty string if the raw data does not hold an import row.
+func derivedTrainScheduleKey(s *TrainSchedule) string {
+	var record map[string]any
+	if err := json.Unmarshal([]byte(s.RawData), &record); err != nil {
+		return ""
+	}
+
+	values := make(map[string]string, len(record))
+	for name, value := range record {
+		if text, ok := value.(string); ok {
+			values[name] = text
+		}
+	}
+
+	key, err := TrainScheduleKeyFromRecord(values)
+	if err != nil {
+		return ""
+	}
+	return key
+}
+
+// FindTrainScheduleDuplicates groups records that probably describe the same
+// train: records with the same derived ID, or with the same departure
+// (train number, depot and time) or arrival. Typically these are rows imported
+// before IDs were derived the same way everywhere.
+//
+// Parameters:
+//   - db: Database connection
+//   - from, to: Optional time range; records departing or arriving in it are checked
+//
+// Returns:
+//   - Duplicate groups in time order
+//   - Error if the database operation fails
+func FindTrainScheduleDuplicates(db *sql.DB, from, to *time.Time) ([]TrainScheduleDuplicateGroup, error) {
+	list, err := GetTrainSchedules(db, TrainScheduleFilter{From: from, To: to, Sort: "time"})
+	if err != nil {
+		return nil, err
+	}
+	records := list.Records
+
+	// Union records that share a key
+	parent := make([]int, len(records))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	firstWithKey := make(map[string]int)
+	reasons := make(map[int]map[string]bool)
+	for i := range records {
+		for key, reason := range duplicateKeys(&records[i]) {
+			first, seen := firstWithKey[key]
+			if !seen {
+				firstWithKey[key] = i
+				continue
+			}
+			a, b := find(first), find(i)
+			if a != b {
+				parent[b] = a
+			}
+			if reasons[i] == nil {
+				reasons[i] = make(map[string]bool)
+			}
+			reasons[i][reason] = true
+		}
+	}
+
+	// Collect groups in the order of their first record
+	members := make(map[int][]int)
+	var roots []int
+	for i := range records {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], i)
+	}
+
+	groups := []TrainScheduleDuplicateGroup{}
+	for _, root := range roots {
+		indexes := members[root]
+		if len(indexes) < 2 {
+			continue
+		}
+
+		group := TrainScheduleDuplicateGroup{}
+		groupReasons := make(map[string]bool)
+		for _, i := range indexes {
+			group.Records = append(group.Records, records[i])
+			for reason := range reasons[i] {
+				groupReasons[reason] = true
+			}
+		}
+		for reason := range groupReasons {
+			group.Reasons = append(group.Reasons, reason)
+		}
+		sort.Strings(group.Reasons)
+
+		group.Records = orderForMerge(group.Records)
+		group.Merged = mergedTrainSchedule(group.Records)
+		if id := mergedTrainScheduleID(group.Records); id != "" {
+			group.Merged.ID = id
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+// orderForMerge puts the surviving record first: the one whose ID already is
+// its derived key, otherwise the most recently updated one. The others follow,
+// most recently updated first.
+func orderForMerge(records []TrainSchedule) []TrainSchedule {
+	ordered := append([]TrainSchedule(nil), records...)
+	sort.SliceStable(ordered, func(a, b int) bool {
+		aKeyed := derivedTrainScheduleKey(&ordered[a]) == ordered[a].ID
+		bKeyed := derivedTrainScheduleKey(&ordered[b]) == ordered[b].ID
+		if aKeyed != bKeyed {
+			return aKeyed
+		}
+		return ordered[a].UpdatedAt.After(ordered[b].UpdatedAt)
+	})
+	return ordered
+}
+
+// mergedTrainSchedule merges records ordered by orderForMerge. The surviving
+// record keeps its own values; empty track assignments, actual times and delay
+// reasons are taken from the most recently updated duplicate that has one, and
+// all distinct notes are kept.
+func mergedTrainSchedule(records []TrainSchedule) TrainSchedule {
+	merged := records[0]
+
+	var notes []string
+	seenNotes := make(map[string]bool)
+	for _, record := range records {
+		if merged.StartingTrack == "" {
+			merged.StartingTrack = record.StartingTrack
+		}
+		if merged.TargetTrack == "" {
+			merged.TargetTrack = record.TargetTrack
+		}
+		if merged.ActualDepartureDateTime == nil {
+			merged.ActualDepartureDateTime = record.ActualDepartureDateTime
+		}
+		if merged.ActualArrivalDateTime == nil {
+			merged.ActualArrivalDateTime = record.ActualArrivalDateTime
+		}
+		if merged.DelayReason == "" {
+			merged.DelayReason = record.DelayReason
+		}
+		note := strings.TrimSpace(record.Notes)
+		if note != "" && !seenNotes[note] {
+			seenNotes[note] = true
+			notes = append(notes, note)
+		}
+	}
+	merged.Notes = strings.Join(notes, "\n")
+
+	return merged
+}
+
+// mergedTrainScheduleID returns the derived ID the merged record should get,
+// or an empty string if the surviving record keeps its ID.
+func mergedTrainScheduleID(records []TrainSchedule) string {
+	survivor := &records[0]
+	if derivedTrainScheduleKey(survivor) == survivor.ID {
+		return ""
+	}
+	for i := range records {
+		if key := derivedTrainScheduleKey(&records[i]); key != "" {
+			return key
+		}
+	}
+	return ""
+}
+
+// MergeTrainSchedules merges duplicate records into one.
+//
+// The surviving record is chosen and filled in as in the FindTrainScheduleDuplicates
+// preview, so manual track assignments and notes of every duplicate are kept;
+// the duplicates' parking on depot tracks moves to the survivor. A record
+// stands in one place only, so the parking of a single record is kept: the
+// survivor's if it has any, otherwise that of the duplicate parked last. The
+// other assignments are removed and returned. The other records go to the
+// trash with a merge revision pointing at the survivor.
+// If the survivor does not have its derived ID yet and that ID is free, it is
+// renamed, and its history moves with it.
+//
+// Parameters:
+//   - db: Database connection
+//   - ids: IDs of the records to merge (at least two)
+//   - editor: User performing the merge
+//
+// Returns:
+//   - The merged record
+//   - Track assignments of the other records, removed by the merge
+//   - sql.ErrNoRows if any of the records does not exist
+//   - ErrPlanPublished if any of the records belongs to a published depot plan
+func MergeTrainSchedules(db *sql.DB, ids []string, editor Editor) (*TrainSchedule, []TrackAssignment, error) {
+	unique := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	if len(unique) < 2 {
+		return nil, nil, fmt.Errorf("at least two different records are needed for a merge")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	locked, err := lockTrainSchedules(tx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(locked) != len(unique) {
+		return nil, nil, sql.ErrNoRows
+	}
+
+	records := make([]TrainSchedule, 0, len(locked))
+	touched := make([]*TrainSchedule, 0, len(locked))
+	for _, record := range locked {
+		records = append(records, *record)
+		touched = append(touched, record)
+	}
+	if err := checkPlansOpen(tx, touched...); err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(records, func(a, b int) bool { return records[a].ID < records[b].ID })
+	records = orderForMerge(records)
+
+	survivor := records[0]
+	merged := mergedTrainSchedule(records)
+
+	// Keep the parking of one record for the survivor and drop the rest
+	parked, dropped, err := mergedTrackAssignments(tx, records)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, a := range dropped {
+		if _, err := tx.Exec("DELETE FROM track_assignments WHERE id = ?", a.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+	if parked != "" && parked != survivor.ID {
+		if _, err := tx.Exec(
+			"UPDATE track_assignments SET schedule_id = ?, version = version + 1 WHERE schedule_id = ?",
+			survivor.ID, parked,
+		); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Move the duplicates to the trash
+	for _, record := range records[1:] {
+		if _, err := tx.Exec(
+			"UPDATE train_schedules SET deleted_at = NOW(), deleted_by = ?, version = version + 1 WHERE id = ?",
+			editor.UserID, record.ID,
+		); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Carry over manual edits of the duplicates
+	changes := trainScheduleChanges(&survivor, &merged)
+	if len(changes) > 0 {
+		_, err := tx.Exec(`
+			UPDATE train_schedules
+			SET starting_track = ?, target_track = ?, notes = ?,
+				actual_departure_date_time = ?, actual_arrival_date_time = ?, delay_reason = ?,
+				updated_at = NOW(), version = version + 1
+			WHERE id = ?
+		`, merged.StartingTrack, merged.TargetTrack, merged.Notes,
+			merged.ActualDepartureDateTime, merged.ActualArrivalDateTime, merged.DelayReason, survivor.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Give the survivor its derived ID, unless another record already has it
+	finalID := survivor.ID
+	if newID := mergedTrainScheduleID(records); newID != "" {
+		var taken bool
+		err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM train_schedules WHERE id = ?)", newID).Scan(&taken)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !taken {
+			if err := renameTrainSchedule(tx, survivor.ID, newID, editor); err != nil {
+				return nil, nil, err
+			}
+			finalID = newID
+		}
+	}
+
+	// The duplicates' last state stays in their history, pointing at the survivor
+	var revisions []TrainScheduleRevision
+	for i := range records[1:] {
+		revision, err := trainScheduleSnapshot(RevisionDelete, &records[i+1])
+		if err != nil {
+			return nil, nil, err
+		}
+		revision.Action = RevisionMerge
+		revision.NewValue = &finalID
+		revisions = append(revisions, revision)
+	}
+	for _, change := range changes {
+		change.ScheduleID = finalID
+		revisions = append(revisions, change)
+	}
+	if err := insertTrainScheduleRevisions(tx, revisions, editor); err != nil {
+		return nil, nil, err
+	}
+
+	result, err := getTrainScheduleForUpdate(tx, finalID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return result, dropped, nil
+}
+
+// mergedTrackAssignments decides whose parking a merged record keeps: the
+// survivor's (records[0]) if it has any, otherwise that of the record whose
+// assignment was changed last. It returns that record's ID, empty if none of
+// the records is parked, and the assignments of the other records.
+func mergedTrackAssignments(tx *sql.Tx, records []TrainSchedule) (string, []TrackAssignment, error) {
+	args := make([]any, len(records))
+	for i := range records {
+		args[i] = records[i].ID
+	}
+	rows, err := tx.Query(
+		"SELECT "+trackAssignmentColumns+" FROM "+trackAssignmentTables+" WHERE a.schedule_id IN ("+
+			strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")+") ORDER BY a.id FOR UPDATE",
+		args...,
+	)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+
+	byRecord := make(map[string][]TrackAssignment)
+	for rows.Next() {
+		a, err := scanTrackAssignment(rows)
+		if err != nil {
+			return "", nil, err
+		}
+		byRecord[a.ScheduleID] = append(byRecord[a.ScheduleID], a)
+	}
+	if err := rows.Err(); err != nil {
+		return "", nil, err
+	}
+
+	kept := ""
+	if len(byRecord[records[0].ID]) > 0 {
+		kept = records[0].ID
+	} else {
+		var latest time.Time
+		for _, record := range records[1:] {
+			for _, a := range byRecord[record.ID] {
+				if kept == "" || a.UpdatedAt.After(latest) {
+					kept, latest = record.ID, a.UpdatedAt
+				}
+			}
+		}
+	}
+
+	var dropped []TrackAssignment
+	for _, record := range records {
+		if record.ID != kept {
+			dropped = append(dropped, byRecord[record.ID]...)
+		}
+	}
+	return kept, dropped, nil
+}
+
+// renameTrainSchedule changes a record's ID and moves its history along.
+// Track assignments follow through their ON UPDATE CASCADE foreign key.
+func renameTrainSchedule(tx *sql.Tx, oldID, newID string, editor Editor) error {
+	if _, err := tx.Exec(
+		"UPDATE train_schedules SET id = ?, updated_at = NOW(), version = version + 1 WHERE id = ?",
+		newID, oldID,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE train_schedule_revisions SET schedule_id = ? WHERE schedule_id = ?",
+		newID, oldID,
+	); err != nil {
+		return err
+	}
+
+	return insertTrainScheduleRevision(tx, &TrainScheduleRevision{
+		ScheduleID: newID,
+		Action:     RevisionMerge,
+		Field:      "id",
+		OldValue:   &oldID,
+		NewValue:   &newID,
+	}, editor)
+}
@@ -0,0 +1,75 @@
+// backend/internal/models/train_schedule_key.go
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"yopta-template/internal/timeparse"
+)
+
+// TrainScheduleKey derives the ID of a train schedule record as
+// "<working>_<date>_<trip>":
+//   - working: vehicle working designation (required)
+//   - date: departure date, or the service date for rows without a departure,
+//     as YYYYMMDD whatever format the source used (required)
+//   - trip: departure trip number (may be empty)
+//
+// Working and trip are reduced to upper-case letters and digits, so
+// "vj-12 a" and "VJ12A" give the same key, and since no part can contain
+// the separator, different parts can never run together into the same key.
+// The same train therefore gets the same ID from every import path.
+//
+// Example: TrainScheduleKey("VJ-101", "23.05.2025", "6101") == "VJ101_20250523_6101"
+func TrainScheduleKey(working, date, trip string) (string, error) {
+	working = NormalizeKeyPart(working)
+	if working == "" {
+		return "", fmt.Errorf("vehicle working designation is empty")
+	}
+
+	day, err := timeparse.ParseDate(date)
+	if err != nil {
+		return "", err
+	}
+
+	return working + "_" + day.Format("20060102") + "_" + NormalizeKeyPart(trip), nil
+}
+
+// TrainScheduleKeyFromRecord derives the record ID from a mapped import row
+// (internal field names to values), as stored in raw_data by clipboard imports.
+// The trip falls back to the departure train number and then to the network
+// train number, like the Klasika page did.
+func TrainScheduleKeyFromRecord(record map[string]string) (string, error) {
+	return TrainScheduleKey(
+		record["vehicleWorkingDesignation"],
+		firstNonEmptyValue(record["departureDate"], record["date"]),
+		firstNonEmptyValue(
+			record["departureTripNumber"],
+			record["departureTrainNumber"],
+			record["departureNetworkTrainNumber"],
+		),
+	)
+}
+
+// NormalizeKeyPart upper-cases a key component and drops everything
+// except letters and digits (spaces, dashes, dots, slashes, ...).
+func NormalizeKeyPart(value string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(value) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// firstNonEmptyValue returns the first non-empty string from the arguments.
+func firstNonEmptyValue(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,449 @@
+// backend/internal/models/train_schedule_query.go
+package models
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned for a pagination cursor that was not issued
+// for the requested sort order.
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// TrainScheduleList is a collection of train schedule records.
+// Used for API responses when returning multiple records.
+type TrainScheduleList struct {
+	Records    []TrainSchedule `json:"records"`              // Array of train schedule records
+	Total      int             `json:"total"`                // Number of records matching the filter, across all pages
+	NextCursor string          `json:"nextCursor,omitempty"` // Cursor of the next page, empty on the last page
+	Shift      *ShiftWindow    `json:"shift,omitempty"`      // Shift the records were selected for
+	Early      []TrainSchedule `json:"early,omitempty"`      // Records shortly before the shift (first page only)
+	Late       []TrainSchedule `json:"late,omitempty"`       // Records shortly after the shift (first page only)
+}
+
+// ShiftWindow is a shift placed on a specific day.
+type ShiftWindow struct {
+	Name  string    `json:"name"`  // Shift name
+	Start time.Time `json:"start"` // Shift start
+	End   time.Time `json:"end"`   // Shift end
+}
+
+// TrainScheduleFilter selects train schedule records.
+//
+// A record has a departure side (starting location, departure time, departure
+// train, starting track, departure employee) and an arrival side. Depot, time
+// range, train number, track and employee filters must all match the same
+// side, so "depot VL, track 3" finds trains leaving VL from track 3 and trains
+// arriving at VL on track 3, but not trains leaving VL that arrive elsewhere
+// on a track 3.
+type TrainScheduleFilter struct {
+	UserID      int        // Owner ID (0 for all records)
+	VisibleTo   int        // Only records this user owns or that touch their depots (0 for all records)
+	Deleted     bool       // List the records in the trash instead of the live ones
+	Published   bool       // Only records of published depot plans (for viewers)
+	Depot       string     // Starting or end location code
+	From        *time.Time // Departure or arrival at or after this time
+	To          *time.Time // Departure or arrival before this time
+	TrainNumber string     // Departure or arrival train number (prefix match)
+	VehicleName string     // Vehicle name (substring match)
+	Employee    string     // Departure or arrival employee (substring match)
+	Track       string     // Starting or target track
+	Sort        string     // time (default), departure, arrival, train_number, vehicle or updated
+	Descending  bool       // Reverse the sort order
+	Limit       int        // Page size (0 returns all matching records)
+	Cursor      string     // NextCursor of the previous page
+}
+
+// trainScheduleSort describes a sort order usable with cursor pagination.
+// The expression never returns NULL so that keyset comparisons work.
+type trainScheduleSort struct {
+	expr  string                        // SQL expression to sort by
+	value func(s *TrainSchedule) string // Value of expr for a loaded record
+}
+
+// noTime replaces missing times in sort keys, so records without times sort first.
+const noTime = "1000-01-01 00:00:00"
+
+// trainScheduleSorts lists the accepted sort orders. Ties are broken by ID.
+var trainScheduleSorts = map[string]trainScheduleSort{
+	"time": {
+		expr: "COALESCE(departure_date_time, arrival_date_time, '" + noTime + "')",
+		value: func(s *TrainSchedule) string {
+			if s.DepartureDateTime != nil {
+				return sortTime(s.DepartureDateTime)
+			}
+			return sortTime(s.ArrivalDateTime)
+		},
+	},
+	"departure": {
+		expr:  "COALESCE(departure_date_time, '" + noTime + "')",
+		value: func(s *TrainSchedule) string { return sortTime(s.DepartureDateTime) },
+	},
+	"arrival": {
+		expr:  "COALESCE(arrival_date_time, '" + noTime + "')",
+		value: func(s *TrainSchedule) string { return sortTime(s.ArrivalDateTime) },
+	},
+	"train_number": {
+		expr: "IF(train_number_departure <> '', train_number_departure, train_number_arrival)",
+		value: func(s *TrainSchedule) string {
+			if s.TrainNumberDeparture != "" {
+				return s.TrainNumberDeparture
+			}
+			return s.TrainNumberArrival
+		},
+	},
+	"vehicle": {
+		expr:  "vehicle_name",
+		value: func(s *TrainSchedule) string { return s.VehicleName },
+	},
+	"updated": {
+		expr:  "updated_at",
+		value: func(s *TrainSchedule) string { return sortTime(&s.UpdatedAt) },
+	},
+	"deleted": {
+		expr:  "COALESCE(deleted_at, '" + noTime + "')",
+		value: func(s *TrainSchedule) string { return sortTime(s.DeletedAt) },
+	},
+}
+
+// IsTrainScheduleSort reports whether name is an accepted sort order.
+func IsTrainScheduleSort(name string) bool {
+	_, ok := trainScheduleSorts[name]
+	return ok
+}
+
+// sortTime formats a time the way MySQL compares DATETIME values.
+func sortTime(t *time.Time) string {
+	if t == nil {
+		return noTime
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// trainScheduleCursor is the decoded form of a pagination cursor.
+// It holds the sort key and ID of the last record on the previous page.
+type trainScheduleCursor struct {
+	Sort       string `json:"s"`
+	Descending bool   `json:"d"`
+	Value      string `json:"v"`
+	ID         string `json:"id"`
+}
+
+// encode returns the cursor as an opaque URL-safe string.
+func (c trainScheduleCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeTrainScheduleCursor parses a cursor and checks that it matches the sort order.
+func decodeTrainScheduleCursor(value, sortName string, descending bool) (*trainScheduleCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor trainScheduleCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != sortName || cursor.Descending != descending || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// trainScheduleSide names the columns of one side of a record.
+type trainScheduleSide struct {
+	location    string
+	time        string
+	trainNumber string
+	track       string
+	employee    string
+}
+
+var (
+	departureSide = trainScheduleSide{
+		"starting_location", "departure_date_time", "train_number_departure", "starting_track", "employee1_departure",
+	}
+	arrivalSide = trainScheduleSide{
+		"end_location", "arrival_date_time", "train_number_arrival", "target_track", "employee1_arrival",
+	}
+)
+
+// likeEscaper escapes LIKE wildcards in user input.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// sideConditions returns the side filters for one side of a record, joined with AND.
+// Returns an empty string if no side filter is set.
+func (f TrainScheduleFilter) sideConditions(side trainScheduleSide, withDepot bool) (string, []any) {
+	var conditions []string
+	var params []any
+
+	if withDepot && f.Depot != "" {
+		conditions = append(conditions, side.location+" = ?")
+		params = append(params, f.Depot)
+	}
+	if f.From != nil {
+		conditions = append(conditions, side.time+" >= ?")
+		params = append(params, *f.From)
+	}
+	if f.To != nil {
+		conditions = append(conditions, side.time+" < ?")
+		params = append(params, *f.To)
+	}
+	if f.TrainNumber != "" {
+		conditions = append(conditions, side.trainNumber+" LIKE ?")
+		params = append(params, likeEscaper.Replace(f.TrainNumber)+"%")
+	}
+	if f.Track != "" {
+		conditions = append(conditions, side.track+" = ?")
+		params = append(params, f.Track)
+	}
+	if f.Employee != "" {
+		conditions = append(conditions, side.employee+" LIKE ?")
+		params = append(params, "%"+likeEscaper.Replace(f.Employee)+"%")
+	}
+
+	return strings.Join(conditions, " AND "), params
+}
+
+// where builds the WHERE clause shared by the list and count queries.
+func (f TrainScheduleFilter) where() (string, []any) {
+	clause := " WHERE deleted_at IS NULL"
+	if f.Deleted {
+		clause = " WHERE deleted_at IS NOT NULL"
+	}
+	var params []any
+
+	if f.UserID > 0 {
+		clause += " AND user_id = ?"
+		params = append(params, f.UserID)
+	}
+	if f.VisibleTo > 0 {
+		clause += " AND (user_id = ? OR starting_location IN (" + depotMemberCodes + ")" +
+			" OR end_location IN (" + depotMemberCodes + "))"
+		params = append(params, f.VisibleTo, f.VisibleTo, f.VisibleTo)
+	}
+	if f.Published {
+		clause += " AND " + publishedPlanCondition
+	}
+	if f.VehicleName != "" {
+		clause += " AND vehicle_name LIKE ?"
+		params = append(params, "%"+likeEscaper.Replace(f.VehicleName)+"%")
+	}
+
+	departure, departureParams := f.sideConditions(departureSide, true)
+	arrival, arrivalParams := f.sideConditions(arrivalSide, true)
+	if departure != "" {
+		clause += " AND ((" + departure + ") OR (" + arrival + "))"
+		params = append(params, departureParams...)
+		params = append(params, arrivalParams...)
+	}
+
+	return clause, params
+}
+
+// GetTrainSchedules retrieves one page of train schedule records matching the filter.
+// Pages are addressed by cursor rather than offset, so records added while a
+// client pages through the list neither repeat nor go missing.
+//
+// Parameters:
+//   - db: Database connection
+//   - filter: Filters, sort order and page position
+//
+// Returns:
+//   - Matching records with the total count and the next page cursor
+//   - ErrInvalidCursor if the cursor does not belong to this sort order
+//   - Error if the database operation fails
+func GetTrainSchedules(db *sql.DB, filter TrainScheduleFilter) (*TrainScheduleList, error) {
+	if filter.Sort == "" {
+		filter.Sort = "time"
+	}
+	sortOrder, ok := trainScheduleSorts[filter.Sort]
+	if !ok {
+		return nil, errors.New("unknown sort order: " + filter.Sort)
+	}
+
+	where, params := filter.where()
+
+	// Count all matching records, regardless of the page
+	list := &TrainScheduleList{Records: []TrainSchedule{}}
+	if err := db.QueryRow("SELECT COUNT(*) FROM train_schedules"+where, params...).Scan(&list.Total); err != nil {
+		return nil, err
+	}
+
+	direction, compare := "ASC", ">"
+	if filter.Descending {
+		direction, compare = "DESC", "<"
+	}
+
+	// Continue after the last record of the previous page
+	if filter.Cursor != "" {
+		cursor, err := decodeTrainScheduleCursor(filter.Cursor, filter.Sort, filter.Descending)
+		if err != nil {
+			return nil, err
+		}
+		where += " AND (" + sortOrder.expr + " " + compare + " ? OR (" +
+			sortOrder.expr + " = ? AND id " + compare + " ?))"
+		params = append(params, cursor.Value, cursor.Value, cursor.ID)
+	}
+
+	query := "SELECT " + trainScheduleSelectColumns + " FROM train_schedules" + where +
+		" ORDER BY " + sortOrder.expr + " " + direction + ", id " + direction
+
+	// Fetch one extra record to learn whether another page follows
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		params = append(params, filter.Limit+1)
+	}
+
+	rows, err := db.Query(query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		schedule, err := scanTrainSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		list.Records = append(list.Records, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if filter.Limit > 0 && len(list.Records) > filter.Limit {
+		list.Records = list.Records[:filter.Limit]
+		last := &list.Records[len(list.Records)-1]
+		list.NextCursor = trainScheduleCursor{
+			Sort:       filter.Sort,
+			Descending: filter.Descending,
+			Value:      sortOrder.value(last),
+			ID:         last.ID,
+		}.encode()
+	}
+
+	return list, nil
+}
+
+// GetTrainScheduleDepots returns the depot codes that appear in records
+// matching the filter, sorted alphabetically. A depot is listed if some
+// record departs from it or arrives at it within the filtered time range.
+// The Depot, sort and page fields of the filter are ignored.
+//
+// Parameters:
+//   - db: Database connection
+//   - filter: Filters applied to the records
+//
+// Returns:
+//   - Depot codes
+//   - Error if the database operation fails
+func GetTrainScheduleDepots(db *sql.DB, filter TrainScheduleFilter) ([]string, error) {
+	var parts []string
+	var params []any
+	for _, side := range []trainScheduleSide{departureSide, arrivalSide} {
+		// Only this side has to match here, so the shared WHERE is built without side filters
+		sideFilter := TrainScheduleFilter{
+			UserID:      filter.UserID,
+			VisibleTo:   filter.VisibleTo,
+			Published:   filter.Published,
+			VehicleName: filter.VehicleName,
+		}
+		where, whereParams := sideFilter.where()
+
+		where += " AND " + side.location + " <> ''"
+		conditions, sideParams := filter.sideConditions(side, false)
+		if conditions != "" {
+			where += " AND " + conditions
+		}
+
+		parts = append(parts, "SELECT "+side.location+" AS depot FROM train_schedules"+where)
+		params = append(params, whereParams...)
+		params = append(params, sideParams...)
+	}
+
+	rows, err := db.Query(strings.Join(parts, " UNION ")+" ORDER BY depot", params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	depots := []string{}
+	for rows.Next() {
+		var depot string
+		if err := rows.Scan(&depot); err != nil {
+			return nil, err
+		}
+		depots = append(depots, depot)
+	}
+
+	return depots, rows.Err()
+}
+
+// GetTrainSchedulesInShift retrieves the records of a depot shift on the given day.
+// The shift window replaces the filter's time range. The first page also
+// carries the early and late spill-over: records within the shift's early
+// minutes before its start and late minutes after its end, in time order.
+//
+// Parameters:
+//   - db: Database connection
+//   - filter: Filters, sort order and page position; From and To are ignored
+//   - shift: Shift definition of the filtered depot
+//   - day: Day the shift starts on
+//
+// Returns:
+//   - Matching records with shift window and spill-over
+//   - Error if the shift is invalid or the database operation fails
+func GetTrainSchedulesInShift(
+	db *sql.DB,
+	filter TrainScheduleFilter,
+	shift StationShift,
+	day time.Time,
+) (*TrainScheduleList, error) {
+	start, end, err := shift.Window(day)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.From, filter.To = &start, &end
+	list, err := GetTrainSchedules(db, filter)
+	if err != nil {
+		return nil, err
+	}
+	list.Shift = &ShiftWindow{Name: shift.Name, Start: start, End: end}
+
+	if filter.Cursor != "" {
+		return list, nil
+	}
+
+	// Spill-over lists are short, so they are returned whole and in time order
+	spill := filter
+	spill.Sort, spill.Descending, spill.Limit = "time", false, 0
+
+	if shift.EarlyMinutes > 0 {
+		from := start.Add(-time.Duration(shift.EarlyMinutes) * time.Minute)
+		spill.From, spill.To = &from, &start
+		early, err := GetTrainSchedules(db, spill)
+		if err != nil {
+			return nil, err
+		}
+		list.Early = early.Records
+	}
+
+	if shift.LateMinutes > 0 {
+		to := end.Add(time.Duration(shift.LateMinutes) * time.Minute)
+		spill.From, spill.To = &end, &to
+		late, err := GetTrainSchedules(db, spill)
+		if err != nil {
+			return nil, err
+		}
+		list.Late = late.Records
+	}
+
+	return list, nil
+}
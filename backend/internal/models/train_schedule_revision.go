@@ -0,0 +1,494 @@
+// backend/internal/models/train_schedule_revision.go
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Revision actions
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRevert  = "revert"
+	RevisionMerge   = "merge"
+	RevisionRestore = "restore"
+)
+
+// TrainScheduleRevision is one entry of a train schedule record's change history.
+// Update and revert entries describe a single field; create and delete entries
+// hold the whole record as JSON. A merge entry of a duplicate holds its last
+// state and the ID of the record it was merged into; a merge entry with field
+// "id" records the surviving record getting its derived ID.
+type TrainScheduleRevision struct {
+	ID                 int64     `json:"id"`
+	ScheduleID         string    `json:"scheduleId"`
+	Action             string    `json:"action"`
+	Field              string    `json:"field,omitempty"`
+	OldValue           *string   `json:"oldValue"`
+	NewValue           *string   `json:"newValue"`
+	RevertedRevisionID *int64    `json:"revertedRevisionId,omitempty"`
+	UserID             *int      `json:"userId"`
+	Username           string    `json:"username,omitempty"`
+	RequestID          string    `json:"requestId"`
+	CreatedAt          time.Time `json:"createdAt"`
+}
+
+// trainScheduleColumn links a TrainSchedule JSON field to its database column.
+type trainScheduleColumn struct {
+	Field  string
+	Column string
+	Size   int // Longest value the column holds in characters, 0 for timestamps
+}
+
+// trainScheduleColumns lists the fields tracked in the revision history.
+// Identity, ownership, timestamps and raw import data are not tracked.
+var trainScheduleColumns = []trainScheduleColumn{
+	{"trainNumberDeparture", "train_number_departure", 50},
+	{"trainNumberArrival", "train_number_arrival", 50},
+	{"vehicleName", "vehicle_name", 100},
+	{"startingLocation", "starting_location", 50},
+	{"endLocation", "end_location", 50},
+	{"departureDateTime", "departure_date_time", 0},
+	{"arrivalDateTime", "arrival_date_time", 0},
+	{"actualDepartureDateTime", "actual_departure_date_time", 0},
+	{"actualArrivalDateTime", "actual_arrival_date_time", 0},
+	{"delayReason", "delay_reason", 255},
+	{"startingTrack", "starting_track", 50},
+	{"targetTrack", "target_track", 50},
+	{"employee1Departure", "employee1_departure", 255},
+	{"employee1Arrival", "employee1_arrival", 255},
+	{"dutyDeparture", "duty_departure", 100},
+	{"dutyArrival", "duty_arrival", 100},
+	{"notes", "notes", 16383}, // TEXT holds 65535 bytes of up to 4-byte characters
+}
+
+// trainScheduleColumnOf returns the column of a tracked field.
+func trainScheduleColumnOf(field string) (trainScheduleColumn, bool) {
+	for _, c := range trainScheduleColumns {
+		if c.Field == field {
+			return c, true
+		}
+	}
+	return trainScheduleColumn{}, false
+}
+
+// setTrainScheduleField stores a revision-formatted value in the column of a
+// tracked field and bumps the record version. Every single-field change goes
+// through here, so only known columns are ever written.
+func setTrainScheduleField(tx *sql.Tx, id, field string, value *string) error {
+	c, ok := trainScheduleColumnOf(field)
+	if !ok {
+		return fmt.Errorf("unknown train schedule field %q", field)
+	}
+
+	dbValue, err := trainScheduleColumnValue(field, value)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE train_schedules SET "+c.Column+" = ?, updated_at = NOW(), version = version + 1 WHERE id = ?",
+		dbValue, id,
+	)
+	return err
+}
+
+// trainScheduleFieldValue returns a field value as stored in the revision history.
+// Timestamps are written in UTC (RFC 3339), a missing timestamp is nil.
+func trainScheduleFieldValue(s *TrainSchedule, field string) *string {
+	var value string
+	switch field {
+	case "trainNumberDeparture":
+		value = s.TrainNumberDeparture
+	case "trainNumberArrival":
+		value = s.TrainNumberArrival
+	case "vehicleName":
+		value = s.VehicleName
+	case "startingLocation":
+		value = s.StartingLocation
+	case "endLocation":
+		value = s.EndLocation
+	case "departureDateTime":
+		return formatRevisionTime(s.DepartureDateTime)
+	case "arrivalDateTime":
+		return formatRevisionTime(s.ArrivalDateTime)
+	case "actualDepartureDateTime":
+		return formatRevisionTime(s.ActualDepartureDateTime)
+	case "actualArrivalDateTime":
+		return formatRevisionTime(s.ActualArrivalDateTime)
+	case "delayReason":
+		value = s.DelayReason
+	case "startingTrack":
+		value = s.StartingTrack
+	case "targetTrack":
+		value = s.TargetTrack
+	case "employee1Departure":
+		value = s.Employee1Departure
+	case "employee1Arrival":
+		value = s.Employee1Arrival
+	case "dutyDeparture":
+		value = s.DutyDeparture
+	case "dutyArrival":
+		value = s.DutyArrival
+	case "notes":
+		value = s.Notes
+	default:
+		return nil
+	}
+	return &value
+}
+
+// trainScheduleColumnValue converts a revision value back into a database value.
+func trainScheduleColumnValue(field string, value *string) (any, error) {
+	switch field {
+	case "departureDateTime", "arrivalDateTime", "actualDepartureDateTime", "actualArrivalDateTime":
+		if value == nil {
+			return nil, nil
+		}
+		t, err := time.Parse(time.RFC3339, *value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stored time %q for %s: %w", *value, field, err)
+		}
+		return t, nil
+	}
+	if value == nil {
+		return "", nil
+	}
+	return *value, nil
+}
+
+// formatRevisionTime formats an optional timestamp for the revision history.
+func formatRevisionTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	value := t.UTC().Format(time.RFC3339)
+	return &value
+}
+
+// sameRevisionValue compares two optional revision values.
+func sameRevisionValue(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// trainScheduleChanges builds an update revision for every tracked
+// field that differs between the old and the new record.
+func trainScheduleChanges(before, after *TrainSchedule) []TrainScheduleRevision {
+	var revisions []TrainScheduleRevision
+	for _, c := range trainScheduleColumns {
+		oldValue := trainScheduleFieldValue(before, c.Field)
+		newValue := trainScheduleFieldValue(after, c.Field)
+		if sameRevisionValue(oldValue, newValue) {
+			continue
+		}
+		revisions = append(revisions, TrainScheduleRevision{
+			ScheduleID: before.ID,
+			Action:     RevisionUpdate,
+			Field:      c.Field,
+			OldValue:   oldValue,
+			NewValue:   newValue,
+		})
+	}
+	return revisions
+}
+
+// trainScheduleSnapshot builds a create or delete revision holding the whole record.
+func trainScheduleSnapshot(action string, s *TrainSchedule) (TrainScheduleRevision, error) {
+	revision := TrainScheduleRevision{ScheduleID: s.ID, Action: action}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return revision, err
+	}
+	snapshot := string(data)
+
+	if action == RevisionDelete {
+		revision.OldValue = &snapshot
+	} else {
+		revision.NewValue = &snapshot
+	}
+	return revision, nil
+}
+
+// recordTrainScheduleSnapshot writes a create or delete revision holding the whole record.
+func recordTrainScheduleSnapshot(tx *sql.Tx, action string, s *TrainSchedule, editor Editor) error {
+	revision, err := trainScheduleSnapshot(action, s)
+	if err != nil {
+		return err
+	}
+	return insertTrainScheduleRevision(tx, &revision, editor)
+}
+
+// insertTrainScheduleRevision stores a single revision row.
+func insertTrainScheduleRevision(tx *sql.Tx, r *TrainScheduleRevision, editor Editor) error {
+	return insertTrainScheduleRevisions(tx, []TrainScheduleRevision{*r}, editor)
+}
+
+// revisionInsertBatch limits rows per INSERT to stay well below
+// the 65535 placeholder limit of a MySQL prepared statement.
+const revisionInsertBatch = 1000
+
+// insertTrainScheduleRevisions stores revision rows with multi-row INSERTs.
+func insertTrainScheduleRevisions(tx *sql.Tx, revisions []TrainScheduleRevision, editor Editor) error {
+	var user any
+	if editor.UserID > 0 {
+		user = editor.UserID
+	}
+
+	for start := 0; start < len(revisions); start += revisionInsertBatch {
+		end := min(start+revisionInsertBatch, len(revisions))
+		batch := revisions[start:end]
+
+		query := `
+			INSERT INTO train_schedule_revisions
+				(schedule_id, action, field, old_value, new_value, reverted_revision_id, user_id, request_id)
+			VALUES ` + placeholderRows(len(batch), 8)
+		args := make([]any, 0, len(batch)*8)
+		for _, r := range batch {
+			args = append(args,
+				r.ScheduleID, r.Action, r.Field, r.OldValue, r.NewValue, r.RevertedRevisionID, user, editor.RequestID,
+			)
+		}
+
+		if _, err := tx.Exec(query, args...); err != nil {
+			return fmt.Errorf("failed to record train schedule revisions: %w", err)
+		}
+	}
+	return nil
+}
+
+// placeholderRows builds "(?, ?), (?, ?)" for multi-row statements.
+func placeholderRows(rows, columns int) string {
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", columns), ", ") + ")"
+	return strings.TrimSuffix(strings.Repeat(row+", ", rows), ", ")
+}
+
+// GetTrainScheduleRevisions returns the change history of a record, newest first.
+//
+// Parameters:
+//   - db: Database connection
+//   - scheduleID: Train schedule record ID
+//
+// Returns:
+//   - Revisions of the record (empty if it was never changed)
+//   - Error if the database operation fails
+func GetTrainScheduleRevisions(db *sql.DB, scheduleID string) ([]TrainScheduleRevision, error) {
+	rows, err := db.Query(`
+		SELECT
+			r.id, r.schedule_id, r.action, r.field, r.old_value, r.new_value,
+			r.reverted_revision_id, r.user_id, COALESCE(u.username, ''), r.request_id, r.created_at
+		FROM train_schedule_revisions r
+		LEFT JOIN users u ON u.id = r.user_id
+		WHERE r.schedule_id = ?
+		ORDER BY r.id DESC
+	`, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query train schedule revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []TrainScheduleRevision
+	for rows.Next() {
+		var r TrainScheduleRevision
+		if err := rows.Scan(
+			&r.ID, &r.ScheduleID, &r.Action, &r.Field, &r.OldValue, &r.NewValue,
+			&r.RevertedRevisionID, &r.UserID, &r.Username, &r.RequestID, &r.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan train schedule revision: %w", err)
+		}
+		revisions = append(revisions, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating train schedule revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// TrainScheduleHistoryRecord returns a record for history access checks.
+// For deleted or merged-away records their last snapshot is returned.
+//
+// Returns:
+//   - The record, or its last snapshot
+//   - sql.ErrNoRows if the record never existed
+func TrainScheduleHistoryRecord(db *sql.DB, scheduleID string) (*TrainSchedule, error) {
+	record, err := GetTrainScheduleByID(db, scheduleID)
+	if err == nil {
+		return record, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	var snapshot string
+	err = db.QueryRow(`
+		SELECT old_value FROM train_schedule_revisions
+		WHERE schedule_id = ? AND action IN (?, ?) AND field = ''
+		ORDER BY id DESC LIMIT 1
+	`, scheduleID, RevisionDelete, RevisionMerge).Scan(&snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted TrainSchedule
+	if err := json.Unmarshal([]byte(snapshot), &deleted); err != nil {
+		return nil, fmt.Errorf("failed to read deleted record: %w", err)
+	}
+	return &deleted, nil
+}
+
+// RevertTrainSchedule restores a record to its state right after the given revision.
+// Every field changed by later revisions gets the value it had at that point;
+// each restored field is recorded as a revert revision, so reverts can be undone too.
+// A revert is checked like any other change: it may not overwrite a newer
+// version, move the record out of the user's depots or park a vehicle against
+// the rules of a track.
+//
+// Parameters:
+//   - db: Database connection
+//   - scheduleID: Train schedule record ID
+//   - revisionID: Revision to go back to
+//   - expectedVersion: Version the client saw (0 skips the check)
+//   - editor: User performing the revert
+//
+// Returns:
+//   - The record after the revert
+//   - sql.ErrNoRows if the record or revision does not exist or the user may not edit it
+//   - ErrPlanPublished if the record belongs to a published depot plan
+//   - ErrScheduleForbidden if the revert moves the record out of the depots the user edits
+//   - *TrackConflictError if the revert causes conflicts on tracks without exceptions
+//   - Error if the database operation fails (*VersionConflictError if the record was changed meanwhile)
+func RevertTrainSchedule(
+	db *sql.DB,
+	scheduleID string,
+	revisionID int64,
+	expectedVersion int64,
+	editor Editor,
+) (*TrainSchedule, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := getTrainScheduleForUpdate(tx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if !editor.canEdit(current) {
+		return nil, sql.ErrNoRows // Use standard error for security
+	}
+	if err := checkVersion("train_schedule", scheduleID, expectedVersion, current.Version); err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	err = tx.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM train_schedule_revisions WHERE id = ? AND schedule_id = ?)",
+		revisionID, scheduleID,
+	).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	// The first later change of each field holds the value the field had at the chosen revision
+	rows, err := tx.Query(`
+		SELECT field, old_value
+		FROM train_schedule_revisions
+		WHERE schedule_id = ? AND id > ? AND field <> ''
+		ORDER BY id ASC
+	`, scheduleID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]*string)
+	for rows.Next() {
+		var field string
+		var oldValue *string
+		if err := rows.Scan(&field, &oldValue); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if _, seen := targets[field]; !seen {
+			targets[field] = oldValue
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Conflicts the record already has do not block the revert
+	before, err := trackConflicts(tx, current)
+	if err != nil {
+		return nil, err
+	}
+
+	parkingChanged := false
+	for _, c := range trainScheduleColumns {
+		target, changed := targets[c.Field]
+		if !changed {
+			continue
+		}
+		currentValue := trainScheduleFieldValue(current, c.Field)
+		if sameRevisionValue(currentValue, target) {
+			continue
+		}
+		parkingChanged = parkingChanged || parkingFields[c.Field]
+
+		if err := setTrainScheduleField(tx, scheduleID, c.Field, target); err != nil {
+			return nil, err
+		}
+
+		reverted := revisionID
+		err = insertTrainScheduleRevision(tx, &TrainScheduleRevision{
+			ScheduleID:         scheduleID,
+			Action:             RevisionRevert,
+			Field:              c.Field,
+			OldValue:           currentValue,
+			NewValue:           target,
+			RevertedRevisionID: &reverted,
+		}, editor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reverted, err := getTrainScheduleForUpdate(tx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPlansOpen(tx, current, reverted); err != nil {
+		return nil, err
+	}
+	if !editor.canSave(current, reverted) {
+		return nil, ErrScheduleForbidden
+	}
+	if parkingChanged {
+		after, err := trackConflicts(tx, reverted)
+		if err != nil {
+			return nil, err
+		}
+		if blocking := blockingConflicts(before, after); len(blocking) > 0 {
+			return nil, &TrackConflictError{Conflicts: blocking}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return reverted, nil
+}
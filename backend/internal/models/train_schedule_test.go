@@ -0,0 +1,70 @@
+// backend/internal/models/train_schedule_test.go
+package models
+
+import "testing"
+
+func TestEditorCanSave(t *testing.T) {
+	owner, other := 1, 2
+	record := func(from, to string, userID *int) *TrainSchedule {
+		return &TrainSchedule{StartingLocation: from, EndLocation: to, UserID: userID}
+	}
+	editor := Editor{UserID: owner, Depots: map[string]string{"VL": DepotRoleEditor, "KN": DepotRoleViewer}}
+	admin := Editor{UserID: other, IsAdmin: true}
+
+	tests := []struct {
+		name              string
+		editor            Editor
+		existing, updated *TrainSchedule
+		want              bool
+	}{
+		{"new record in an edited depot", editor, nil, record("VL", "KN", nil), true},
+		{"new record in a viewed depot", editor, nil, record("KN", "KN", nil), false},
+		{"new record outside the depots", editor, nil, record("XX", "YY", nil), false},
+		{"new record by an admin", admin, nil, record("XX", "YY", nil), true},
+		{"own record kept in place", editor, record("XX", "YY", &owner), record("XX", "YY", &owner), true},
+		{"own record moved out of the depots", editor, record("VL", "YY", &owner), record("XX", "YY", &owner), false},
+		{"record moved within an edited depot", editor, record("VL", "YY", &other), record("VL", "KN", &other), true},
+		{"record of another user outside the depots", editor, record("XX", "YY", &other), record("XX", "YY", &other), false},
+		{"record without an owner", editor, record("XX", "YY", nil), record("XX", "YY", nil), false},
+		{"record only viewed", editor, record("KN", "YY", &other), record("KN", "YY", &other), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.editor.canSave(tt.existing, tt.updated); got != tt.want {
+				t.Errorf("canSave() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditableChangeError(t *testing.T) {
+	limit := 3
+	editable := map[string]*EditableField{
+		"targetTrack":     {FieldName: "targetTrack", ValueType: EditableText, MaxLength: &limit},
+		"arrivalDateTime": {FieldName: "arrivalDateTime", ValueType: EditableDateTime, IsRequired: true},
+	}
+	change := func(field string, value *string) []TrainScheduleRevision {
+		return []TrainScheduleRevision{{Field: field, NewValue: value}}
+	}
+	text := func(value string) *string { return &value }
+
+	tests := []struct {
+		name    string
+		changes []TrainScheduleRevision
+		want    string
+	}{
+		{"no changes", nil, ""},
+		{"editable field", change("targetTrack", text("3.2")), ""},
+		{"field that is not editable", change("vehicleName", text("ER20-001")), SaveErrorForbidden},
+		{"value too long", change("targetTrack", text("12.10")), SaveErrorInvalid},
+		{"time", change("arrivalDateTime", text("2025-07-01T18:00:00Z")), ""},
+		{"required time cleared", change("arrivalDateTime", nil), SaveErrorInvalid},
+	}
+
+	for _, tt := range tests {
+		if code, _ := editableChangeError(editable, tt.changes); code != tt.want {
+			t.Errorf("%s: editableChangeError() = %q, want %q", tt.name, code, tt.want)
+		}
+	}
+}
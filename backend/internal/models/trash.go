@@ -0,0 +1,243 @@
+// backend/internal/models/trash.go
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// DefaultTrashRetentionDays is how long deleted records stay in the trash
+// when no retention is configured.
+const DefaultTrashRetentionDays = 30
+
+// TrashPurge counts the rows removed from the trash for good.
+type TrashPurge struct {
+	Schedules int64 `json:"schedules"` // Train schedule records
+	Stations  int64 `json:"stations"`  // Stations, with their tracks, shifts and depot teams
+}
+
+// RestoreTrainSchedule takes a record out of the trash. The restore is
+// written to the revision history with the restored record. A restored
+// record parks its vehicles again, so it is checked against the rules of
+// the tracks like any other change.
+//
+// Parameters:
+//   - db: Database connection
+//   - id: ID of the record in the trash
+//   - editor: User restoring the record (must be allowed to edit it)
+//
+// Returns:
+//   - The restored record
+//   - sql.ErrNoRows if the record is not in the trash or the user may not edit it
+//   - ErrPlanPublished if the record belongs to a published depot plan
+//   - *TrackConflictError if the restored record causes conflicts on tracks without exceptions
+func RestoreTrainSchedule(db *sql.DB, id string, editor Editor) (*TrainSchedule, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	trashed, err := scanTrainSchedule(tx.QueryRow(
+		"SELECT "+trainScheduleSelectColumns+" FROM train_schedules WHERE id = ? AND deleted_at IS NOT NULL FOR UPDATE",
+		id,
+	))
+	if err != nil {
+		return nil, err
+	}
+	if !editor.canEdit(&trashed) {
+		return nil, sql.ErrNoRows // Use standard error for security
+	}
+	if err := checkPlansOpen(tx, &trashed); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE train_schedules SET deleted_at = NULL, deleted_by = NULL, version = version + 1 WHERE id = ?",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	restored, err := getTrainScheduleForUpdate(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Conflicts are read from the stored movements, so the record is checked
+	// once it is back; a blocking conflict rolls the restore back
+	conflicts, err := trackConflicts(tx, restored)
+	if err != nil {
+		return nil, err
+	}
+	if blocking := blockingConflicts(nil, conflicts); len(blocking) > 0 {
+		return nil, &TrackConflictError{Conflicts: blocking}
+	}
+
+	if err := recordTrainScheduleSnapshot(tx, RevisionRestore, restored, editor); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// PurgeTrainSchedule removes a record from the trash for good. Its revision
+// history is kept, so the deleted record can still be looked up there.
+// Returns sql.ErrNoRows if the record is not in the trash.
+func PurgeTrainSchedule(db *sql.DB, id string) error {
+	result, err := db.Exec("DELETE FROM train_schedules WHERE id = ? AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+// lockTrashedTrainSchedules reads the trashed records with the given IDs and
+// locks them until the transaction ends.
+func lockTrashedTrainSchedules(tx *sql.Tx, ids []string) (map[string]*TrainSchedule, error) {
+	return lockTrainScheduleRows(tx, ids, "deleted_at IS NOT NULL")
+}
+
+// purgeTrashedTrainSchedules removes the trashed copies of the given records,
+// so records saved again after deletion can take their IDs back. Callers
+// check first that the editor may edit the trashed copies.
+func purgeTrashedTrainSchedules(tx *sql.Tx, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	_, err := tx.Exec(
+		"DELETE FROM train_schedules WHERE id IN ("+
+			strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")+") AND deleted_at IS NOT NULL",
+		args...,
+	)
+	return err
+}
+
+// RestoreStation takes a station out of the trash with its tracks, shifts
+// and depot team.
+// Returns sql.ErrNoRows if the station is not in the trash.
+func RestoreStation(db *sql.DB, id int) error {
+	result, err := db.Exec(
+		"UPDATE stations SET deleted_at = NULL, deleted_by = NULL, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+// PurgeStation removes a station from the trash for good, together with its
+// tracks, shifts and depot team.
+// Returns sql.ErrNoRows if the station is not in the trash.
+func PurgeStation(db *sql.DB, id int) error {
+	result, err := db.Exec("DELETE FROM stations WHERE id = ? AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result)
+}
+
+// StationCodeInTrash reports whether a station in the trash holds the code.
+// Station codes are unique, so the code cannot be reused until that station
+// is restored or purged.
+func StationCodeInTrash(db *sql.DB, code string) (bool, error) {
+	var exists bool
+	err := db.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM stations WHERE code = ? AND deleted_at IS NOT NULL)", code,
+	).Scan(&exists)
+	return exists, err
+}
+
+// PurgeTrash removes the records and stations deleted before the given time.
+//
+// Parameters:
+//   - db: Database connection
+//   - before: Rows moved to the trash before this time are purged
+//
+// Returns:
+//   - Number of purged records and stations
+//   - Error if the database operation fails
+func PurgeTrash(db *sql.DB, before time.Time) (TrashPurge, error) {
+	var purged TrashPurge
+
+	result, err := db.Exec("DELETE FROM train_schedules WHERE deleted_at < ?", before)
+	if err != nil {
+		return purged, fmt.Errorf("failed to purge train schedules: %w", err)
+	}
+	if purged.Schedules, err = result.RowsAffected(); err != nil {
+		return purged, err
+	}
+
+	result, err = db.Exec("DELETE FROM stations WHERE deleted_at < ?", before)
+	if err != nil {
+		return purged, fmt.Errorf("failed to purge stations: %w", err)
+	}
+	if purged.Stations, err = result.RowsAffected(); err != nil {
+		return purged, err
+	}
+
+	return purged, nil
+}
+
+// PurgeExpiredTrash purges the rows that stayed in the trash longer than the
+// trash_retention_days system setting allows. The trash keeps rows for at
+// least a day, so a zero or negative setting does not empty it.
+func PurgeExpiredTrash(db *sql.DB) (TrashPurge, error) {
+	days, err := GetSystemSettingInt(db, "trash_retention_days", DefaultTrashRetentionDays)
+	if err != nil {
+		return TrashPurge{}, err
+	}
+	if days < 1 {
+		days = 1
+	}
+	return PurgeTrash(db, time.Now().AddDate(0, 0, -days))
+}
+
+// StartTrashPurge purges expired trash once at start and then at every interval.
+// The purge runs in the background for the lifetime of the process.
+func StartTrashPurge(db *sql.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			purged, err := PurgeExpiredTrash(db)
+			if err != nil {
+				log.Printf("Klaida valant šiukšliadėžę: %v", err) // Error purging the trash
+			} else if purged.Schedules > 0 || purged.Stations > 0 {
+				log.Printf(
+					"Šiukšliadėžė išvalyta: %d įrašai, %d stotys",
+					purged.Schedules,
+					purged.Stations,
+				) // Trash purged: records, stations
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// requireAffected returns sql.ErrNoRows if a statement changed no rows.
+func requireAffected(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
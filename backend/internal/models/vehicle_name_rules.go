@@ -0,0 +1,190 @@
+// backend/internal/models/vehicle_name_rules.go
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// VehicleNameRule describes how a vehicle display name is built from the
+// technical vehicle type and vehicle number columns of an import.
+// Rules are tried in sort order; the first one whose type pattern matches wins.
+type VehicleNameRule struct {
+	ID               int       `json:"id"`
+	Name             string    `json:"name"`
+	TypePattern      string    `json:"type_pattern"`
+	Strategy         string    `json:"strategy"`
+	MatchPattern     string    `json:"match_pattern"`
+	OutputTemplate   string    `json:"output_template"`
+	FallbackTemplate string    `json:"fallback_template"`
+	SortOrder        int       `json:"sort_order"`
+	IsActive         bool      `json:"is_active"`
+	Description      *string   `json:"description,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// GetAllVehicleNameRules retrieves all vehicle name rules ordered by sort_order
+func GetAllVehicleNameRules(db *sql.DB) ([]VehicleNameRule, error) {
+	return queryVehicleNameRules(db, "")
+}
+
+// GetActiveVehicleNameRules retrieves the rules used by imports
+func GetActiveVehicleNameRules(db *sql.DB) ([]VehicleNameRule, error) {
+	return queryVehicleNameRules(db, "WHERE is_active = TRUE")
+}
+
+// queryVehicleNameRules runs the rule listing query with an optional WHERE clause
+func queryVehicleNameRules(db *sql.DB, where string) ([]VehicleNameRule, error) {
+	query := `
+		SELECT
+			id, name, type_pattern, strategy, match_pattern,
+			output_template, fallback_template, sort_order, is_active,
+			description, created_at, updated_at
+		FROM vehicle_name_rules
+		` + where + `
+		ORDER BY sort_order ASC, id ASC
+	`
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vehicle name rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []VehicleNameRule
+	for rows.Next() {
+		var rule VehicleNameRule
+		err := rows.Scan(
+			&rule.ID, &rule.Name, &rule.TypePattern, &rule.Strategy, &rule.MatchPattern,
+			&rule.OutputTemplate, &rule.FallbackTemplate, &rule.SortOrder, &rule.IsActive,
+			&rule.Description, &rule.CreatedAt, &rule.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan vehicle name rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating vehicle name rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// GetVehicleNameRuleByID retrieves a single vehicle name rule by ID
+func GetVehicleNameRuleByID(db *sql.DB, id int) (*VehicleNameRule, error) {
+	query := `
+		SELECT
+			id, name, type_pattern, strategy, match_pattern,
+			output_template, fallback_template, sort_order, is_active,
+			description, created_at, updated_at
+		FROM vehicle_name_rules
+		WHERE id = ?
+	`
+
+	var rule VehicleNameRule
+	err := db.QueryRow(query, id).Scan(
+		&rule.ID, &rule.Name, &rule.TypePattern, &rule.Strategy, &rule.MatchPattern,
+		&rule.OutputTemplate, &rule.FallbackTemplate, &rule.SortOrder, &rule.IsActive,
+		&rule.Description, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("vehicle name rule not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vehicle name rule: %w", err)
+	}
+
+	return &rule, nil
+}
+
+// CreateVehicleNameRule creates a new vehicle name rule
+func CreateVehicleNameRule(db *sql.DB, rule *VehicleNameRule) error {
+	query := `
+		INSERT INTO vehicle_name_rules
+			(name, type_pattern, strategy, match_pattern, output_template,
+			 fallback_template, sort_order, is_active, description)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := db.Exec(
+		query,
+		rule.Name, rule.TypePattern, rule.Strategy, rule.MatchPattern, rule.OutputTemplate,
+		rule.FallbackTemplate, rule.SortOrder, rule.IsActive, rule.Description,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create vehicle name rule: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	rule.ID = int(id)
+	return nil
+}
+
+// UpdateVehicleNameRule updates an existing vehicle name rule
+func UpdateVehicleNameRule(db *sql.DB, id int, rule *VehicleNameRule) error {
+	query := `
+		UPDATE vehicle_name_rules
+		SET
+			name = ?,
+			type_pattern = ?,
+			strategy = ?,
+			match_pattern = ?,
+			output_template = ?,
+			fallback_template = ?,
+			sort_order = ?,
+			is_active = ?,
+			description = ?
+		WHERE id = ?
+	`
+
+	result, err := db.Exec(
+		query,
+		rule.Name, rule.TypePattern, rule.Strategy, rule.MatchPattern, rule.OutputTemplate,
+		rule.FallbackTemplate, rule.SortOrder, rule.IsActive, rule.Description, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update vehicle name rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("vehicle name rule not found")
+	}
+
+	rule.ID = id
+	return nil
+}
+
+// DeleteVehicleNameRule deletes a vehicle name rule by ID
+func DeleteVehicleNameRule(db *sql.DB, id int) error {
+	query := `DELETE FROM vehicle_name_rules WHERE id = ?`
+
+	result, err := db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete vehicle name rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("vehicle name rule not found")
+	}
+
+	return nil
+}
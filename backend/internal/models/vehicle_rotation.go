@@ -0,0 +1,190 @@
+// backend/internal/models/vehicle_rotation.go
+package models
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+)
+
+// Problems found in a vehicle rotation
+const (
+	RotationGap      = "gap"      // The vehicle leaves from a location other than where it arrived
+	RotationOverlap  = "overlap"  // The vehicle leaves before it arrived, so it is double-booked
+	RotationStranded = "stranded" // No train takes the vehicle out after it arrived
+)
+
+// RotationLookaround is how far before and after the requested range records
+// are read, so the stops at the range edges get their arrival and departure.
+// A vehicle not taken out within this time after the range is stranded.
+const RotationLookaround = 48 * time.Hour
+
+// VehicleRotationStop is the time a vehicle spends at one location between
+// the train that brings it there and the train that takes it out.
+type VehicleRotationStop struct {
+	Location     string         `json:"location"`     // Where the vehicle arrives (or departs from at the chain start)
+	Arrival      *TrainSchedule `json:"arrival"`      // Record bringing the vehicle in, nil at the chain start
+	Departure    *TrainSchedule `json:"departure"`    // Record taking the vehicle out, nil if there is none
+	ArrivedAt    *time.Time     `json:"arrivedAt"`    // Arrival time
+	DepartsAt    *time.Time     `json:"departsAt"`    // Departure time
+	DwellMinutes *int           `json:"dwellMinutes"` // Time between arrival and departure, negative for overlaps
+	Issues       []string       `json:"issues"`       // Problems found at this stop
+}
+
+// VehicleRotation is the chain of stops of one vehicle over a time range.
+type VehicleRotation struct {
+	VehicleName string                `json:"vehicleName"` // Vehicle name
+	Stops       []VehicleRotationStop `json:"stops"`       // Stops in time order
+	Issues      int                   `json:"issues"`      // Number of stops with problems
+	Unscheduled []TrainSchedule       `json:"unscheduled"` // Records of the vehicle without any times, left out of the chain
+}
+
+// GetVehicleRotations builds the rotation chain of every vehicle that runs
+// within the time range.
+//
+// Each record moves a vehicle from its starting location (departure) to its
+// end location (arrival). Ordered by time, the arrival of one record and the
+// departure of the next form a stop, with the dwell time between them. A stop
+// is reported when the vehicle leaves from another location than where it
+// arrived (gap), leaves before it arrived (overlap), or is not taken out
+// within RotationLookaround after arriving (stranded).
+//
+// Only the owner, visibility (including Published) and vehicle name of the
+// filter are used: other filters would drop records from the middle of a chain.
+//
+// Parameters:
+//   - db: Database connection
+//   - filter: Owner, visibility and vehicle name (substring match) of the records
+//   - from, to: Time range; stops overlapping it are returned
+//
+// Returns:
+//   - Rotations ordered by vehicle name
+//   - Error if the database operation fails
+func GetVehicleRotations(db *sql.DB, filter TrainScheduleFilter, from, to time.Time) ([]VehicleRotation, error) {
+	readFrom, readTo := from.Add(-RotationLookaround), to.Add(RotationLookaround)
+	list, err := GetTrainSchedules(db, TrainScheduleFilter{
+		UserID:      filter.UserID,
+		VisibleTo:   filter.VisibleTo,
+		Published:   filter.Published,
+		VehicleName: filter.VehicleName,
+		From:        &readFrom,
+		To:          &readTo,
+		Sort:        "time",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byVehicle := make(map[string][]TrainSchedule)
+	for _, record := range list.Records {
+		if record.VehicleName == "" {
+			continue
+		}
+		byVehicle[record.VehicleName] = append(byVehicle[record.VehicleName], record)
+	}
+
+	names := make([]string, 0, len(byVehicle))
+	for name := range byVehicle {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rotations := []VehicleRotation{}
+	for _, name := range names {
+		rotation := buildVehicleRotation(name, byVehicle[name], from, to)
+		if len(rotation.Stops) > 0 || len(rotation.Unscheduled) > 0 {
+			rotations = append(rotations, rotation)
+		}
+	}
+
+	return rotations, nil
+}
+
+// buildVehicleRotation chains the records of one vehicle, read with
+// RotationLookaround around the range, and keeps the stops overlapping it.
+func buildVehicleRotation(name string, records []TrainSchedule, from, to time.Time) VehicleRotation {
+	rotation := VehicleRotation{
+		VehicleName: name,
+		Stops:       []VehicleRotationStop{},
+		Unscheduled: []TrainSchedule{},
+	}
+
+	var moves []*TrainSchedule
+	for i := range records {
+		if records[i].DepartureDateTime == nil && records[i].ArrivalDateTime == nil {
+			rotation.Unscheduled = append(rotation.Unscheduled, records[i])
+			continue
+		}
+		moves = append(moves, &records[i])
+	}
+	sort.SliceStable(moves, func(a, b int) bool {
+		return trainScheduleSorts["time"].value(moves[a]) < trainScheduleSorts["time"].value(moves[b])
+	})
+
+	// A stop precedes each move, and one more follows the last move
+	var stops []VehicleRotationStop
+	var previous *TrainSchedule
+	for _, move := range moves {
+		stops = append(stops, newRotationStop(previous, move))
+		previous = move
+	}
+	if previous != nil {
+		stops = append(stops, newRotationStop(previous, nil))
+	}
+
+	for _, stop := range stops {
+		// A stop lasts from its arrival to its departure; open ends reach the range
+		if stop.ArrivedAt != nil && !stop.ArrivedAt.Before(to) {
+			continue
+		}
+		if stop.DepartsAt != nil && stop.DepartsAt.Before(from) {
+			continue
+		}
+		if stop.ArrivedAt == nil && stop.DepartsAt == nil {
+			continue
+		}
+		if stop.Arrival != nil && stop.Departure == nil && stop.ArrivedAt != nil {
+			stop.Issues = append(stop.Issues, RotationStranded)
+		}
+		if len(stop.Issues) > 0 {
+			rotation.Issues++
+		}
+		rotation.Stops = append(rotation.Stops, stop)
+	}
+
+	return rotation
+}
+
+// newRotationStop describes the stop between the arrival of one record and
+// the departure of the next. Either may be nil at the ends of the chain.
+func newRotationStop(arrival, departure *TrainSchedule) VehicleRotationStop {
+	stop := VehicleRotationStop{Arrival: arrival, Departure: departure, Issues: []string{}}
+
+	if arrival != nil {
+		stop.Location = arrival.EndLocation
+		stop.ArrivedAt = arrival.ArrivalDateTime
+	}
+	if departure != nil {
+		if stop.Location == "" {
+			stop.Location = departure.StartingLocation
+		}
+		stop.DepartsAt = departure.DepartureDateTime
+	}
+	if arrival == nil || departure == nil {
+		return stop
+	}
+
+	if arrival.EndLocation != "" && departure.StartingLocation != "" &&
+		arrival.EndLocation != departure.StartingLocation {
+		stop.Issues = append(stop.Issues, RotationGap)
+	}
+	if stop.ArrivedAt != nil && stop.DepartsAt != nil {
+		dwell := int(stop.DepartsAt.Sub(*stop.ArrivedAt).Minutes())
+		stop.DwellMinutes = &dwell
+		if stop.DepartsAt.Before(*stop.ArrivedAt) {
+			stop.Issues = append(stop.Issues, RotationOverlap)
+		}
+	}
+
+	return stop
+}
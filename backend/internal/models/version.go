@@ -0,0 +1,41 @@
+// backend/internal/models/version.go
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrVersionConflict is returned when a client writes a record using a stale copy.
+// Use errors.As with *VersionConflictError to find out which record it was.
+var ErrVersionConflict = errors.New("record was modified by another user")
+
+// VersionConflictError describes a write rejected by the version check.
+type VersionConflictError struct {
+	Entity   string // "train_schedule", "station" or "track"
+	ID       string // ID of the conflicting record
+	Expected int64  // Version the client edited
+	Current  int64  // Version stored in the database
+}
+
+// Error implements the error interface.
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf(
+		"%s %s: expected version %d, current version %d: %v",
+		e.Entity, e.ID, e.Expected, e.Current, ErrVersionConflict,
+	)
+}
+
+// Unwrap makes errors.Is(err, ErrVersionConflict) work.
+func (e *VersionConflictError) Unwrap() error {
+	return ErrVersionConflict
+}
+
+// checkVersion compares the client's version with the stored one.
+// An expected version of 0 means the client did not ask for a check.
+func checkVersion(entity, id string, expected, current int64) error {
+	if expected == 0 || expected == current {
+		return nil
+	}
+	return &VersionConflictError{Entity: entity, ID: id, Expected: expected, Current: current}
+}
@@ -0,0 +1,318 @@
+// backend/internal/parking/parking.go
+package parking
+
+// This package checks how vehicles are parked on depot tracks. A vehicle
+// occupies one position of a track from the time it arrives until the train
+// that takes it out departs. Positions are numbered from one end of the track;
+// the track rule decides in which order vehicles may enter and leave:
+//
+//   - fifo (through tracks): vehicles enter at the high end and leave at the
+//     low end, so a lower position cannot be taken while a higher one is
+//     occupied, and a higher position cannot leave before a lower one;
+//   - filo (dead-end tracks): vehicles enter and leave at the low end, so a
+//     higher position cannot be taken while a lower one is occupied, and a
+//     lower position blocks the departure of a higher one.
+//
+// A dead-end track has a single entry end, so it is filo whatever its rule
+// says. The checks are the same the parking timeline runs in the browser, so
+// the server and the dispatchers see the same conflicts. The server also
+// checks that the vehicles of known length fit the track's length; the
+// timeline does not know vehicle lengths.
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Track rules
+const (
+	RuleFIFO = "fifo"
+	RuleFILO = "filo"
+)
+
+// Track types
+const (
+	TypeThrough = "through"
+	TypeDeadEnd = "dead_end"
+)
+
+// Conflict types
+const (
+	ConflictPosition = "position" // Two vehicles on one position, or a position the track does not have
+	ConflictFIFO     = "fifo"     // Entering or leaving against the fifo rule
+	ConflictFILO     = "filo"     // Entering or leaving against the filo rule
+	ConflictLength   = "length"   // The vehicles on the track are longer than the track
+)
+
+// Conflict messages, as shown on the parking timeline
+const (
+	messageSamePosition    = "Konfliktas: bandymas užimti tą pačią poziciją"
+	messageFIFOEnter       = "Konfliktas: negalima užimti, nes užimta aukštesnė pozicija"
+	messageFIFOLeave       = "Konfliktas: negalima išvykti, nes užimta žemesnė pozicija"
+	messageFILOEnter       = "Konfliktas: negalima užimti, nes užimta žemesnė pozicija"
+	messageFILOLeave       = "Konfliktas: blokuojamas žemesnės pozicijos išvykimas"
+	messageUnknownTrack    = "Konfliktas: stotyje nėra tokio kelio"
+	messageUnknownPosition = "Konfliktas: kelyje nėra tokios pozicijos"
+	messageTooLong         = "Konfliktas: transporto priemonės netelpa kelyje"
+)
+
+// Track is a track of a station as far as parking is concerned.
+type Track struct {
+	Number     string `json:"number"`     // Track number within the station
+	Positions  int    `json:"positions"`  // Number of positions (0 if not limited)
+	Length     int    `json:"length"`     // Usable length in meters (0 if unknown)
+	Type       string `json:"type"`       // TypeThrough or TypeDeadEnd
+	Rule       string `json:"rule"`       // RuleFIFO or RuleFILO
+	Exceptions bool   `json:"exceptions"` // Conflicts on the track are allowed as exceptions
+}
+
+// filo reports whether vehicles leave the track in the reverse order they
+// entered it: dead-end tracks always do.
+func (t Track) filo() bool {
+	return t.Rule == RuleFILO || t.Type == TypeDeadEnd
+}
+
+// Assignment is a vehicle standing on a track position for a time.
+type Assignment struct {
+	ID          string    `json:"id"`                     // Identifies the assignment in conflicts
+	Vehicle     string    `json:"vehicle"`                // Vehicle name
+	Track       string    `json:"track"`                  // Track number
+	Position    int       `json:"position"`               // Position on the track, from 1
+	Length      int       `json:"length,omitempty"`       // Vehicle length in meters (0 if unknown)
+	Start       time.Time `json:"start"`                  // When the vehicle arrives
+	End         time.Time `json:"end"`                    // When the vehicle leaves
+	ArrivalID   string    `json:"arrival_id,omitempty"`   // Record bringing the vehicle in
+	DepartureID string    `json:"departure_id,omitempty"` // Record taking the vehicle out
+}
+
+// Conflict is a broken parking rule of an assignment.
+type Conflict struct {
+	Type       string    `json:"type"`              // One of the Conflict* types
+	Assignment string    `json:"assignment_id"`     // Assignment in conflict
+	With       string    `json:"with_id,omitempty"` // Assignment it conflicts with
+	Track      string    `json:"track"`             // Track number
+	Position   int       `json:"position"`          // Position of the assignment
+	Time       time.Time `json:"time"`              // When the conflict happens
+	Message    string    `json:"message"`           // Explanation for dispatchers
+	Exception  bool      `json:"exception"`         // The track allows exceptions, so it is only a warning
+}
+
+// Movement is an arrival or departure of a vehicle at a station with the
+// track written on the record, e.g. "3.2" for position 2 of track 3.
+type Movement struct {
+	ID      string    // Record ID
+	Vehicle string    // Vehicle name
+	Time    time.Time // Arrival or departure time
+	Track   string    // Track and position, empty if not assigned
+}
+
+// ParseTrack splits a track written as "track.position", e.g. "3.2".
+// It reports false if the value has no track or no valid position.
+func ParseTrack(value string) (track string, position int, ok bool) {
+	number, rest, found := strings.Cut(strings.TrimSpace(value), ".")
+	if !found {
+		return "", 0, false
+	}
+	number = normalizeTrack(number)
+	position, err := strconv.Atoi(strings.TrimSpace(rest))
+	if number == "" || err != nil || position < 1 {
+		return "", 0, false
+	}
+	return number, position, true
+}
+
+// normalizeTrack drops leading zeros of numeric track numbers, so "03" and
+// "3" are the same track.
+func normalizeTrack(track string) string {
+	track = strings.TrimSpace(track)
+	if n, err := strconv.Atoi(track); err == nil {
+		return strconv.Itoa(n)
+	}
+	return track
+}
+
+// Pair builds the assignments of a station from the arrivals and departures
+// that have a track, the way the parking timeline does:
+//
+//   - an arrival with a track stays there until the next departure of the
+//     same vehicle, or until the end of the range if there is none;
+//   - a departure with a track not taken out by such an arrival stands there
+//     since the last arrival of the same vehicle, or since the start of the range.
+//
+// Movements with a track that ParseTrack rejects are skipped.
+func Pair(arrivals, departures []Movement, from, to time.Time) []Assignment {
+	arrivals, departures = byTime(arrivals), byTime(departures)
+	paired := make(map[int]bool)
+
+	var assignments []Assignment
+	for _, arrival := range arrivals {
+		track, position, ok := ParseTrack(arrival.Track)
+		if !ok {
+			continue
+		}
+		assignment := Assignment{
+			ID:        arrival.ID,
+			Vehicle:   arrival.Vehicle,
+			Track:     track,
+			Position:  position,
+			Start:     arrival.Time,
+			End:       to,
+			ArrivalID: arrival.ID,
+		}
+		for i, departure := range departures {
+			if arrival.Vehicle != "" && departure.Vehicle == arrival.Vehicle && departure.Time.After(arrival.Time) {
+				assignment.End = departure.Time
+				assignment.DepartureID = departure.ID
+				paired[i] = true
+				break
+			}
+		}
+		assignments = append(assignments, assignment)
+	}
+
+	for i, departure := range departures {
+		if paired[i] {
+			continue
+		}
+		track, position, ok := ParseTrack(departure.Track)
+		if !ok {
+			continue
+		}
+		assignment := Assignment{
+			ID:          departure.ID,
+			Vehicle:     departure.Vehicle,
+			Track:       track,
+			Position:    position,
+			Start:       from,
+			End:         departure.Time,
+			DepartureID: departure.ID,
+		}
+		for j := len(arrivals) - 1; j >= 0; j-- {
+			arrival := arrivals[j]
+			if departure.Vehicle != "" && arrival.Vehicle == departure.Vehicle && arrival.Time.Before(departure.Time) {
+				assignment.Start = arrival.Time
+				assignment.ArrivalID = arrival.ID
+				break
+			}
+		}
+		assignments = append(assignments, assignment)
+	}
+
+	return assignments
+}
+
+// byTime returns a copy of the movements ordered by time.
+func byTime(movements []Movement) []Movement {
+	sorted := append([]Movement(nil), movements...)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		return sorted[a].Time.Before(sorted[b].Time)
+	})
+	return sorted
+}
+
+// Detect finds the conflicts between assignments on the given tracks.
+//
+// Every assignment is checked against the track it stands on: the track must
+// exist and have the position. Then each pair of assignments on the same
+// track that overlap in time is checked: they may not share a position, and
+// the later one may neither enter nor leave against the track rule. Tracks
+// without a rule are treated as fifo, dead-end tracks as filo. Finally, on
+// tracks of known length, an assignment of known length may not arrive while
+// the vehicles already there leave too little room.
+//
+// Conflicts are ordered by time; the assignments are not changed.
+func Detect(tracks []Track, assignments []Assignment) []Conflict {
+	byNumber := make(map[string]Track, len(tracks))
+	for _, track := range tracks {
+		byNumber[normalizeTrack(track.Number)] = track
+	}
+
+	sorted := append([]Assignment(nil), assignments...)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		return sorted[a].Start.Before(sorted[b].Start)
+	})
+
+	conflicts := []Conflict{}
+	add := func(kind string, assignment Assignment, with string, track Track, at time.Time, message string) {
+		conflicts = append(conflicts, Conflict{
+			Type:       kind,
+			Assignment: assignment.ID,
+			With:       with,
+			Track:      assignment.Track,
+			Position:   assignment.Position,
+			Time:       at,
+			Message:    message,
+			Exception:  track.Exceptions,
+		})
+	}
+
+	for _, a := range sorted {
+		track, found := byNumber[normalizeTrack(a.Track)]
+		if !found {
+			add(ConflictPosition, a, "", track, a.Start, messageUnknownTrack)
+		} else if a.Position < 1 || (track.Positions > 0 && a.Position > track.Positions) {
+			add(ConflictPosition, a, "", track, a.Start, messageUnknownPosition)
+		}
+	}
+
+	for i, a := range sorted {
+		track, found := byNumber[normalizeTrack(a.Track)]
+		if !found {
+			continue
+		}
+		fifo := !track.filo()
+
+		for _, b := range sorted[i+1:] {
+			if normalizeTrack(b.Track) != normalizeTrack(a.Track) {
+				continue
+			}
+			// b starts no earlier than a, so they overlap if a is still there
+			if !a.End.After(b.Start) {
+				continue
+			}
+
+			switch {
+			case a.Position == b.Position:
+				add(ConflictPosition, a, b.ID, track, b.Start, messageSamePosition)
+				add(ConflictPosition, b, a.ID, track, b.Start, messageSamePosition)
+			case fifo && a.Position > b.Position:
+				// A lower position cannot be taken while a higher one is occupied
+				add(ConflictFIFO, b, a.ID, track, b.Start, messageFIFOEnter)
+			case fifo && a.End.After(b.End):
+				// A higher position cannot leave while a lower one is occupied
+				add(ConflictFIFO, b, a.ID, track, a.End, messageFIFOLeave)
+			case !fifo && a.Position < b.Position:
+				// A higher position cannot be taken while a lower one is occupied
+				add(ConflictFILO, b, a.ID, track, b.Start, messageFILOEnter)
+			case !fifo && a.Position > b.Position && a.End.Before(b.End):
+				// The lower position blocks the departure of the higher one
+				add(ConflictFILO, b, a.ID, track, a.End, messageFILOLeave)
+			}
+		}
+	}
+
+	for i, b := range sorted {
+		track, found := byNumber[normalizeTrack(b.Track)]
+		if !found || track.Length <= 0 || b.Length <= 0 {
+			continue
+		}
+		// The track is fullest when a vehicle arrives; vehicles of unknown
+		// length count as nothing
+		used := b.Length
+		for _, a := range sorted[:i] {
+			if normalizeTrack(a.Track) == normalizeTrack(b.Track) && a.End.After(b.Start) {
+				used += a.Length
+			}
+		}
+		if used > track.Length {
+			add(ConflictLength, b, "", track, b.Start, messageTooLong)
+		}
+	}
+
+	sort.SliceStable(conflicts, func(a, b int) bool {
+		return conflicts[a].Time.Before(conflicts[b].Time)
+	})
+	return conflicts
+}
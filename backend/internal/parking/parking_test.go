@@ -0,0 +1,299 @@
+// backend/internal/parking/parking_test.go
+package parking
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testBase = time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+// at returns the test day's time after the given hours.
+func at(hours float64) time.Time {
+	return testBase.Add(time.Duration(hours * float64(time.Hour)))
+}
+
+// stand is an assignment of vehicle id on track.position from start to end hours.
+func stand(id, track string, position int, start, end float64) Assignment {
+	return Assignment{ID: id, Vehicle: id, Track: track, Position: position, Start: at(start), End: at(end)}
+}
+
+// long sets the vehicle length of an assignment.
+func long(assignment Assignment, length int) Assignment {
+	assignment.Length = length
+	return assignment
+}
+
+// found is the part of a conflict the tests compare.
+type found struct {
+	Type, Assignment, With string
+	Exception              bool
+}
+
+func summarize(conflicts []Conflict) []found {
+	summary := []found{}
+	for _, c := range conflicts {
+		summary = append(summary, found{c.Type, c.Assignment, c.With, c.Exception})
+	}
+	return summary
+}
+
+func TestDetect(t *testing.T) {
+	fifo := Track{Number: "3", Positions: 3, Rule: RuleFIFO}
+	filo := Track{Number: "4", Positions: 3, Rule: RuleFILO}
+	lenient := Track{Number: "5", Positions: 3, Rule: RuleFIFO, Exceptions: true}
+	noRule := Track{Number: "6", Positions: 3}
+	deadEnd := Track{Number: "7", Positions: 3, Type: TypeDeadEnd, Rule: RuleFIFO}
+	short := Track{Number: "8", Positions: 3, Length: 100, Rule: RuleFIFO}
+	tracks := []Track{fifo, filo, lenient, noRule, deadEnd, short}
+
+	tests := []struct {
+		name        string
+		assignments []Assignment
+		want        []found
+	}{
+		{
+			name:        "no assignments",
+			assignments: nil,
+			want:        []found{},
+		},
+		{
+			name: "same position overlapping",
+			assignments: []Assignment{
+				stand("a", "3", 1, 18, 22),
+				stand("b", "3", 1, 20, 23),
+			},
+			want: []found{
+				{ConflictPosition, "a", "b", false},
+				{ConflictPosition, "b", "a", false},
+			},
+		},
+		{
+			name: "same position touching end and start",
+			assignments: []Assignment{
+				stand("a", "3", 1, 18, 20),
+				stand("b", "3", 1, 20, 23),
+			},
+			want: []found{},
+		},
+		{
+			name: "same position on different tracks",
+			assignments: []Assignment{
+				stand("a", "3", 1, 18, 22),
+				stand("b", "4", 1, 20, 23),
+			},
+			want: []found{},
+		},
+		{
+			name: "fifo in order",
+			assignments: []Assignment{
+				stand("a", "3", 1, 18, 22),
+				stand("b", "3", 2, 19, 23),
+			},
+			want: []found{},
+		},
+		{
+			name: "fifo entering below an occupied position",
+			assignments: []Assignment{
+				stand("a", "3", 2, 18, 22),
+				stand("b", "3", 1, 19, 23),
+			},
+			want: []found{{ConflictFIFO, "b", "a", false}},
+		},
+		{
+			name: "fifo leaving before a lower position",
+			assignments: []Assignment{
+				stand("a", "3", 1, 18, 23),
+				stand("b", "3", 2, 19, 22),
+			},
+			want: []found{{ConflictFIFO, "b", "a", false}},
+		},
+		{
+			name: "filo in order",
+			assignments: []Assignment{
+				stand("a", "4", 2, 18, 23),
+				stand("b", "4", 1, 19, 22),
+			},
+			want: []found{},
+		},
+		{
+			name: "filo entering above an occupied position",
+			assignments: []Assignment{
+				stand("a", "4", 1, 18, 23),
+				stand("b", "4", 2, 19, 22),
+			},
+			want: []found{{ConflictFILO, "b", "a", false}},
+		},
+		{
+			name: "filo lower position blocking the departure",
+			assignments: []Assignment{
+				stand("a", "4", 2, 18, 22),
+				stand("b", "4", 1, 19, 23),
+			},
+			want: []found{{ConflictFILO, "b", "a", false}},
+		},
+		{
+			name: "fifo ordering touching end and start",
+			assignments: []Assignment{
+				stand("a", "3", 2, 18, 19),
+				stand("b", "3", 1, 19, 23),
+			},
+			want: []found{},
+		},
+		{
+			name: "track without a rule is fifo",
+			assignments: []Assignment{
+				stand("a", "6", 2, 18, 22),
+				stand("b", "6", 1, 19, 23),
+			},
+			want: []found{{ConflictFIFO, "b", "a", false}},
+		},
+		{
+			name: "dead-end track is filo",
+			assignments: []Assignment{
+				stand("a", "7", 1, 18, 23),
+				stand("b", "7", 2, 19, 22),
+			},
+			want: []found{{ConflictFILO, "b", "a", false}},
+		},
+		{
+			name: "vehicles longer than the track",
+			assignments: []Assignment{
+				long(stand("a", "8", 1, 18, 22), 60),
+				long(stand("b", "8", 2, 19, 23), 50),
+			},
+			want: []found{{ConflictLength, "b", "", false}},
+		},
+		{
+			name: "vehicles fitting the track",
+			assignments: []Assignment{
+				long(stand("a", "8", 1, 18, 22), 60),
+				long(stand("b", "8", 2, 19, 23), 40),
+			},
+			want: []found{},
+		},
+		{
+			name: "long vehicles touching end and start",
+			assignments: []Assignment{
+				long(stand("a", "8", 1, 18, 19), 60),
+				long(stand("b", "8", 1, 19, 23), 50),
+			},
+			want: []found{},
+		},
+		{
+			name: "vehicle of unknown length",
+			assignments: []Assignment{
+				long(stand("a", "8", 1, 18, 22), 60),
+				stand("b", "8", 2, 19, 23),
+			},
+			want: []found{},
+		},
+		{
+			name: "conflicts on a track with exceptions are warnings",
+			assignments: []Assignment{
+				stand("a", "5", 2, 18, 22),
+				stand("b", "5", 1, 19, 23),
+			},
+			want: []found{{ConflictFIFO, "b", "a", true}},
+		},
+		{
+			name: "track numbers with leading zeros",
+			assignments: []Assignment{
+				stand("a", "03", 1, 18, 22),
+				stand("b", "3", 1, 20, 23),
+			},
+			want: []found{
+				{ConflictPosition, "a", "b", false},
+				{ConflictPosition, "b", "a", false},
+			},
+		},
+		{
+			name:        "unknown track",
+			assignments: []Assignment{stand("a", "9", 1, 18, 22)},
+			want:        []found{{ConflictPosition, "a", "", false}},
+		},
+		{
+			name: "position outside the track",
+			assignments: []Assignment{
+				stand("a", "3", 4, 8, 12),
+				stand("b", "3", 0, 18, 22),
+			},
+			want: []found{
+				{ConflictPosition, "a", "", false},
+				{ConflictPosition, "b", "", false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarize(Detect(tracks, tt.assignments))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Detect() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectOrdersByTime(t *testing.T) {
+	tracks := []Track{{Number: "1", Positions: 2, Rule: RuleFIFO}}
+	assignments := []Assignment{
+		stand("late", "1", 1, 20, 23),
+		stand("early", "1", 2, 18, 22),
+	}
+
+	conflicts := Detect(tracks, assignments)
+	if len(conflicts) != 1 {
+		t.Fatalf("Detect() returned %d conflicts, want 1", len(conflicts))
+	}
+	if c := conflicts[0]; c.Assignment != "late" || !c.Time.Equal(at(20)) {
+		t.Errorf("conflict = %s at %s, want late at %s", c.Assignment, c.Time, at(20))
+	}
+}
+
+func TestParseTrack(t *testing.T) {
+	tests := []struct {
+		value    string
+		track    string
+		position int
+		ok       bool
+	}{
+		{"3.2", "3", 2, true},
+		{" 03.1 ", "3", 1, true},
+		{"A.4", "A", 4, true},
+		{"3", "", 0, false},
+		{"3.0", "", 0, false},
+		{"3.x", "", 0, false},
+		{".2", "", 0, false},
+		{"", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		track, position, ok := ParseTrack(tt.value)
+		if track != tt.track || position != tt.position || ok != tt.ok {
+			t.Errorf("ParseTrack(%q) = %q, %d, %v, want %q, %d, %v",
+				tt.value, track, position, ok, tt.track, tt.position, tt.ok)
+		}
+	}
+}
+
+func TestPair(t *testing.T) {
+	arrivals := []Movement{
+		{ID: "a1", Vehicle: "V1", Time: at(18), Track: "3.1"},
+		{ID: "a2", Vehicle: "V2", Time: at(19), Track: ""},
+	}
+	departures := []Movement{
+		{ID: "d1", Vehicle: "V1", Time: at(22), Track: ""},
+		{ID: "d0", Vehicle: "V0", Time: at(6), Track: "03.2"},
+	}
+
+	got := Pair(arrivals, departures, at(0), at(24))
+	want := []Assignment{
+		{ID: "a1", Vehicle: "V1", Track: "3", Position: 1, Start: at(18), End: at(22), ArrivalID: "a1", DepartureID: "d1"},
+		{ID: "d0", Vehicle: "V0", Track: "3", Position: 2, Start: at(0), End: at(6), DepartureID: "d0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Pair() = %+v, want %+v", got, want)
+	}
+}
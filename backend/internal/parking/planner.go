@@ -0,0 +1,443 @@
+// backend/internal/parking/planner.go
+package parking
+
+// The planner proposes where vehicles stand overnight. Vehicles are placed
+// one by one in the order they arrive, each on the free position that causes
+// the fewest conflicts with the vehicles already placed: first positions
+// without any conflict, then positions whose conflicts the track allows as
+// exceptions, and only when nothing else is left a position against the
+// rules. Vehicles left against the rules are then moved or swapped while that
+// helps; the ones that still break a rule are explained. Fixed (locked)
+// assignments are never moved.
+//
+// Dead-end tracks are planned as filo. Track lengths are only kept when the
+// stays carry vehicle lengths; train schedule records have none, so the
+// proposal lists that as a limitation.
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Issue messages
+const (
+	messageNoTracks   = "Stotyje nėra kelių su pozicijomis"
+	messageNoCleanFit = "Visose pozicijose būtų pažeistos kelių taisyklės: parinkta pozicija su mažiausiai konfliktų"
+)
+
+// Limitation messages
+const (
+	limitationNoLengths = "Nežinomi transporto priemonių ilgiai, todėl kelių ilgiai netikrinami"
+)
+
+// Stays pairs the arrivals and departures of a station into the times
+// vehicles stand there, whatever track the records name: each arrival lasts
+// until the next departure of the same vehicle, or until the end of the range;
+// a departure no arrival pairs with stands there since the start of the range.
+// The stays have no track or position yet.
+func Stays(arrivals, departures []Movement, from, to time.Time) []Assignment {
+	arrivals, departures = byTime(arrivals), byTime(departures)
+	paired := make(map[int]bool)
+
+	var stays []Assignment
+	for _, arrival := range arrivals {
+		stay := Assignment{
+			ID:        arrival.ID,
+			Vehicle:   arrival.Vehicle,
+			Start:     arrival.Time,
+			End:       to,
+			ArrivalID: arrival.ID,
+		}
+		for i, departure := range departures {
+			if !paired[i] && arrival.Vehicle != "" && departure.Vehicle == arrival.Vehicle && departure.Time.After(arrival.Time) {
+				stay.End = departure.Time
+				stay.DepartureID = departure.ID
+				paired[i] = true
+				break
+			}
+		}
+		stays = append(stays, stay)
+	}
+
+	for i, departure := range departures {
+		if paired[i] {
+			continue
+		}
+		stays = append(stays, Assignment{
+			ID:          departure.ID,
+			Vehicle:     departure.Vehicle,
+			Start:       from,
+			End:         departure.Time,
+			DepartureID: departure.ID,
+		})
+	}
+
+	return stays
+}
+
+// Issue explains why a stay could not be placed without breaking the rules.
+type Issue struct {
+	Assignment string     `json:"assignment_id"` // Stay the issue is about
+	Vehicle    string     `json:"vehicle"`       // Vehicle name
+	Message    string     `json:"message"`       // Explanation for dispatchers
+	Conflicts  []Conflict `json:"conflicts"`     // Conflicts the chosen position causes
+}
+
+// Score rates a proposal: Value is 100 when every stay is placed without
+// conflicts; stays placed with conflicts the track allows count half, stays
+// placed against the rules or not placed count nothing.
+type Score struct {
+	Value      int `json:"value"`      // 0-100
+	Stays      int `json:"stays"`      // Stays to place
+	Clean      int `json:"clean"`      // Placed without conflicts
+	Exceptions int `json:"exceptions"` // Placed with conflicts allowed as exceptions only
+	Blocked    int `json:"blocked"`    // Placed against the rules of a track
+	Unplaced   int `json:"unplaced"`   // Not placed at all
+}
+
+// Proposal is the planner's answer.
+type Proposal struct {
+	Assignments []Assignment `json:"assignments"` // Proposed placements in time order
+	Fixed       []Assignment `json:"fixed"`       // Locked assignments kept as they are
+	Conflicts   []Conflict   `json:"conflicts"`   // Conflicts left in the plan, locked assignments included
+	Issues      []Issue      `json:"issues"`      // Stays that could not be placed by the rules
+	Limitations []string     `json:"limitations"` // Rules the planner could not check
+	Score       Score        `json:"score"`
+}
+
+// Conflict weights: a conflict against the rules outweighs the conflicts
+// allowed as exceptions, which outweigh the position preference.
+const (
+	weightBlocking  = 1_000_000
+	weightException = 1_000
+)
+
+// improveRounds bounds the improvement passes over a greedy plan.
+const improveRounds = 10
+
+// maxOpenPositions bounds the positions tried on a track without a limit, as
+// every position tried costs a conflict check per stay.
+const maxOpenPositions = 30
+
+// slot is a position a stay may take.
+type slot struct {
+	track    Track
+	position int
+}
+
+// planner holds the state of one Plan run.
+type planner struct {
+	tracks  map[string]Track        // Tracks by normalized number
+	fixed   map[string][]Assignment // Fixed assignments by track
+	planned []Assignment            // Placed stays
+}
+
+// onTrack returns the fixed and placed assignments of a track.
+func (p *planner) onTrack(track string) []Assignment {
+	assignments := append([]Assignment(nil), p.fixed[track]...)
+	for _, assignment := range p.planned {
+		if assignment.Track == track {
+			assignments = append(assignments, assignment)
+		}
+	}
+	return assignments
+}
+
+// cost weighs the conflicts on a track.
+func (p *planner) cost(track string) int {
+	return penalty(Detect([]Track{p.tracks[track]}, p.onTrack(track)))
+}
+
+// penalty weighs conflicts.
+func penalty(conflicts []Conflict) int {
+	total := 0
+	for _, conflict := range conflicts {
+		if conflict.Exception {
+			total += weightException
+		} else {
+			total += weightBlocking
+		}
+	}
+	return total
+}
+
+// Plan places the stays on the tracks around the fixed assignments.
+//
+// Stays are first placed in the order they start. For each stay every track
+// position is tried, and the one adding the fewest conflicts with the
+// assignments already placed wins: conflicts against the rules weigh most,
+// then conflicts the track allows as exceptions. Among equal positions fifo
+// tracks are filled from position 1 and filo tracks from their last
+// position, as vehicles enter them; earlier tracks come first. A track
+// without a limit is given as many positions as vehicles ever stand at the
+// station at once, up to maxOpenPositions.
+//
+// Placing one vehicle at a time cannot see the vehicles still to come, so the
+// stays left against the rules are then moved to other positions or swapped with
+// other stays for as long as that lowers the conflicts.
+func Plan(tracks []Track, fixed []Assignment, stays []Assignment) Proposal {
+	p := &planner{
+		tracks: make(map[string]Track, len(tracks)),
+		fixed:  make(map[string][]Assignment),
+	}
+	open := min(peakOverlap(append(append([]Assignment{}, fixed...), stays...)), maxOpenPositions)
+	var slots []slot
+	for _, track := range tracks {
+		p.tracks[normalizeTrack(track.Number)] = track
+		positions := track.Positions
+		if positions == 0 {
+			positions = open
+		}
+		for position := 1; position <= positions; position++ {
+			slots = append(slots, slot{track, position})
+		}
+	}
+	for _, assignment := range fixed {
+		key := normalizeTrack(assignment.Track)
+		p.fixed[key] = append(p.fixed[key], assignment)
+	}
+
+	ordered := append([]Assignment(nil), stays...)
+	sort.SliceStable(ordered, func(a, b int) bool {
+		if !ordered[a].Start.Equal(ordered[b].Start) {
+			return ordered[a].Start.Before(ordered[b].Start)
+		}
+		return ordered[a].End.Before(ordered[b].End)
+	})
+
+	proposal := Proposal{
+		Assignments: []Assignment{},
+		Fixed:       append([]Assignment{}, fixed...),
+		Issues:      []Issue{},
+		Limitations: limitations(tracks, stays),
+	}
+
+	unplaced := 0
+	for _, stay := range ordered {
+		if len(slots) == 0 {
+			unplaced++
+			proposal.Issues = append(proposal.Issues, Issue{
+				Assignment: stay.ID, Vehicle: stay.Vehicle, Message: messageNoTracks, Conflicts: []Conflict{},
+			})
+			continue
+		}
+
+		best, bestCost := Assignment{}, math.MaxInt
+		for _, s := range slots {
+			candidate := stay
+			candidate.Track = normalizeTrack(s.track.Number)
+			candidate.Position = s.position
+
+			cost := slotPreference(s) + penalty(conflictsOf(s.track, p.onTrack(candidate.Track), candidate))
+			if cost < bestCost {
+				best, bestCost = candidate, cost
+			}
+		}
+		p.planned = append(p.planned, best)
+	}
+
+	p.improve(slots)
+	proposal.Assignments = append(proposal.Assignments, p.planned...)
+
+	all := append(append([]Assignment{}, fixed...), proposal.Assignments...)
+	proposal.Conflicts = Detect(tracks, all)
+
+	// Rate each stay by the conflicts it takes part in once everything is placed
+	byStay := make(map[string][]Conflict)
+	for _, conflict := range proposal.Conflicts {
+		byStay[conflict.Assignment] = append(byStay[conflict.Assignment], conflict)
+		if conflict.With != "" && conflict.With != conflict.Assignment {
+			byStay[conflict.With] = append(byStay[conflict.With], conflict)
+		}
+	}
+
+	score := Score{Stays: len(stays), Unplaced: unplaced}
+	for _, assignment := range proposal.Assignments {
+		conflicts := byStay[assignment.ID]
+		blocking := false
+		for _, conflict := range conflicts {
+			if !conflict.Exception {
+				blocking = true
+			}
+		}
+		switch {
+		case len(conflicts) == 0:
+			score.Clean++
+		case !blocking:
+			score.Exceptions++
+		default:
+			score.Blocked++
+			proposal.Issues = append(proposal.Issues, Issue{
+				Assignment: assignment.ID, Vehicle: assignment.Vehicle, Message: messageNoCleanFit, Conflicts: conflicts,
+			})
+		}
+	}
+	score.Value = 100
+	if score.Stays > 0 {
+		score.Value = (200*score.Clean + 100*score.Exceptions) / (2 * score.Stays)
+	}
+	proposal.Score = score
+
+	return proposal
+}
+
+// improve moves the placed stays that break the rules of their track to
+// other positions, or swaps them with other placed stays standing at the same
+// time, whenever that lowers the weight of the conflicts on the tracks
+// involved. Fixed assignments are never moved.
+func (p *planner) improve(slots []slot) {
+	costs := make(map[string]int, len(p.tracks))
+	for track := range p.tracks {
+		costs[track] = p.cost(track)
+	}
+
+	// try applies a change of placed stays and keeps it if it lowers the cost
+	try := func(change map[int]Assignment) bool {
+		previous := make(map[int]Assignment, len(change))
+		before, after := 0, make(map[string]int)
+		for i, moved := range change {
+			for _, track := range []string{p.planned[i].Track, moved.Track} {
+				if _, seen := after[track]; !seen {
+					after[track] = 0
+					before += costs[track]
+				}
+			}
+			previous[i] = p.planned[i]
+		}
+
+		for i, moved := range change {
+			p.planned[i] = moved
+		}
+		total := 0
+		for track := range after {
+			after[track] = p.cost(track)
+			total += after[track]
+		}
+		if total < before {
+			for track, cost := range after {
+				costs[track] = cost
+			}
+			return true
+		}
+		for i, old := range previous {
+			p.planned[i] = old
+		}
+		return false
+	}
+
+	for round := 0; round < improveRounds; round++ {
+		improved := false
+		for i := range p.planned {
+			current := p.planned[i]
+			if penalty(conflictsOf(p.tracks[current.Track], p.othersOnTrack(i), current)) < weightBlocking {
+				continue
+			}
+
+			for _, s := range slots {
+				moved := p.planned[i]
+				moved.Track, moved.Position = normalizeTrack(s.track.Number), s.position
+				if moved.Track == p.planned[i].Track && moved.Position == p.planned[i].Position {
+					continue
+				}
+				if try(map[int]Assignment{i: moved}) {
+					improved = true
+				}
+			}
+
+			// Only stays standing at the same time compete for positions
+			for j := range p.planned {
+				a, b := p.planned[i], p.planned[j]
+				if j == i || (a.Track == b.Track && a.Position == b.Position) || !a.Start.Before(b.End) || !b.Start.Before(a.End) {
+					continue
+				}
+				a.Track, a.Position, b.Track, b.Position = b.Track, b.Position, a.Track, a.Position
+				if try(map[int]Assignment{i: a, j: b}) {
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			return
+		}
+	}
+}
+
+// peakOverlap returns the most assignments standing at the same time.
+func peakOverlap(assignments []Assignment) int {
+	type event struct {
+		at    time.Time
+		delta int
+	}
+	events := make([]event, 0, 2*len(assignments))
+	for _, assignment := range assignments {
+		events = append(events, event{assignment.Start, 1}, event{assignment.End, -1})
+	}
+	// Leaving before arriving at the same time, as touching stays share a position
+	sort.Slice(events, func(a, b int) bool {
+		if !events[a].at.Equal(events[b].at) {
+			return events[a].at.Before(events[b].at)
+		}
+		return events[a].delta < events[b].delta
+	})
+
+	peak, current := 0, 0
+	for _, e := range events {
+		current += e.delta
+		peak = max(peak, current)
+	}
+	return peak
+}
+
+// limitations lists the rules of the tracks the stays do not let the planner
+// check: track lengths are only checked for vehicles of known length.
+func limitations(tracks []Track, stays []Assignment) []string {
+	found := []string{}
+	for _, track := range tracks {
+		if track.Length <= 0 {
+			continue
+		}
+		for _, stay := range stays {
+			if stay.Length <= 0 {
+				return append(found, limitationNoLengths)
+			}
+		}
+		break
+	}
+	return found
+}
+
+// othersOnTrack returns the assignments on the track of placed stay i, except i.
+func (p *planner) othersOnTrack(i int) []Assignment {
+	track := p.planned[i].Track
+	others := append([]Assignment(nil), p.fixed[track]...)
+	for j, assignment := range p.planned {
+		if j != i && assignment.Track == track {
+			others = append(others, assignment)
+		}
+	}
+	return others
+}
+
+// slotPreference orders equally good positions: fifo tracks fill from
+// position 1, filo and dead-end tracks from their last position.
+func slotPreference(s slot) int {
+	if s.track.filo() && s.track.Positions > 0 {
+		return s.track.Positions - s.position
+	}
+	return s.position - 1
+}
+
+// conflictsOf returns the conflicts a candidate placement takes part in
+// among the assignments already on its track.
+func conflictsOf(track Track, placed []Assignment, candidate Assignment) []Conflict {
+	assignments := append(append([]Assignment{}, placed...), candidate)
+
+	var conflicts []Conflict
+	for _, conflict := range Detect([]Track{track}, assignments) {
+		if conflict.Assignment == candidate.ID || conflict.With == candidate.ID {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return conflicts
+}
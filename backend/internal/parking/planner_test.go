@@ -0,0 +1,234 @@
+// backend/internal/parking/planner_test.go
+package parking
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// stay is an unplaced stay of vehicle id from start to end hours.
+func stay(id string, start, end float64) Assignment {
+	return Assignment{ID: id, Vehicle: id, Start: at(start), End: at(end)}
+}
+
+// placements maps the IDs of placed stays to "track.position".
+func placements(assignments []Assignment) map[string]string {
+	placed := make(map[string]string, len(assignments))
+	for _, a := range assignments {
+		placed[a.ID] = fmt.Sprintf("%s.%d", a.Track, a.Position)
+	}
+	return placed
+}
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name   string
+		tracks []Track
+		fixed  []Assignment
+		stays  []Assignment
+		want   map[string]string
+		score  Score
+	}{
+		{
+			name:   "fifo track fills from position 1",
+			tracks: []Track{{Number: "1", Positions: 3, Rule: RuleFIFO}},
+			stays:  []Assignment{stay("s1", 18, 22), stay("s2", 19, 23)},
+			want:   map[string]string{"s1": "1.1", "s2": "1.2"},
+			score:  Score{Value: 100, Stays: 2, Clean: 2},
+		},
+		{
+			name:   "filo track fills from its last position",
+			tracks: []Track{{Number: "2", Positions: 2, Rule: RuleFILO}},
+			stays:  []Assignment{stay("s1", 18, 23), stay("s2", 19, 22)},
+			want:   map[string]string{"s1": "2.2", "s2": "2.1"},
+			score:  Score{Value: 100, Stays: 2, Clean: 2},
+		},
+		{
+			name:   "dead-end track is planned as filo",
+			tracks: []Track{{Number: "07", Positions: 2, Type: TypeDeadEnd, Rule: RuleFIFO}},
+			stays:  []Assignment{stay("s1", 18, 23), stay("s2", 19, 22)},
+			want:   map[string]string{"s1": "7.2", "s2": "7.1"},
+			score:  Score{Value: 100, Stays: 2, Clean: 2},
+		},
+		{
+			name:   "locked assignments are kept",
+			tracks: []Track{{Number: "1", Positions: 2, Rule: RuleFIFO}},
+			fixed:  []Assignment{stand("L", "1", 1, 17, 20)},
+			stays:  []Assignment{stay("s1", 18, 23)},
+			want:   map[string]string{"s1": "1.2"},
+			score:  Score{Value: 100, Stays: 1, Clean: 1},
+		},
+		{
+			name: "track allowing exceptions before a blocking conflict",
+			tracks: []Track{
+				{Number: "1", Positions: 1, Rule: RuleFIFO},
+				{Number: "2", Positions: 1, Rule: RuleFIFO, Exceptions: true},
+			},
+			fixed: []Assignment{stand("L1", "1", 1, 17, 24), stand("L2", "2", 1, 17, 24)},
+			stays: []Assignment{stay("s1", 18, 22)},
+			want:  map[string]string{"s1": "2.1"},
+			score: Score{Value: 50, Stays: 1, Exceptions: 1},
+		},
+		{
+			name: "stays swapped when the greedy order blocks them",
+			tracks: []Track{
+				{Number: "1", Positions: 3, Rule: RuleFIFO},
+				{Number: "2", Positions: 2, Rule: RuleFILO},
+			},
+			fixed: []Assignment{stand("L", "2", 2, 17, 24)},
+			stays: []Assignment{stay("a1", 18, 23), stay("a2", 19, 21), stay("a3", 20, 22)},
+			score: Score{Value: 100, Stays: 3, Clean: 3},
+		},
+		{
+			name: "vehicles of known length kept within the track",
+			tracks: []Track{
+				{Number: "1", Positions: 3, Length: 100, Rule: RuleFIFO},
+				{Number: "2", Positions: 3, Length: 100, Rule: RuleFIFO},
+			},
+			stays: []Assignment{long(stay("s1", 18, 22), 60), long(stay("s2", 19, 23), 60)},
+			want:  map[string]string{"s1": "1.1", "s2": "2.1"},
+			score: Score{Value: 100, Stays: 2, Clean: 2},
+		},
+		{
+			name:   "no tracks",
+			tracks: nil,
+			stays:  []Assignment{stay("s1", 18, 22)},
+			want:   map[string]string{},
+			score:  Score{Value: 0, Stays: 1, Unplaced: 1},
+		},
+		{
+			name:   "nothing to place",
+			tracks: []Track{{Number: "1", Positions: 3, Rule: RuleFIFO}},
+			want:   map[string]string{},
+			score:  Score{Value: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proposal := Plan(tt.tracks, tt.fixed, tt.stays)
+			if tt.want != nil {
+				if got := placements(proposal.Assignments); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Plan() placed %v, want %v", got, tt.want)
+				}
+			}
+			if proposal.Score != tt.score {
+				t.Errorf("Plan() score = %+v, want %+v", proposal.Score, tt.score)
+			}
+			if !reflect.DeepEqual(proposal.Fixed, append([]Assignment{}, tt.fixed...)) {
+				t.Errorf("Plan() fixed = %+v, want %+v", proposal.Fixed, tt.fixed)
+			}
+			if tt.score.Value == 100 && len(proposal.Conflicts) > 0 {
+				t.Errorf("Plan() left conflicts %+v", proposal.Conflicts)
+			}
+		})
+	}
+}
+
+func TestPlanIssues(t *testing.T) {
+	tracks := []Track{{Number: "1", Positions: 1, Rule: RuleFIFO}}
+	proposal := Plan(tracks, nil, []Assignment{stay("s1", 18, 22), stay("s2", 19, 23)})
+
+	// Both stays take part in the conflict, so both are explained
+	if len(proposal.Issues) != 2 {
+		t.Fatalf("Plan() returned %d issues, want 2", len(proposal.Issues))
+	}
+	for _, issue := range proposal.Issues {
+		if issue.Message != messageNoCleanFit || len(issue.Conflicts) == 0 {
+			t.Errorf("issue = %+v, want the conflicts of a blocked stay", issue)
+		}
+	}
+	if proposal.Score != (Score{Value: 0, Stays: 2, Blocked: 2}) {
+		t.Errorf("Plan() score = %+v, want two blocked stays", proposal.Score)
+	}
+}
+
+func TestPlanOpenTrack(t *testing.T) {
+	tracks := []Track{{Number: "1", Rule: RuleFIFO}}
+
+	// Three stays at once and one after them need three positions
+	stays := []Assignment{stay("s1", 18, 20), stay("s2", 18, 21), stay("s3", 19, 22), stay("s4", 22, 23)}
+	proposal := Plan(tracks, nil, stays)
+	if proposal.Score.Clean != 4 {
+		t.Errorf("Plan() score = %+v, want 4 clean stays", proposal.Score)
+	}
+	for _, a := range proposal.Assignments {
+		if a.Position > 3 {
+			t.Errorf("Plan() placed %s at position %d, want at most 3", a.ID, a.Position)
+		}
+	}
+
+	// Positions on a track without a limit are capped
+	stays = nil
+	for i := 0; i < maxOpenPositions+5; i++ {
+		stays = append(stays, stay(fmt.Sprintf("s%d", i), 18, 23))
+	}
+	for _, a := range Plan(tracks, nil, stays).Assignments {
+		if a.Position > maxOpenPositions {
+			t.Fatalf("Plan() placed %s at position %d, want at most %d", a.ID, a.Position, maxOpenPositions)
+		}
+	}
+}
+
+func TestPlanLimitations(t *testing.T) {
+	measured := []Track{{Number: "1", Positions: 2, Length: 100, Rule: RuleFIFO}}
+	unmeasured := []Track{{Number: "1", Positions: 2, Rule: RuleFIFO}}
+
+	tests := []struct {
+		name   string
+		tracks []Track
+		stays  []Assignment
+		want   []string
+	}{
+		{"vehicle lengths unknown", measured, []Assignment{stay("s1", 18, 22)}, []string{limitationNoLengths}},
+		{"vehicle lengths known", measured, []Assignment{long(stay("s1", 18, 22), 50)}, []string{}},
+		{"track lengths unknown", unmeasured, []Assignment{stay("s1", 18, 22)}, []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := Plan(tt.tracks, nil, tt.stays).Limitations; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Plan() limitations = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPeakOverlap(t *testing.T) {
+	tests := []struct {
+		name        string
+		assignments []Assignment
+		want        int
+	}{
+		{"none", nil, 0},
+		{"apart", []Assignment{stay("a", 18, 19), stay("b", 20, 21)}, 1},
+		{"touching", []Assignment{stay("a", 18, 19), stay("b", 19, 21)}, 1},
+		{"overlapping", []Assignment{stay("a", 18, 22), stay("b", 19, 21), stay("c", 20, 23)}, 3},
+	}
+
+	for _, tt := range tests {
+		if got := peakOverlap(tt.assignments); got != tt.want {
+			t.Errorf("%s: peakOverlap() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStays(t *testing.T) {
+	arrivals := []Movement{
+		{ID: "a1", Vehicle: "V1", Time: at(18), Track: "3.1"},
+		{ID: "a2", Vehicle: "V2", Time: at(19)},
+	}
+	departures := []Movement{
+		{ID: "d1", Vehicle: "V1", Time: at(22)},
+		{ID: "d0", Vehicle: "V0", Time: at(6), Track: "3.2"},
+	}
+
+	got := Stays(arrivals, departures, at(0), at(24))
+	want := []Assignment{
+		{ID: "a1", Vehicle: "V1", Start: at(18), End: at(22), ArrivalID: "a1", DepartureID: "d1"},
+		{ID: "a2", Vehicle: "V2", Start: at(19), End: at(24), ArrivalID: "a2"},
+		{ID: "d0", Vehicle: "V0", Start: at(0), End: at(6), DepartureID: "d0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Stays() = %+v, want %+v", got, want)
+	}
+}
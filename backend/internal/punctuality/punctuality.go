@@ -0,0 +1,260 @@
+// backend/internal/punctuality/punctuality.go
+package punctuality
+
+// This package measures how punctual trains were. Every departure and
+// arrival with both a planned and an actual time is a movement; its delay is
+// the difference between the two. Movements are summarised per train number,
+// depot, ISO week and delay reason, and the most delayed ones are listed.
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"yopta-template/internal/models"
+	"yopta-template/internal/timeparse"
+)
+
+// Movement kinds
+const (
+	Departure = "departure"
+	Arrival   = "arrival"
+)
+
+// Defaults used when no options are given
+const (
+	DefaultOnTimeMinutes = 5  // Delays up to five minutes count as on time
+	DefaultWorst         = 10 // Number of most delayed movements listed
+)
+
+// Options select the movements of a report and how they are judged.
+type Options struct {
+	From          time.Time // Planned times at or after this time
+	To            time.Time // Planned times before this time
+	Depot         string    // Only movements at this depot (empty for all)
+	OnTimeMinutes int       // Longest delay that still counts as on time
+	Worst         int       // Number of most delayed movements to list
+}
+
+// Validate checks that the options are usable.
+func (o Options) Validate() error {
+	if !o.From.Before(o.To) {
+		return fmt.Errorf("the range must end after it starts")
+	}
+	if o.OnTimeMinutes < 0 {
+		return fmt.Errorf("on time minutes must not be negative")
+	}
+	if o.Worst < 0 {
+		return fmt.Errorf("worst count must not be negative")
+	}
+	return nil
+}
+
+// Movement is one departure or arrival with an actual time recorded.
+type Movement struct {
+	ScheduleID   string    `json:"scheduleId"`   // Record the movement belongs to
+	Kind         string    `json:"kind"`         // Departure or Arrival
+	TrainNumber  string    `json:"trainNumber"`  // Departure or arrival train number
+	Depot        string    `json:"depot"`        // Starting location of departures, end location of arrivals
+	VehicleName  string    `json:"vehicleName"`  // Vehicle
+	Planned      time.Time `json:"planned"`      // Planned time
+	Actual       time.Time `json:"actual"`       // Actual time
+	DelayMinutes int       `json:"delayMinutes"` // Minutes late, negative if early
+	DelayReason  string    `json:"delayReason"`  // Reason recorded for the record
+}
+
+// Stats summarise the movements of one train number, depot, week or reason.
+type Stats struct {
+	Key                 string  `json:"key"`                 // Train number, depot code, ISO week ("2025-W21") or delay reason
+	Movements           int     `json:"movements"`           // Movements with an actual time
+	OnTime              int     `json:"onTime"`              // Movements at most OnTimeMinutes late
+	OnTimePercent       float64 `json:"onTimePercent"`       // Share of movements on time
+	AverageDelayMinutes float64 `json:"averageDelayMinutes"` // Mean delay, early movements counting as no delay
+	MaxDelayMinutes     int     `json:"maxDelayMinutes"`     // Longest delay
+	TotalDelayMinutes   int     `json:"totalDelayMinutes"`   // Sum of delays
+}
+
+// add counts a movement.
+func (s *Stats) add(m Movement, onTimeMinutes int) {
+	s.Movements++
+	if m.DelayMinutes <= onTimeMinutes {
+		s.OnTime++
+	}
+	if m.DelayMinutes > 0 {
+		s.TotalDelayMinutes += m.DelayMinutes
+	}
+	if m.DelayMinutes > s.MaxDelayMinutes {
+		s.MaxDelayMinutes = m.DelayMinutes
+	}
+}
+
+// finish computes the shares and averages once all movements are counted.
+func (s *Stats) finish() {
+	if s.Movements == 0 {
+		return
+	}
+	s.OnTimePercent = round1(100 * float64(s.OnTime) / float64(s.Movements))
+	s.AverageDelayMinutes = round1(float64(s.TotalDelayMinutes) / float64(s.Movements))
+}
+
+// Report is the punctuality of the movements within a time range.
+type Report struct {
+	From          time.Time  `json:"from"`
+	To            time.Time  `json:"to"`
+	Depot         string     `json:"depot,omitempty"`
+	OnTimeMinutes int        `json:"onTimeMinutes"` // Longest delay counted as on time
+	Overall       Stats      `json:"overall"`       // All movements
+	ByTrain       []Stats    `json:"byTrain"`       // Per train number, least punctual first
+	ByDepot       []Stats    `json:"byDepot"`       // Per depot, least punctual first
+	ByWeek        []Stats    `json:"byWeek"`        // Per ISO week of the planned time, in week order
+	ByReason      []Stats    `json:"byReason"`      // Late movements per delay reason, most delay first
+	Worst         []Movement `json:"worst"`         // Most delayed movements, longest delay first
+	Unrecorded    int        `json:"unrecorded"`    // Planned movements without an actual time
+}
+
+// Summarize builds the punctuality report of schedule records.
+//
+// Parameters:
+//   - records: Records with planned times in or around the range
+//   - opts: Range, depot and on-time threshold (see Options)
+//
+// Returns:
+//   - Report of the movements planned within the range
+func Summarize(records []models.TrainSchedule, opts Options) Report {
+	report := Report{
+		From:          opts.From,
+		To:            opts.To,
+		Depot:         opts.Depot,
+		OnTimeMinutes: opts.OnTimeMinutes,
+		Overall:       Stats{Key: "all"},
+		Worst:         []Movement{},
+	}
+
+	byTrain := make(map[string]*Stats)
+	byDepot := make(map[string]*Stats)
+	byWeek := make(map[string]*Stats)
+	byReason := make(map[string]*Stats)
+	count := func(groups map[string]*Stats, key string, m Movement) {
+		stats := groups[key]
+		if stats == nil {
+			stats = &Stats{Key: key}
+			groups[key] = stats
+		}
+		stats.add(m, opts.OnTimeMinutes)
+	}
+
+	var late []Movement
+	for i := range records {
+		for _, m := range movements(&records[i]) {
+			if m.Planned.Before(opts.From) || !m.Planned.Before(opts.To) {
+				continue
+			}
+			if opts.Depot != "" && m.Depot != opts.Depot {
+				continue
+			}
+			if m.Actual.IsZero() {
+				report.Unrecorded++
+				continue
+			}
+
+			report.Overall.add(m, opts.OnTimeMinutes)
+			if m.TrainNumber != "" {
+				count(byTrain, m.TrainNumber, m)
+			}
+			if m.Depot != "" {
+				count(byDepot, m.Depot, m)
+			}
+			year, week := m.Planned.In(timeparse.Vilnius).ISOWeek()
+			count(byWeek, fmt.Sprintf("%04d-W%02d", year, week), m)
+			if m.DelayMinutes > opts.OnTimeMinutes {
+				count(byReason, m.DelayReason, m)
+				late = append(late, m)
+			}
+		}
+	}
+
+	report.Overall.finish()
+	report.ByTrain = leastPunctualFirst(byTrain)
+	report.ByDepot = leastPunctualFirst(byDepot)
+
+	report.ByWeek = collect(byWeek)
+	sort.Slice(report.ByWeek, func(a, b int) bool { return report.ByWeek[a].Key < report.ByWeek[b].Key })
+
+	report.ByReason = collect(byReason)
+	sort.Slice(report.ByReason, func(a, b int) bool {
+		x, y := report.ByReason[a], report.ByReason[b]
+		if x.TotalDelayMinutes != y.TotalDelayMinutes {
+			return x.TotalDelayMinutes > y.TotalDelayMinutes
+		}
+		return x.Key < y.Key
+	})
+
+	sort.SliceStable(late, func(a, b int) bool { return late[a].DelayMinutes > late[b].DelayMinutes })
+	if len(late) > opts.Worst {
+		late = late[:opts.Worst]
+	}
+	report.Worst = append(report.Worst, late...)
+
+	return report
+}
+
+// movements returns the departure and arrival of a record that have a
+// planned time. Movements without an actual time have a zero Actual.
+func movements(s *models.TrainSchedule) []Movement {
+	var list []Movement
+	add := func(kind, train, depot string, planned, actual *time.Time) {
+		if planned == nil {
+			return
+		}
+		m := Movement{
+			ScheduleID:  s.ID,
+			Kind:        kind,
+			TrainNumber: train,
+			Depot:       depot,
+			VehicleName: s.VehicleName,
+			Planned:     *planned,
+			DelayReason: s.DelayReason,
+		}
+		if actual != nil {
+			m.Actual = *actual
+			m.DelayMinutes = int(actual.Sub(*planned).Round(time.Minute) / time.Minute)
+		}
+		list = append(list, m)
+	}
+
+	add(Departure, s.TrainNumberDeparture, s.StartingLocation, s.DepartureDateTime, s.ActualDepartureDateTime)
+	add(Arrival, s.TrainNumberArrival, s.EndLocation, s.ArrivalDateTime, s.ActualArrivalDateTime)
+	return list
+}
+
+// collect finishes the grouped stats and returns them as a list.
+func collect(groups map[string]*Stats) []Stats {
+	list := make([]Stats, 0, len(groups))
+	for _, stats := range groups {
+		stats.finish()
+		list = append(list, *stats)
+	}
+	return list
+}
+
+// leastPunctualFirst orders grouped stats by average delay, then by on-time share.
+func leastPunctualFirst(groups map[string]*Stats) []Stats {
+	list := collect(groups)
+	sort.Slice(list, func(a, b int) bool {
+		x, y := list[a], list[b]
+		if x.AverageDelayMinutes != y.AverageDelayMinutes {
+			return x.AverageDelayMinutes > y.AverageDelayMinutes
+		}
+		if x.OnTimePercent != y.OnTimePercent {
+			return x.OnTimePercent < y.OnTimePercent
+		}
+		return x.Key < y.Key
+	})
+	return list
+}
+
+// round1 rounds to one decimal place.
+func round1(x float64) float64 {
+	return math.Round(x*10) / 10
+}
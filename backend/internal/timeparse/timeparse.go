@@ -0,0 +1,264 @@
+// backend/internal/timeparse/timeparse.go
+package timeparse
+
+// This package parses the date and time cells found in schedule exports.
+// Every import path uses it, so a cell means the same thing whether it was
+// pasted from Klasika or uploaded in an Antras workbook.
+//
+// Wall-clock values are interpreted in the Europe/Vilnius timezone. The
+// resulting time.Time values are absolute instants; the MySQL driver stores
+// them in the connection timezone (UTC by default).
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "time/tzdata" // Embedded zone database, so Vilnius resolves in minimal containers
+)
+
+// Vilnius is the timezone of all schedule times.
+var Vilnius = mustLoadLocation("Europe/Vilnius")
+
+// maxDayOffset limits "(+N)" offsets; larger values are treated as typos.
+const maxDayOffset = 7
+
+// Excel serial dates count days from 1899-12-30 (the 1900 leap year bug
+// is already accounted for in this epoch for dates after 1900-03-01).
+var excelEpoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+// maxExcelSerial is 9999-12-31, the last date Excel can represent.
+const maxExcelSerial = 2958465
+
+// minTimeCellSerial is 2000-01-01. Time cells holding a smaller whole number,
+// such as "830", are typos rather than dates and times.
+const minTimeCellSerial = 36526
+
+// dateLayouts lists the accepted date formats, tried in order.
+var dateLayouts = []string{"2006-01-02", "2006.01.02", "2006/01/02", "02.01.2006", "2.1.2006"}
+
+// timestampLayouts lists the accepted date and time formats, tried in order.
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006.01.02 15:04",
+	"02.01.2006 15:04",
+}
+
+var (
+	clockPattern   = regexp.MustCompile(`^(\d{1,2}):(\d{2})(?::(\d{2}))?(?:\s*\(([+-]\d+)\))?$`)
+	isoDatePrefix  = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T`)
+	excelSerialFmt = regexp.MustCompile(`^\d+(\.\d+)?$`)
+)
+
+// Error describes a malformed cell.
+type Error struct {
+	Kind   string // "date", "time" or "date and time"
+	Value  string // Original cell value
+	Reason string // What was expected or out of range
+}
+
+// Error implements the error interface,
+// e.g. `invalid time "25:10": hour out of range`.
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid %s %q: %s", e.Kind, e.Value, e.Reason)
+}
+
+// Clock is a time of day with an optional day offset, as in "00:24 (+1)".
+type Clock struct {
+	Hour      int
+	Minute    int
+	Second    int
+	DayOffset int // Days relative to the base date
+}
+
+// ParseClock parses a time cell. Accepted forms:
+//   - "08:48" or "08:48:30"
+//   - "23:59 (+1)" or "00:24 (-1)" with a day offset
+//   - an Excel time value, i.e. a fraction of a day in [0, 1) ("0.5" = 12:00)
+//
+// Other numbers, such as "8" or "830", are rejected rather than read as midnight.
+func ParseClock(value string) (Clock, error) {
+	return parseClock(value, false)
+}
+
+// ParseTimeCell parses the time cell of a row that has its own date cell, as
+// ParseDateTime reads it: like ParseClock, but a full Excel date and time
+// serial from 2000 on ("45800.25") is accepted too and its time of day is
+// used. Importers validate time cells with it, so the rows ParseDateTime
+// would read are not rejected first.
+func ParseTimeCell(value string) (Clock, error) {
+	return parseClock(value, true)
+}
+
+// parseClock parses a time cell like ParseClock. With serial, the cell may
+// also be a full Excel date and time serial, whose time of day is used; the
+// date comes from another cell.
+func parseClock(value string, serial bool) (Clock, error) {
+	value = strings.TrimSpace(value)
+
+	if excelSerialFmt.MatchString(value) {
+		fraction, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return Clock{}, &Error{Kind: "time", Value: value, Reason: "not a number"}
+		}
+		if fraction >= 1 && (!serial || fraction < minTimeCellSerial) {
+			return Clock{}, &Error{Kind: "time", Value: value, Reason: "expected HH:MM or an Excel time between 0 and 1"}
+		}
+		if fraction > maxExcelSerial {
+			return Clock{}, &Error{Kind: "time", Value: value, Reason: "serial date out of range"}
+		}
+		seconds := int(math.Round((fraction - math.Floor(fraction)) * 24 * 60 * 60))
+		if seconds == 24*60*60 {
+			return Clock{DayOffset: 1}, nil
+		}
+		return Clock{Hour: seconds / 3600, Minute: seconds / 60 % 60, Second: seconds % 60}, nil
+	}
+
+	match := clockPattern.FindStringSubmatch(value)
+	if match == nil {
+		return Clock{}, &Error{Kind: "time", Value: value, Reason: "expected HH:MM, HH:MM:SS or HH:MM (+N)"}
+	}
+
+	var c Clock
+	c.Hour, _ = strconv.Atoi(match[1])
+	c.Minute, _ = strconv.Atoi(match[2])
+	if match[3] != "" {
+		c.Second, _ = strconv.Atoi(match[3])
+	}
+	if match[4] != "" {
+		c.DayOffset, _ = strconv.Atoi(match[4])
+	}
+
+	switch {
+	case c.Hour > 23:
+		return Clock{}, &Error{Kind: "time", Value: value, Reason: "hour out of range"}
+	case c.Minute > 59:
+		return Clock{}, &Error{Kind: "time", Value: value, Reason: "minute out of range"}
+	case c.Second > 59:
+		return Clock{}, &Error{Kind: "time", Value: value, Reason: "second out of range"}
+	case c.DayOffset > maxDayOffset || c.DayOffset < -maxDayOffset:
+		return Clock{}, &Error{Kind: "time", Value: value, Reason: "day offset out of range"}
+	}
+
+	return c, nil
+}
+
+// ParseDate parses a date cell and returns midnight of that day in Vilnius.
+// Accepted forms are YYYY-MM-DD (optionally followed by a time, which is
+// ignored), YYYY.MM.DD, YYYY/MM/DD, DD.MM.YYYY and Excel serial dates.
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if excelSerialFmt.MatchString(value) {
+		t, err := fromExcelSerial(value, "date")
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, Vilnius), nil
+	}
+
+	if isoDatePrefix.MatchString(value) || (len(value) > 10 && value[10] == ' ') {
+		value = value[:10]
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, Vilnius); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, &Error{
+		Kind:   "date",
+		Value:  value,
+		Reason: "expected YYYY-MM-DD, YYYY.MM.DD, DD.MM.YYYY or an Excel serial date",
+	}
+}
+
+// ParseDateTime combines a date cell and a time cell into a Vilnius timestamp.
+// The clock's day offset moves the result by whole days, so
+// ParseDateTime("2025-05-23", "00:24 (+1)") is 2025-05-24 00:24. The time
+// cell may also be a full Excel serial; only its time of day is used.
+// Returns nil without error when either cell is empty.
+func ParseDateTime(dateValue, clockValue string) (*time.Time, error) {
+	if strings.TrimSpace(dateValue) == "" || strings.TrimSpace(clockValue) == "" {
+		return nil, nil
+	}
+	date, err := ParseDate(dateValue)
+	if err != nil {
+		return nil, err
+	}
+	clock, err := ParseTimeCell(clockValue)
+	if err != nil {
+		return nil, err
+	}
+	t := At(date, clock)
+	return &t, nil
+}
+
+// At places a clock on the given day in Vilnius.
+// Wall-clock times skipped by the spring DST change are moved forward by an hour.
+func At(date time.Time, c Clock) time.Time {
+	date = date.In(Vilnius)
+	return time.Date(
+		date.Year(), date.Month(), date.Day()+c.DayOffset,
+		c.Hour, c.Minute, c.Second, 0,
+		Vilnius,
+	)
+}
+
+// ParseTimestamp parses a cell holding both date and time,
+// either as text ("2025-05-23 08:48") or as an Excel serial with a fraction.
+func ParseTimestamp(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if excelSerialFmt.MatchString(value) {
+		t, err := fromExcelSerial(value, "date and time")
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, Vilnius), nil
+	}
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, value, Vilnius); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(Vilnius), nil
+	}
+
+	return time.Time{}, &Error{
+		Kind:   "date and time",
+		Value:  value,
+		Reason: "expected YYYY-MM-DD HH:MM[:SS], RFC 3339 or an Excel serial date",
+	}
+}
+
+// fromExcelSerial converts an Excel serial number into a wall-clock time (UTC fields).
+func fromExcelSerial(value, kind string) (time.Time, error) {
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return time.Time{}, &Error{Kind: kind, Value: value, Reason: "not a number"}
+	}
+	if serial < 1 || serial > maxExcelSerial {
+		return time.Time{}, &Error{Kind: kind, Value: value, Reason: "serial date out of range"}
+	}
+	days := math.Floor(serial)
+	seconds := math.Round((serial - days) * 24 * 60 * 60)
+	return excelEpoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second), nil
+}
+
+// mustLoadLocation loads a timezone from the embedded zone database
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(fmt.Sprintf("timeparse: cannot load timezone %s: %v", name, err))
+	}
+	return loc
+}
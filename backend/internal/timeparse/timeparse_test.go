@@ -0,0 +1,83 @@
+// backend/internal/timeparse/timeparse_test.go
+package timeparse
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Clock
+		ok    bool
+	}{
+		{"08:48", Clock{Hour: 8, Minute: 48}, true},
+		{"08:48:30", Clock{Hour: 8, Minute: 48, Second: 30}, true},
+		{"00:24 (+1)", Clock{Minute: 24, DayOffset: 1}, true},
+		{"0.5", Clock{Hour: 12}, true},
+		{"0", Clock{}, true},
+		{"0.99999999", Clock{DayOffset: 1}, true},
+		{"8", Clock{}, false},
+		{"830", Clock{}, false},
+		{"23", Clock{}, false},
+		{"1.5", Clock{}, false},
+		{"45800.25", Clock{}, false},
+		{"25:10", Clock{}, false},
+		{"8.48", Clock{}, false},
+		{"", Clock{}, false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseClock(tt.value)
+		if tt.ok {
+			if err != nil || got != tt.want {
+				t.Errorf("ParseClock(%q) = %+v, %v, want %+v", tt.value, got, err, tt.want)
+			}
+			continue
+		}
+		var parseErr *Error
+		if !errors.As(err, &parseErr) {
+			t.Errorf("ParseClock(%q) error = %v, want *Error", tt.value, err)
+		}
+	}
+}
+
+func TestParseDateTimeWithSerialClock(t *testing.T) {
+	// 45800.25 is 2025-05-23 06:00; only the time of day counts
+	got, err := ParseDateTime("2025-06-01", "45800.25")
+	if err != nil {
+		t.Fatalf("ParseDateTime() error = %v", err)
+	}
+	want := time.Date(2025, 6, 1, 6, 0, 0, 0, Vilnius)
+	if !got.Equal(want) {
+		t.Errorf("ParseDateTime() = %s, want %s", got, want)
+	}
+
+	if _, err := ParseDateTime("2025-06-01", "3000000"); err == nil {
+		t.Errorf("ParseDateTime() with an out of range serial succeeded")
+	}
+}
+
+func TestParseTimeCell(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Clock
+		ok    bool
+	}{
+		{"08:30", Clock{Hour: 8, Minute: 30}, true},
+		{"0.75", Clock{Hour: 18}, true},
+		{"45800.25", Clock{Hour: 6}, true},
+		{"45800", Clock{}, true},
+		{"830", Clock{}, false},
+		{"3000000", Clock{}, false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTimeCell(tt.value)
+		if (err == nil) != tt.ok || (tt.ok && got != tt.want) {
+			t.Errorf("ParseTimeCell(%q) = %+v, %v, want %+v, ok %v", tt.value, got, err, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+// backend/internal/vehiclename/vehiclename.go
+package vehiclename
+
+// This package builds vehicle display names from the "Technical vehicle type"
+// and "Vehicle no." columns of an import. Both columns may list several coupled
+// units separated by commas; the rules decide which unit names the train.
+//
+// Templates may use these placeholders:
+//   - {vehicle}  selected vehicle number (e.g. "631-010")
+//   - {number}   part of the vehicle number after the first dash ("010")
+//   - {prefix}   part of the vehicle number before the first dash ("631")
+//   - {type}     selected technical vehicle type
+//   - {typeBase} type without the motor car marker: first word of the type,
+//     or the type without a trailing "m" ("DR1AMvm" -> "DR1AMv")
+//   - {count}    number of units in the consist
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"yopta-template/internal/models"
+)
+
+// Rule strategies
+const (
+	StrategyFirst        = "first"         // Use the first unit of the consist
+	StrategyMatchVehicle = "match_vehicle" // Use the first vehicle matching MatchPattern
+	StrategyMatchType    = "match_type"    // Use the first type matching MatchPattern and the vehicle at the same position
+)
+
+// placeholderPattern finds {placeholder} tokens in templates
+var placeholderPattern = regexp.MustCompile(`\{([a-zA-Z]+)\}`)
+
+// knownPlaceholders lists the placeholders templates may use
+var knownPlaceholders = map[string]bool{
+	"vehicle": true, "number": true, "prefix": true,
+	"type": true, "typeBase": true, "count": true,
+}
+
+// Resolution is the result of resolving one type/number pair.
+type Resolution struct {
+	Name     string `json:"name"`                // Resulting display name
+	RuleID   *int   `json:"rule_id"`             // Matching rule, nil if the default was used
+	RuleName string `json:"rule_name,omitempty"` // Name of the matching rule
+	Fallback bool   `json:"fallback"`            // True if the rule's match pattern found nothing
+}
+
+// compiledRule is a rule with its regular expressions prepared
+type compiledRule struct {
+	rule  models.VehicleNameRule
+	typeP *regexp.Regexp
+	match *regexp.Regexp
+}
+
+// Engine resolves vehicle names with a fixed, ordered set of rules.
+// It is safe for concurrent use.
+type Engine struct {
+	rules []compiledRule
+}
+
+// New compiles the rules in the given order. Inactive rules are skipped.
+//
+// Returns:
+//   - Engine ready to resolve names
+//   - Error naming the first invalid rule
+func New(rules []models.VehicleNameRule) (*Engine, error) {
+	engine := &Engine{}
+	for _, rule := range rules {
+		if !rule.IsActive {
+			continue
+		}
+		compiled, err := compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		engine.rules = append(engine.rules, compiled)
+	}
+	return engine, nil
+}
+
+// Validate checks a rule before it is stored: patterns must compile,
+// the strategy must be known and templates may only use known placeholders.
+func Validate(rule models.VehicleNameRule) error {
+	_, err := compile(rule)
+	return err
+}
+
+// compile validates a rule and prepares its regular expressions
+func compile(rule models.VehicleNameRule) (compiledRule, error) {
+	compiled := compiledRule{rule: rule}
+
+	if rule.TypePattern == "" {
+		return compiled, fmt.Errorf("type pattern is required")
+	}
+	typeP, err := regexp.Compile(rule.TypePattern)
+	if err != nil {
+		return compiled, fmt.Errorf("invalid type pattern: %w", err)
+	}
+	compiled.typeP = typeP
+
+	switch rule.Strategy {
+	case StrategyFirst:
+	case StrategyMatchVehicle, StrategyMatchType:
+		if rule.MatchPattern == "" {
+			return compiled, fmt.Errorf("match pattern is required for strategy %q", rule.Strategy)
+		}
+		match, err := regexp.Compile(rule.MatchPattern)
+		if err != nil {
+			return compiled, fmt.Errorf("invalid match pattern: %w", err)
+		}
+		compiled.match = match
+	default:
+		return compiled, fmt.Errorf("unknown strategy %q", rule.Strategy)
+	}
+
+	for _, template := range []string{rule.OutputTemplate, rule.FallbackTemplate} {
+		for _, m := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+			if !knownPlaceholders[m[1]] {
+				return compiled, fmt.Errorf("unknown placeholder {%s}", m[1])
+			}
+		}
+	}
+
+	return compiled, nil
+}
+
+// Resolve builds the display name for a type/number pair.
+// When no rule matches, the first vehicle number is used as is.
+// Empty input gives an empty name.
+func (e *Engine) Resolve(typeStr, vehicleStr string) Resolution {
+	if typeStr == "" || vehicleStr == "" {
+		return Resolution{}
+	}
+
+	types := splitList(typeStr)
+	for i := range types {
+		types[i] = strings.TrimPrefix(types[i], "*")
+	}
+	vehicles := splitList(vehicleStr)
+
+	for _, r := range e.rules {
+		if !r.typeP.MatchString(types[0]) {
+			continue
+		}
+		id := r.rule.ID
+		res := Resolution{RuleID: &id, RuleName: r.rule.Name}
+
+		index := -1
+		switch r.rule.Strategy {
+		case StrategyFirst:
+			index = 0
+		case StrategyMatchVehicle:
+			for i, v := range vehicles {
+				if r.match.MatchString(v) {
+					index = i
+					break
+				}
+			}
+		case StrategyMatchType:
+			for i, t := range types {
+				if r.match.MatchString(t) {
+					index = i
+					break
+				}
+			}
+			if index >= len(vehicles) {
+				index = -1
+			}
+		}
+
+		if index < 0 {
+			res.Fallback = true
+			res.Name = render(r.rule.FallbackTemplate, types, vehicles, 0, 0)
+			return res
+		}
+
+		// match_vehicle keeps the first type; match_type pairs type and vehicle by position
+		typeIndex := 0
+		if r.rule.Strategy == StrategyMatchType {
+			typeIndex = index
+		}
+		res.Name = render(r.rule.OutputTemplate, types, vehicles, typeIndex, index)
+		return res
+	}
+
+	return Resolution{Name: vehicles[0]}
+}
+
+// Name is a shorthand for Resolve(...).Name
+func (e *Engine) Name(typeStr, vehicleStr string) string {
+	return e.Resolve(typeStr, vehicleStr).Name
+}
+
+// render fills a template with values of the selected type and vehicle
+func render(template string, types, vehicles []string, typeIndex, vehicleIndex int) string {
+	vehicle := vehicles[vehicleIndex]
+	vehicleParts := strings.Split(vehicle, "-")
+	number := ""
+	if len(vehicleParts) > 1 {
+		number = vehicleParts[1]
+	}
+	typ := types[typeIndex]
+
+	values := map[string]string{
+		"vehicle":  vehicle,
+		"number":   number,
+		"prefix":   vehicleParts[0],
+		"type":     typ,
+		"typeBase": typeBase(typ),
+		"count":    strconv.Itoa(len(types)),
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(template, func(token string) string {
+		return values[token[1:len(token)-1]]
+	})
+}
+
+// typeBase strips the motor car marker from a type:
+// "DR1A 3m" -> "DR1A", "DR1AMvm" -> "DR1AMv", "RA-2" -> "RA-2"
+func typeBase(typ string) string {
+	if strings.Contains(typ, " ") {
+		return strings.Split(typ, " ")[0]
+	}
+	return strings.TrimSuffix(typ, "m")
+}
+
+// splitList splits a comma-separated cell into trimmed items
+func splitList(value string) []string {
+	items := strings.Split(value, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
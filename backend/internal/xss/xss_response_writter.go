@@ -53,6 +53,7 @@ func (x *xssResponseWriter) WriteHeader(statusCode int) {
 // Write intercepts data being written to the response and applies XSS sanitization.
 // The sanitization approach varies based on the content type:
 // - For JSON responses: It parses, sanitizes, and re-serializes the JSON data
+// - For NDJSON streams: Each write is one JSON line and is sanitized the same way
 // - For other content types: It passes the data through without modification
 //
 // This method ensures that all outgoing data is properly sanitized while maintaining
@@ -78,7 +79,24 @@ func (x *xssResponseWriter) Write(b []byte) (int, error) {
 		return x.ResponseWriter.Write(sanitized)
 	}
 
+	// Streamed responses write one JSON value per line, so each write can be sanitized alone
+	if strings.Contains(contentType, "application/x-ndjson") {
+		sanitized, err := utils.SanitizeJSON(b)
+		if err != nil {
+			return x.ResponseWriter.Write(b)
+		}
+		return x.ResponseWriter.Write(append(sanitized, '\n'))
+	}
+
 	// For other content types (images, files, etc.), pass through unchanged
 	// NOTE: HTML content could be sanitized here in the future if needed
 	return x.ResponseWriter.Write(b)
 }
+
+// Flush sends buffered data to the client, so streamed responses
+// (e.g. import progress) arrive while the handler is still running.
+func (x *xssResponseWriter) Flush() {
+	if flusher, ok := x.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,36 @@
+-- +goose Up
+-- Migration to create train_schedules table for persisted Klasika schedule records
+-- Each record pairs an arrival train with a departure train for one vehicle at a depot
+
+CREATE TABLE IF NOT EXISTS train_schedules (
+    id VARCHAR(191) NOT NULL PRIMARY KEY COMMENT 'Record key derived from vehicle working, date and trip number',
+    train_number_departure VARCHAR(50) NOT NULL DEFAULT '',
+    train_number_arrival VARCHAR(50) NOT NULL DEFAULT '',
+    vehicle_name VARCHAR(100) NOT NULL DEFAULT '',
+    starting_location VARCHAR(50) NOT NULL DEFAULT '' COMMENT 'Departure depot code',
+    end_location VARCHAR(50) NOT NULL DEFAULT '' COMMENT 'Arrival depot code',
+    departure_date_time DATETIME NULL,
+    arrival_date_time DATETIME NULL,
+    starting_track VARCHAR(50) NOT NULL DEFAULT '',
+    target_track VARCHAR(50) NOT NULL DEFAULT '',
+    employee1_departure VARCHAR(255) NOT NULL DEFAULT '',
+    employee1_arrival VARCHAR(255) NOT NULL DEFAULT '',
+    duty_departure VARCHAR(100) NOT NULL DEFAULT '',
+    duty_arrival VARCHAR(100) NOT NULL DEFAULT '',
+    notes TEXT NOT NULL,
+    raw_data MEDIUMTEXT NOT NULL COMMENT 'Original imported row as JSON',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    user_id INT NULL,
+
+    KEY idx_train_schedules_user_id (user_id),
+    KEY idx_train_schedules_departure (starting_location, departure_date_time),
+    KEY idx_train_schedules_arrival (end_location, arrival_date_time),
+    KEY idx_train_schedules_departure_time (departure_date_time),
+    KEY idx_train_schedules_arrival_time (arrival_date_time),
+    KEY idx_train_schedules_vehicle (vehicle_name),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Stores train schedule records imported into Klasika';
+
+-- +goose Down
+DROP TABLE IF EXISTS train_schedules;
@@ -0,0 +1,67 @@
+-- +goose Up
+-- Migration to persist Antras workbook imports
+-- Every uploaded workbook becomes an audited import with its parsed rows and crew members
+
+CREATE TABLE IF NOT EXISTS antras_imports (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    file_name VARCHAR(255) NOT NULL,
+    sheets_count INT NOT NULL DEFAULT 0 COMMENT 'Number of imported sheets after depot merging',
+    records_count INT NOT NULL DEFAULT 0,
+    skipped_sheets INT NOT NULL DEFAULT 0,
+    duplicates_skipped INT NOT NULL DEFAULT 0,
+    errors MEDIUMTEXT NOT NULL COMMENT 'Sheet and row errors as JSON',
+    user_id INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    KEY idx_antras_imports_user_id (user_id),
+    KEY idx_antras_imports_created_at (created_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Audit trail of Antras workbook imports';
+
+CREATE TABLE IF NOT EXISTS antras_records (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    import_id BIGINT NOT NULL,
+    station_code VARCHAR(50) NOT NULL COMMENT 'Sheet name with depot suffix normalized (LTE+D -> LTE.D)',
+    original_sheet VARCHAR(100) NOT NULL,
+    sheet_row INT NOT NULL COMMENT 'Row number within the original sheet',
+    network_point_name VARCHAR(255) NOT NULL DEFAULT '',
+    train_no_in VARCHAR(50) NOT NULL DEFAULT '',
+    train_no_out VARCHAR(50) NOT NULL DEFAULT '',
+    vehicle_in VARCHAR(100) NOT NULL DEFAULT '',
+    vehicle_out VARCHAR(100) NOT NULL DEFAULT '',
+    vehicle_working_in VARCHAR(100) NOT NULL DEFAULT '',
+    vehicle_working_out VARCHAR(100) NOT NULL DEFAULT '',
+    validity_in DATE NULL,
+    validity_out DATE NULL,
+    arrival_date_time DATETIME NULL,
+    departure_date_time DATETIME NULL,
+    raw_data MEDIUMTEXT NOT NULL COMMENT 'Mapped row values as JSON',
+
+    KEY idx_antras_records_import (import_id),
+    KEY idx_antras_records_station (station_code),
+    KEY idx_antras_records_arrival (arrival_date_time),
+    KEY idx_antras_records_departure (departure_date_time),
+    FOREIGN KEY (import_id) REFERENCES antras_imports(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Parsed rows of Antras workbook imports';
+
+CREATE TABLE IF NOT EXISTS antras_staff (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    record_id BIGINT NOT NULL,
+    direction ENUM('in', 'out') NOT NULL COMMENT 'Crew of the incoming or outgoing train',
+    personnel_number VARCHAR(50) NOT NULL DEFAULT '',
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    phone VARCHAR(100) NOT NULL DEFAULT '',
+    occupation VARCHAR(1) NOT NULL DEFAULT '' COMMENT 'M = driver, K = conductor',
+    duty VARCHAR(100) NOT NULL DEFAULT '',
+    duty_start DATETIME NULL,
+    duty_end DATETIME NULL,
+
+    KEY idx_antras_staff_record (record_id),
+    KEY idx_antras_staff_personnel (personnel_number),
+    FOREIGN KEY (record_id) REFERENCES antras_records(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Crew members listed on Antras rows';
+
+-- +goose Down
+DROP TABLE IF EXISTS antras_staff;
+DROP TABLE IF EXISTS antras_records;
+DROP TABLE IF EXISTS antras_imports;
@@ -0,0 +1,35 @@
+-- +goose Up
+-- Migration to create vehicle_name_rules table
+-- Rules turn "Technical vehicle type" and "Vehicle no." columns into display names,
+-- so new rolling stock classes can be configured without a frontend release
+
+CREATE TABLE vehicle_name_rules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL COMMENT 'Short rule name shown in the admin UI',
+    type_pattern VARCHAR(255) NOT NULL COMMENT 'Regular expression matched against the first technical vehicle type',
+    strategy ENUM('first', 'match_vehicle', 'match_type') NOT NULL DEFAULT 'first' COMMENT 'Which unit of the consist names the train',
+    match_pattern VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'Regular expression for match_vehicle (vehicle numbers) or match_type (types)',
+    output_template VARCHAR(255) NOT NULL DEFAULT '{vehicle}' COMMENT 'Display name template, e.g. "630MiL-{number}"',
+    fallback_template VARCHAR(255) NOT NULL DEFAULT '{vehicle}' COMMENT 'Template used when match_pattern finds nothing',
+    sort_order INT DEFAULT 0 COMMENT 'Rules are tried in this order, the first matching one wins',
+    is_active BOOLEAN DEFAULT TRUE,
+    description TEXT COMMENT 'Rule description for documentation',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY idx_vehicle_name_rules_name (name),
+    KEY idx_vehicle_name_rules_sort_order (sort_order)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Vehicle display name normalization rules';
+
+-- Default rules reproduce the naming used on the Antras page
+INSERT INTO vehicle_name_rules (name, type_pattern, strategy, match_pattern, output_template, fallback_template, sort_order, description) VALUES
+('Single vehicle', '^(620M|Siemens)$', 'first', '', '{vehicle}', '{vehicle}', 1, 'Locomotives are named by their vehicle number'),
+('Passenger cars', '^(Seat|Coupe)$', 'first', '', '{count} vag. {prefix}', '{vehicle}', 2, 'Wagon count followed by the first wagon series'),
+('630 series', '^630', 'match_vehicle', '^631-', '630MiL-{number}', '{vehicle}', 3, 'Named after the 631-XXX motor car'),
+('730 series', '^730', 'match_vehicle', '^731-', '730ML-{number}', '{vehicle}', 4, 'Named after the 731-XXX motor car'),
+('EJ575', '^EJ575', 'match_vehicle', '^211-', 'EJ575-{number}', '{vehicle}', 5, 'Named after the 211-XXX car'),
+('DR1A', '^DR1A', 'match_type', '(?i:m[^c]?$)| m', '{typeBase} {vehicle}', '{type} {vehicle}', 6, 'Named after the unit whose type carries the motor car marker "m"'),
+('RA-2', '^RA-2', 'match_vehicle', '-01$', '{typeBase}-{prefix}', '{vehicle}', 7, 'Named after the head car ending with -01');
+
+-- +goose Down
+DROP TABLE IF EXISTS vehicle_name_rules;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- Migration to keep the change history of train schedule records
+-- Every field change is stored with its old and new value, the user and the request ID,
+-- so dispatchers' track moves and notes can be traced and reverted
+
+CREATE TABLE IF NOT EXISTS train_schedule_revisions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    schedule_id VARCHAR(191) NOT NULL COMMENT 'Train schedule record ID (kept after the record is deleted)',
+    action ENUM('create', 'update', 'delete', 'revert') NOT NULL,
+    field VARCHAR(50) NOT NULL DEFAULT '' COMMENT 'Changed field in camelCase, empty for create and delete',
+    old_value MEDIUMTEXT NULL COMMENT 'Value before the change (whole record as JSON for delete)',
+    new_value MEDIUMTEXT NULL COMMENT 'Value after the change (whole record as JSON for create)',
+    reverted_revision_id BIGINT NULL COMMENT 'Revision restored by a revert',
+    user_id INT NULL,
+    request_id VARCHAR(64) NOT NULL DEFAULT '' COMMENT 'X-Request-ID of the request that made the change',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    KEY idx_train_schedule_revisions_schedule (schedule_id, id),
+    KEY idx_train_schedule_revisions_user (user_id),
+    KEY idx_train_schedule_revisions_request (request_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Change history of train schedule records';
+
+-- +goose Down
+DROP TABLE IF EXISTS train_schedule_revisions;
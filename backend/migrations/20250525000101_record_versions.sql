@@ -0,0 +1,18 @@
+-- +goose Up
+-- Migration to add version counters for optimistic concurrency control
+-- Every write increments the version; clients send the version they edited
+-- (If-Match header or "version" field) and stale writes are rejected with 409
+
+ALTER TABLE train_schedules
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1 COMMENT 'Incremented on every change' AFTER updated_at;
+
+ALTER TABLE stations
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1 COMMENT 'Incremented on every change of the station or its tracks' AFTER updated_at;
+
+ALTER TABLE tracks
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1 COMMENT 'Incremented on every change' AFTER updated_at;
+
+-- +goose Down
+ALTER TABLE tracks DROP COLUMN version;
+ALTER TABLE stations DROP COLUMN version;
+ALTER TABLE train_schedules DROP COLUMN version;
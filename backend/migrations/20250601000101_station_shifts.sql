@@ -0,0 +1,33 @@
+-- +goose Up
+-- Migration to create station_shifts table
+-- Each depot defines its own shift windows instead of the fixed day (06:00-20:00)
+-- and night (18:00-08:00) shifts, plus how far before and after a shift
+-- records are still shown as early and late spill-over
+
+CREATE TABLE station_shifts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    station_id INT NOT NULL,
+    name VARCHAR(50) NOT NULL COMMENT 'Shift name used in schedule queries, e.g. "day"',
+    start_time TIME NOT NULL COMMENT 'Local (Vilnius) time the shift starts',
+    end_time TIME NOT NULL COMMENT 'Local (Vilnius) time the shift ends',
+    crosses_midnight BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'Whether the shift ends on the next day',
+    early_minutes INT NOT NULL DEFAULT 120 COMMENT 'Spill-over window before the shift start',
+    late_minutes INT NOT NULL DEFAULT 120 COMMENT 'Spill-over window after the shift end',
+    sort_order INT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (station_id) REFERENCES stations(id) ON DELETE CASCADE,
+    UNIQUE KEY idx_station_shifts_name (station_id, name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Shift windows of stations/depots';
+
+-- Existing stations get the shifts the Klasika page used so far
+INSERT INTO station_shifts (station_id, name, start_time, end_time, crosses_midnight, sort_order)
+SELECT id, 'day', '06:00', '20:00', FALSE, 1 FROM stations;
+INSERT INTO station_shifts (station_id, name, start_time, end_time, crosses_midnight, sort_order)
+SELECT id, 'night', '18:00', '08:00', TRUE, 2 FROM stations;
+INSERT INTO station_shifts (station_id, name, start_time, end_time, crosses_midnight, sort_order)
+SELECT id, 'all', '00:00', '00:00', TRUE, 3 FROM stations;
+
+-- +goose Down
+DROP TABLE IF EXISTS station_shifts;
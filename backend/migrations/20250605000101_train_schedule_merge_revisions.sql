@@ -0,0 +1,12 @@
+-- +goose Up
+-- Migration to record duplicate merges in the train schedule history
+-- A merged-away record gets a 'merge' revision holding its last state (old_value)
+-- and the ID of the record it was merged into (new_value)
+
+ALTER TABLE train_schedule_revisions
+    MODIFY COLUMN action ENUM('create', 'update', 'delete', 'revert', 'merge') NOT NULL;
+
+-- +goose Down
+DELETE FROM train_schedule_revisions WHERE action = 'merge';
+ALTER TABLE train_schedule_revisions
+    MODIFY COLUMN action ENUM('create', 'update', 'delete', 'revert') NOT NULL;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Migration to make the crew duty limits configurable
+-- Antras crew duties are checked against these values (in minutes)
+
+INSERT INTO system_settings (setting_key, setting_value, description)
+VALUES
+    ('crew_max_duty_minutes', '720', 'Longest allowed crew duty in minutes'),
+    ('crew_min_rest_minutes', '720', 'Shortest allowed crew rest between duties in minutes')
+ON DUPLICATE KEY UPDATE setting_key = setting_key;
+
+-- +goose Down
+DELETE FROM system_settings WHERE setting_key IN ('crew_max_duty_minutes', 'crew_min_rest_minutes');
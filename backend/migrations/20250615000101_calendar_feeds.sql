@@ -0,0 +1,23 @@
+-- +goose Up
+-- Migration to create calendar_feeds table
+-- Each feed publishes the duties of one employee as an iCalendar (.ics) file
+-- at a secret URL. Only a hash of the URL token is stored, and a revoked feed
+-- stops serving without its row being removed
+
+CREATE TABLE calendar_feeds (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    token_hash CHAR(64) NOT NULL COMMENT 'SHA-256 of the feed token (hex)',
+    personnel_number VARCHAR(50) NOT NULL DEFAULT '' COMMENT 'Antras personnel number of the employee',
+    employee_name VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'Employee as written in the schedule employee fields',
+    user_id INT NULL COMMENT 'User who created the feed',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at DATETIME NULL,
+    last_accessed_at DATETIME NULL,
+
+    UNIQUE KEY idx_calendar_feeds_token (token_hash),
+    KEY idx_calendar_feeds_personnel (personnel_number),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Per-employee iCalendar duty feeds';
+
+-- +goose Down
+DROP TABLE IF EXISTS calendar_feeds;
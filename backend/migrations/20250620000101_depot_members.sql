@@ -0,0 +1,22 @@
+-- +goose Up
+-- Migration to create depot_members table
+-- Users assigned to a depot may work with every train schedule record that
+-- departs from or arrives at it, not only with the records they created:
+-- editors may change them, viewers may only read them
+
+CREATE TABLE depot_members (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    station_id INT NOT NULL,
+    user_id INT NOT NULL,
+    role ENUM('editor', 'viewer') NOT NULL DEFAULT 'viewer',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY idx_depot_members_user (user_id, station_id),
+    KEY idx_depot_members_station (station_id),
+    FOREIGN KEY (station_id) REFERENCES stations(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Depot teams with per-depot roles';
+
+-- +goose Down
+DROP TABLE IF EXISTS depot_members;
@@ -0,0 +1,34 @@
+-- +goose Up
+-- Migration to create editable_fields table
+-- Lists the train schedule fields users may edit one at a time, with the
+-- rules a new value must pass. Fields not listed here or not active are read-only
+
+CREATE TABLE editable_fields (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    field_name VARCHAR(64) NOT NULL COMMENT 'Train schedule JSON field, e.g. vehicleName',
+    value_type ENUM('text', 'integer', 'datetime') NOT NULL DEFAULT 'text',
+    max_length INT NULL COMMENT 'Longest value in characters, NULL for the column size',
+    allowed_values JSON NULL COMMENT 'Values the field may take, NULL for any value',
+    is_required BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'Whether the field may be cleared',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY idx_editable_fields_name (field_name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Editable train schedule fields and their validation';
+
+-- Tracks and notes were the editable fields before they became configurable
+INSERT INTO editable_fields (field_name, value_type, max_length, is_active, description)
+VALUES
+    ('startingTrack', 'text', 50, TRUE, 'Track the vehicle departs from'),
+    ('targetTrack', 'text', 50, TRUE, 'Track the vehicle arrives at'),
+    ('notes', 'text', NULL, TRUE, 'Dispatcher notes'),
+    ('vehicleName', 'text', 100, FALSE, 'Vehicle display name'),
+    ('startingLocation', 'text', 50, FALSE, 'Departure depot code'),
+    ('endLocation', 'text', 50, FALSE, 'Arrival depot code'),
+    ('dutyDeparture', 'text', 100, FALSE, 'Crew duty code of the departure'),
+    ('dutyArrival', 'text', 100, FALSE, 'Crew duty code of the arrival');
+
+-- +goose Down
+DROP TABLE IF EXISTS editable_fields;
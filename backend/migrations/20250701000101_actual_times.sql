@@ -0,0 +1,30 @@
+-- +goose Up
+-- Migration to record actual departure and arrival times next to the planned ones
+-- Dispatchers fill these in with a delay reason; the difference to the planned
+-- times feeds the punctuality statistics
+
+ALTER TABLE train_schedules
+    ADD COLUMN actual_departure_date_time DATETIME NULL COMMENT 'When the train actually departed' AFTER arrival_date_time,
+    ADD COLUMN actual_arrival_date_time DATETIME NULL COMMENT 'When the train actually arrived' AFTER actual_departure_date_time,
+    ADD COLUMN delay_reason VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'Why the train was late' AFTER actual_arrival_date_time;
+
+-- Dispatchers record the actual times and delay reasons one field at a time
+INSERT INTO editable_fields (field_name, value_type, max_length, is_active, description)
+VALUES
+    ('actualDepartureDateTime', 'datetime', NULL, TRUE, 'Actual departure time'),
+    ('actualArrivalDateTime', 'datetime', NULL, TRUE, 'Actual arrival time'),
+    ('delayReason', 'text', 255, TRUE, 'Why the train was late')
+ON DUPLICATE KEY UPDATE field_name = field_name;
+
+-- Delays up to this many minutes count as on time
+INSERT INTO system_settings (setting_key, setting_value, description)
+VALUES ('punctuality_on_time_minutes', '5', 'Longest delay in minutes that still counts as on time')
+ON DUPLICATE KEY UPDATE setting_key = setting_key;
+
+-- +goose Down
+DELETE FROM system_settings WHERE setting_key = 'punctuality_on_time_minutes';
+DELETE FROM editable_fields WHERE field_name IN ('actualDepartureDateTime', 'actualArrivalDateTime', 'delayReason');
+ALTER TABLE train_schedules
+    DROP COLUMN delay_reason,
+    DROP COLUMN actual_arrival_date_time,
+    DROP COLUMN actual_departure_date_time;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- Migration to move deleted train schedule records and stations to a trash bin
+-- Deleted rows keep their data with deleted_at set and can be restored until
+-- they are purged after trash_retention_days. A trashed station keeps its tracks
+
+ALTER TABLE train_schedules
+    ADD COLUMN deleted_at DATETIME NULL COMMENT 'When the record was moved to the trash' AFTER version,
+    ADD COLUMN deleted_by INT NULL COMMENT 'User who deleted the record' AFTER deleted_at,
+    ADD KEY idx_train_schedules_deleted (deleted_at);
+
+ALTER TABLE stations
+    ADD COLUMN deleted_at DATETIME NULL COMMENT 'When the station was moved to the trash' AFTER version,
+    ADD COLUMN deleted_by INT NULL COMMENT 'User who deleted the station' AFTER deleted_at,
+    ADD KEY idx_stations_deleted (deleted_at);
+
+-- Restoring a record from the trash is recorded in its history
+ALTER TABLE train_schedule_revisions
+    MODIFY COLUMN action ENUM('create', 'update', 'delete', 'revert', 'merge', 'restore') NOT NULL;
+
+INSERT INTO system_settings (setting_key, setting_value, description)
+VALUES ('trash_retention_days', '30', 'Days deleted schedule records and stations stay in the trash before they are purged')
+ON DUPLICATE KEY UPDATE setting_key = setting_key;
+
+-- +goose Down
+DELETE FROM system_settings WHERE setting_key = 'trash_retention_days';
+DELETE FROM train_schedule_revisions WHERE action = 'restore';
+ALTER TABLE train_schedule_revisions
+    MODIFY COLUMN action ENUM('create', 'update', 'delete', 'revert', 'merge') NOT NULL;
+DELETE FROM stations WHERE deleted_at IS NOT NULL;
+ALTER TABLE stations DROP KEY idx_stations_deleted, DROP COLUMN deleted_by, DROP COLUMN deleted_at;
+DELETE FROM train_schedules WHERE deleted_at IS NOT NULL;
+ALTER TABLE train_schedules DROP KEY idx_train_schedules_deleted, DROP COLUMN deleted_by, DROP COLUMN deleted_at;
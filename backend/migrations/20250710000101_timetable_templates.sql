@@ -0,0 +1,36 @@
+-- +goose Up
+-- Migration to create timetable_templates table
+-- Most trains repeat weekly within a timetable period. A template describes
+-- such a train once: its departure and arrival, the weekdays it runs on, the
+-- period it is valid for and its default tracks. The generator turns templates
+-- into train_schedules rows for any date range
+
+CREATE TABLE timetable_templates (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL DEFAULT '' COMMENT 'Label shown to planners',
+    working VARCHAR(50) NOT NULL COMMENT 'Vehicle working designation, part of the generated record IDs',
+    train_number_departure VARCHAR(50) NOT NULL DEFAULT '',
+    train_number_arrival VARCHAR(50) NOT NULL DEFAULT '',
+    vehicle_name VARCHAR(100) NOT NULL DEFAULT '',
+    starting_location VARCHAR(50) NOT NULL DEFAULT '',
+    end_location VARCHAR(50) NOT NULL DEFAULT '',
+    departure_time TIME NULL COMMENT 'Local (Vilnius) departure time on the service day',
+    arrival_time TIME NULL COMMENT 'Local (Vilnius) arrival time',
+    arrival_day_offset TINYINT NOT NULL DEFAULT 0 COMMENT 'Days between the service day and the arrival',
+    days_of_week TINYINT UNSIGNED NOT NULL DEFAULT 127 COMMENT 'Weekday mask: 1 = Monday, 2 = Tuesday, ..., 64 = Sunday',
+    valid_from DATE NOT NULL COMMENT 'First service day of the timetable period',
+    valid_to DATE NOT NULL COMMENT 'Last service day of the timetable period',
+    starting_track VARCHAR(50) NOT NULL DEFAULT '' COMMENT 'Default departure track',
+    target_track VARCHAR(50) NOT NULL DEFAULT '' COMMENT 'Default arrival track',
+    notes TEXT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    user_id INT NULL COMMENT 'User who created the template',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    KEY idx_timetable_templates_validity (valid_from, valid_to),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Recurring trains of timetable periods';
+
+-- +goose Down
+DROP TABLE IF EXISTS timetable_templates;
@@ -0,0 +1,43 @@
+-- +goose Up
+-- Migration to create depot_plans and depot_plan_transitions tables
+-- A depot plan is the schedule of one depot on one day: the records departing
+-- from or arriving at the depot that day. Planners prepare it as a draft and
+-- publish it when it is finished; published and archived plans are read-only
+-- until an admin reopens them, and viewers only see published plans.
+-- Every change of state is kept with the user and time
+
+CREATE TABLE depot_plans (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    station_id INT NOT NULL,
+    plan_date DATE NOT NULL COMMENT 'Local (Vilnius) day of the plan',
+    starts_at DATETIME NOT NULL COMMENT 'Start of the day in UTC',
+    ends_at DATETIME NOT NULL COMMENT 'End of the day in UTC (exclusive)',
+    status ENUM('draft', 'published', 'archived') NOT NULL DEFAULT 'draft',
+    version BIGINT NOT NULL DEFAULT 1 COMMENT 'Incremented on every change of state',
+    created_by INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY idx_depot_plans_day (station_id, plan_date),
+    KEY idx_depot_plans_status (status, starts_at),
+    FOREIGN KEY (station_id) REFERENCES stations(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Daily plans of depots';
+
+CREATE TABLE depot_plan_transitions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    plan_id INT NOT NULL,
+    from_status ENUM('draft', 'published', 'archived') NULL COMMENT 'NULL when the plan was created',
+    to_status ENUM('draft', 'published', 'archived') NOT NULL,
+    user_id INT NULL,
+    comment VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    KEY idx_depot_plan_transitions_plan (plan_id, id),
+    FOREIGN KEY (plan_id) REFERENCES depot_plans(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='State changes of depot plans';
+
+-- +goose Down
+DROP TABLE IF EXISTS depot_plan_transitions;
+DROP TABLE IF EXISTS depot_plans;
@@ -0,0 +1,31 @@
+-- +goose Up
+-- Migration to create track_assignments table
+-- A track assignment parks the vehicle of a train schedule record on a
+-- position of a depot track for a time. Dispatchers build the parking plan
+-- of a night from them on the timeline; unlike the free-text starting_track
+-- and target_track of the records they survive reloads and are shared
+-- between dispatchers
+
+CREATE TABLE track_assignments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    track_id INT NOT NULL,
+    position INT NOT NULL COMMENT 'Position on the track, from 1',
+    schedule_id VARCHAR(191) NOT NULL COMMENT 'Record whose vehicle takes the position',
+    starts_at DATETIME NOT NULL COMMENT 'When the vehicle takes the position (UTC)',
+    ends_at DATETIME NOT NULL COMMENT 'When the vehicle leaves the position (UTC, exclusive)',
+    notes VARCHAR(255) NOT NULL DEFAULT '',
+    version BIGINT NOT NULL DEFAULT 1 COMMENT 'Incremented on every change',
+    user_id INT NULL COMMENT 'User who last changed the assignment',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    KEY idx_track_assignments_track (track_id, starts_at),
+    KEY idx_track_assignments_schedule (schedule_id),
+    FOREIGN KEY (track_id) REFERENCES tracks(id) ON DELETE CASCADE,
+    -- Merging duplicates may rename the surviving record; its assignments follow it
+    FOREIGN KEY (schedule_id) REFERENCES train_schedules(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Vehicles parked on depot track positions';
+
+-- +goose Down
+DROP TABLE IF EXISTS track_assignments;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Migration to let dispatchers lock track assignments
+-- The parking planner proposes positions for the vehicles of a night; locked
+-- assignments were placed by hand and the planner keeps them as they are
+
+ALTER TABLE track_assignments
+    ADD COLUMN locked BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'Placed by hand; the parking planner keeps it' AFTER notes;
+
+-- +goose Down
+ALTER TABLE track_assignments DROP COLUMN locked;